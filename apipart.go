@@ -0,0 +1,276 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrPartNotFound the part does not exist in the package
+	ErrPartNotFound = errors.New("part not found")
+	// ErrPartExists the part to be added already exists in the package
+	ErrPartExists = errors.New("part already exists")
+)
+
+// Parts lists the names of the parts to be packed, like word/document.xml,
+// in the order of names
+func (f *Docx) Parts() []string {
+	set := make(map[string]struct{}, 64)
+	for _, name := range f.tmpfslst {
+		set[name] = struct{}{}
+	}
+	for name := range f.parts {
+		set[name] = struct{}{}
+	}
+	for _, m := range f.modelParts {
+		if !emptyRelationships(m) {
+			set[m.name] = struct{}{}
+		}
+	}
+	if f.styles != nil {
+		set["word/styles.xml"] = struct{}{}
+	}
+	if f.numbering != nil {
+		set["word/numbering.xml"] = struct{}{}
+	}
+	for _, m := range f.media {
+		set[m.String()] = struct{}{}
+	}
+	set["word/document.xml"] = struct{}{}
+	set["word/_rels/document.xml.rels"] = struct{}{}
+	set["[Content_Types].xml"] = struct{}{}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Part reads the raw data of the part name. The parts modelled by the
+// library, like word/document.xml, are marshalled from their models.
+func (f *Docx) Part(name string) ([]byte, error) {
+	name = strings.TrimPrefix(name, "/")
+	var model interface{}
+	switch name {
+	case "word/document.xml":
+		model = &f.Document
+	case "word/_rels/document.xml.rels":
+		model = &f.docRelation
+	case "word/styles.xml":
+		if f.styles != nil {
+			model = f.styles
+		}
+	case "word/numbering.xml":
+		if f.numbering != nil {
+			model = f.numbering
+		}
+	case "[Content_Types].xml":
+		if f.contentTypes != nil {
+			model = f.contentTypes
+		}
+	}
+	for i := len(f.modelParts) - 1; model == nil && i >= 0; i-- {
+		if f.modelParts[i].name == name {
+			model = f.modelParts[i].data
+		}
+	}
+	if model != nil {
+		return (&modelPart{data: model}).marshal()
+	}
+	if strings.HasPrefix(name, MEDIA_FOLDER) {
+		if m := f.Media(name[len(MEDIA_FOLDER):]); m != nil {
+			return m.Load()
+		}
+		return nil, ErrPartNotFound
+	}
+	if !f.hasTemplateFile(name) {
+		return nil, ErrPartNotFound
+	}
+	file, err := f.openTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(f.limits.limitReader(name, file))
+}
+
+// PutPart replaces the raw data of the part name, or adds it if missing.
+// The models of the part, like the one returned by Styles, are parsed
+// again from data on the next call.
+func (f *Docx) PutPart(name string, data []byte) error {
+	name = strings.TrimPrefix(name, "/")
+	switch {
+	case name == "word/document.xml":
+		f.Document = Document{}
+		return f.parseDocument(name, bytes.NewReader(data))
+	case name == "word/_rels/document.xml.rels":
+		f.docRelation = Relationships{}
+		return f.parseDocRelation(name, bytes.NewReader(data))
+	case strings.HasPrefix(name, MEDIA_FOLDER):
+		if m := f.Media(name[len(MEDIA_FOLDER):]); m != nil {
+			m.Data = data
+			return nil
+		}
+		f.addMedia(Media{Name: name[len(MEDIA_FOLDER):], Data: data})
+		return nil
+	}
+	if f.parts == nil {
+		f.parts = make(map[string][]byte, 8)
+	}
+	f.parts[name] = data
+	_, err := f.reloadModel(name)
+	return err
+}
+
+// AddPart adds the new part name of contenttype, which is registered
+// as an Override in [Content_Types].xml.
+func (f *Docx) AddPart(name, contenttype string, data []byte) error {
+	name = strings.TrimPrefix(name, "/")
+	for _, n := range f.Parts() {
+		if n == name {
+			return ErrPartExists
+		}
+	}
+	ct, err := f.ContentTypes()
+	if err != nil {
+		return err
+	}
+	err = f.PutPart(name, data)
+	if err != nil {
+		return err
+	}
+	ct.SetOverride(name, contenttype)
+	return nil
+}
+
+// RemovePart removes the part name and its relationships part from the package.
+// The relationships referring to it are kept.
+func (f *Docx) RemovePart(name string) error {
+	name = strings.TrimPrefix(name, "/")
+	switch name {
+	case "word/document.xml", "word/_rels/document.xml.rels", "[Content_Types].xml":
+		return errors.New("cannot remove " + name)
+	}
+	found := false
+	if strings.HasPrefix(name, MEDIA_FOLDER) {
+		if i, ok := f.mediaNameIdx[name[len(MEDIA_FOLDER):]]; ok {
+			f.media = append(f.media[:i], f.media[i+1:]...)
+			f.mediaNameIdx = make(map[string]int, len(f.media))
+			for i, m := range f.media {
+				f.mediaNameIdx[m.Name] = i
+			}
+			found = true
+		}
+	}
+	if f.hasTemplateFile(name) {
+		found = true
+	}
+	delete(f.parts, name)
+	if ok, _ := f.reloadModel(name); ok {
+		found = true
+		parts := f.modelParts[:0]
+		for _, m := range f.modelParts {
+			if m.name != name {
+				parts = append(parts, m)
+			}
+		}
+		f.modelParts = parts
+	}
+	// tmpfslst may be shared with other files
+	lst := make([]string, 0, len(f.tmpfslst))
+	for _, n := range f.tmpfslst {
+		if n != name {
+			lst = append(lst, n)
+		}
+	}
+	f.tmpfslst = lst
+	if !found {
+		return ErrPartNotFound
+	}
+	if !strings.HasSuffix(name, ".rels") {
+		_ = f.RemovePart(relationshipsName(name))
+	}
+	return nil
+}
+
+// reloadModel parses the models of the part name again from the raw part,
+// keeping the pointers returned before, and reports whether name is a model part
+func (f *Docx) reloadModel(name string) (bool, error) {
+	found := false
+	switch name {
+	case "word/styles.xml":
+		found, f.styles, f.cachedStyles = f.styles != nil, nil, nil
+	case "word/numbering.xml":
+		found, f.numbering, f.cachedNumbering = f.numbering != nil, nil, nil
+	case "[Content_Types].xml":
+		found, f.contentTypes = f.contentTypes != nil, nil
+	}
+	for _, m := range f.modelParts {
+		if m.name != name {
+			continue
+		}
+		found = true
+		switch d := m.data.(type) {
+		case *Header:
+			d.Items = nil
+		case *Footer:
+			d.Items = nil
+		case *Comments:
+			d.Comments = nil
+		case *Footnotes:
+			d.Footnotes = nil
+		case *Relationships:
+			d.Relationship = nil
+		}
+		if _, ok := f.parts[name]; !ok {
+			continue // the part is removed
+		}
+		err := f.parseModelPart(m, m.data)
+		if err != nil {
+			return found, err
+		}
+	}
+	return found, nil
+}
+
+// relationshipsName returns the name of the relationships part of the part name,
+// or _rels/.rels of the package if name is empty
+func relationshipsName(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "_rels/.rels"
+	}
+	return path.Join(path.Dir(name), "_rels", path.Base(name)+".rels")
+}
+
+// emptyRelationships checks whether m is a relationships part without
+// any relationship, which is not packed if it is new
+func emptyRelationships(m *modelPart) bool {
+	rels, ok := m.data.(*Relationships)
+	return ok && len(rels.Relationship) == 0
+}
@@ -0,0 +1,131 @@
+package docx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParts(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("parts")
+	parts := strings.Join(f.Parts(), ",")
+	for _, exp := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml"} {
+		if !strings.Contains(parts, exp) {
+			t.Fatal("missing", exp, "in", parts)
+		}
+	}
+
+	const (
+		relCustomXML  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXml"
+		relItemProps  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXmlProps"
+		ctItemProps   = "application/vnd.openxmlformats-officedocument.customXmlProperties+xml"
+		relCustomProp = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	)
+	item := []byte(`<?xml version="1.0" encoding="UTF-8"?><data>42</data>`)
+	err := f.AddPart("customXml/item1.xml", CONTENT_TYPE_XML, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.AddPart("/customXml/item1.xml", CONTENT_TYPE_XML, item) != ErrPartExists {
+		t.Fatal("unexpected success")
+	}
+	err = f.AddPart("customXml/itemProps1.xml", ctItemProps, []byte(`<ds:datastoreItem xmlns:ds="http://schemas.openxmlformats.org/officeDocument/2006/customXml"/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := f.AddPartRelationship("word/document.xml", relCustomXML, "../customXml/item1.xml", false)
+	if err != nil || id != "rId4" {
+		t.Fatal("unexpected id", id, err)
+	}
+	id, err = f.AddPartRelationship("customXml/item1.xml", relItemProps, "itemProps1.xml", false)
+	if err != nil || id != "rId1" {
+		t.Fatal("unexpected id", id, err)
+	}
+	err = f.AddPart("docProps/custom.xml", partContentTypes["docProps/custom.xml"], []byte(`<Properties/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.AddPartRelationship("", relCustomProp, "docProps/custom.xml", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.PartRelationships("word/styles.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the raw styles and the model follows
+	styles, err := f.Part("word/styles.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.LastIndex(styles, []byte("</w:styles>"))
+	styles = append(styles[:i:i], `<w:style w:type="paragraph" w:customStyle="1" w:styleId="Custom"><w:name w:val="Custom"/></w:style></w:styles>`...)
+	err = f.PutPart("word/styles.xml", styles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := f.Styles()
+	if err != nil || s.Styles[len(s.Styles)-1].StyleID != "Custom" {
+		t.Fatal("unexpected styles", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := nf.Part("/customXml/item1.xml")
+	if err != nil || !bytes.Equal(data, item) {
+		t.Fatal("unexpected part", err, string(data))
+	}
+	rels, err := nf.PartRelationships("customXml/item1.xml")
+	if err != nil || len(rels.Relationship) != 1 || rels.Relationship[0].Target != "itemProps1.xml" {
+		t.Fatal("unexpected relationships", err, rels)
+	}
+	rels, err = nf.PartRelationships("")
+	if err != nil || rels.Relationship[len(rels.Relationship)-1].Type != relCustomProp {
+		t.Fatal("unexpected package relationships", err, rels)
+	}
+	target, err := nf.ReferTarget("rId4")
+	if err != nil || target != "../customXml/item1.xml" {
+		t.Fatal("unexpected target", target, err)
+	}
+	ct, err := nf.ContentTypes()
+	if err != nil || ct.Lookup("customXml/itemProps1.xml") != ctItemProps {
+		t.Fatal("unexpected content types", err)
+	}
+	s, err = nf.Styles()
+	if err != nil || s.Styles[len(s.Styles)-1].StyleID != "Custom" {
+		t.Fatal("unexpected styles", err)
+	}
+	parts = strings.Join(nf.Parts(), ",")
+	if strings.Contains(parts, "word/_rels/styles.xml.rels") {
+		t.Fatal("unexpected empty relationships in", parts)
+	}
+
+	err = nf.RemovePart("customXml/item1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts = strings.Join(nf.Parts(), ",")
+	if strings.Contains(parts, "customXml/item1.xml") || strings.Contains(parts, "customXml/_rels/item1.xml.rels") {
+		t.Fatal("unexpected removed part in", parts)
+	}
+	if !errors.Is(nf.RemovePart("customXml/item1.xml"), ErrPartNotFound) {
+		t.Fatal("unexpected success")
+	}
+	err = nf.RemovePartRelationship("word/document.xml", "rId4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nf.RemovePartRelationship("word/document.xml", "rId4") != ErrRefIDNotFound {
+		t.Fatal("unexpected success")
+	}
+}
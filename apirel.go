@@ -20,6 +20,12 @@
 
 package docx
 
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
 // RangeRelationships goes through each doc relation
 func (f *Docx) RangeRelationships(iter func(*Relationship) error) error {
 	for _, r := range f.docRelation.Relationship {
@@ -30,3 +36,72 @@ func (f *Docx) RangeRelationships(iter func(*Relationship) error) error {
 	}
 	return nil
 }
+
+// PartRelationships returns the relationships of the part name, like
+// word/header1.xml, or of the package if name is empty. They are parsed
+// on first call and the changes to them will be written back on packing.
+func (f *Docx) PartRelationships(name string) (*Relationships, error) {
+	name = strings.TrimPrefix(name, "/")
+	if name == "word/document.xml" {
+		return &f.docRelation, nil
+	}
+	relsName := relationshipsName(name)
+	for _, m := range f.modelParts {
+		if r, ok := m.data.(*Relationships); ok && m.name == relsName {
+			return r, nil
+		}
+	}
+	rels := &Relationships{Xmlns: XMLNS_REL}
+	if !f.hasTemplateFile(relsName) {
+		f.newModelPart(relsName, rels, "")
+		return rels, nil
+	}
+	_, err := f.loadModelPart(relsName, rels)
+	if err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+// AddPartRelationship adds a relationship of typ from the part name
+// (the package if empty) to target, which is a URL if external is set,
+// or a part name relative to the folder of the part. It returns the new rId.
+func (f *Docx) AddPartRelationship(name, typ, target string, external bool) (string, error) {
+	rels, err := f.PartRelationships(name)
+	if err != nil {
+		return "", err
+	}
+	rel := Relationship{Type: typ, Target: target}
+	if external {
+		rel.TargetMode = REL_TARGETMODE
+	}
+	if rels == &f.docRelation {
+		rel.ID = "rId" + strconv.Itoa(int(atomic.AddUintptr(&f.rID, 1)))
+	} else {
+		n := 0
+		for _, r := range rels.Relationship {
+			if i, err := strconv.Atoi(strings.TrimPrefix(r.ID, "rId")); err == nil && i > n {
+				n = i
+			}
+		}
+		rel.ID = "rId" + strconv.Itoa(n+1)
+	}
+	rels.Relationship = append(rels.Relationship, rel)
+	return rel.ID, nil
+}
+
+// RemovePartRelationship removes the relationship id from the part name
+// (the package if empty)
+func (f *Docx) RemovePartRelationship(name, id string) error {
+	rels, err := f.PartRelationships(name)
+	if err != nil {
+		return err
+	}
+	for i, r := range rels.Relationship {
+		if r.ID == id {
+			rels.Relationship = append(rels.Relationship[:i], rels.Relationship[i+1:]...)
+			return nil
+		}
+	}
+	return ErrRefIDNotFound
+}
@@ -0,0 +1,324 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openTemplate opens a file of the template or the parsed package by its name in the docx
+func (f *Docx) openTemplate(name string) (fs.File, error) {
+	if data, ok := f.parts[name]; ok {
+		return memFS{name: data}.Open(name)
+	}
+	if f.tmplfs == nil {
+		return nil, fs.ErrNotExist
+	}
+	if f.template != "" {
+		return f.tmplfs.Open("xml/" + f.template + "/" + name)
+	}
+	return f.tmplfs.Open(name)
+}
+
+// decodeTemplate decodes the part name opened by openTemplate into v
+// within the limits of f
+func (f *Docx) decodeTemplate(name string, v interface{}) error {
+	file, err := f.openTemplate(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return f.limits.newDecoder(name, f.limits.limitReader(name, file)).Decode(v)
+}
+
+// hasTemplateFile checks whether name will be packed from the template or the raw parts
+func (f *Docx) hasTemplateFile(name string) bool {
+	if _, ok := f.parts[name]; ok {
+		return true
+	}
+	for _, n := range f.tmpfslst {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Styles parses word/styles.xml on first call and returns it.
+// Changes to it will be written back on packing.
+func (f *Docx) Styles() (*Styles, error) {
+	if f.styles == nil {
+		s, err := f.readStyles()
+		if err != nil {
+			return nil, err
+		}
+		f.styles, f.cachedStyles = s, nil
+	}
+	return f.styles, nil
+}
+
+// readStyles returns the styles for reading only,
+// which will not be written back on packing unless loaded by Styles
+func (f *Docx) readStyles() (*Styles, error) {
+	if f.styles != nil {
+		return f.styles, nil
+	}
+	if f.cachedStyles != nil {
+		return f.cachedStyles, nil
+	}
+	s := &Styles{}
+	if f.hasTemplateFile("word/styles.xml") {
+		err := f.decodeTemplate("word/styles.xml", s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.cachedStyles = s
+	return s, nil
+}
+
+// Numbering parses word/numbering.xml on first call and returns it.
+// An empty one will be created if the docx has no numbering,
+// and its relationship will be added on packing.
+// Changes to it will be written back on packing.
+func (f *Docx) Numbering() (*Numbering, error) {
+	if f.numbering == nil {
+		n, err := f.readNumbering()
+		if err != nil {
+			return nil, err
+		}
+		f.numbering, f.cachedNumbering = n, nil
+	}
+	return f.numbering, nil
+}
+
+// readNumbering returns the numbering for reading only,
+// which will not be written back on packing unless loaded by Numbering
+func (f *Docx) readNumbering() (*Numbering, error) {
+	if f.numbering != nil {
+		return f.numbering, nil
+	}
+	if f.cachedNumbering != nil {
+		return f.cachedNumbering, nil
+	}
+	n := &Numbering{}
+	if f.hasTemplateFile("word/numbering.xml") {
+		err := f.decodeTemplate("word/numbering.xml", n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.cachedNumbering = n
+	return n, nil
+}
+
+// ensureNumberingRelation adds the numbering relationship if missing
+//
+//	this func is not thread-safe
+func (f *Docx) ensureNumberingRelation() {
+	for _, r := range f.docRelation.Relationship {
+		if r.Type == REL_NUMBERING {
+			return
+		}
+	}
+	f.addRelation(REL_NUMBERING, "numbering.xml")
+}
+
+var headingStyleRe = regexp.MustCompile(`^(?i)heading\s?([1-9])$`)
+
+// HeadingLevel returns the heading level (1-9) of p by its
+// paragraph style, or 0 if p is not a heading.
+//
+// The style is matched by the built-in names "heading 1"
+// to "heading 9" through its basedOn chain, or by ids like
+// Heading1 if the styles are unavailable.
+func (f *Docx) HeadingLevel(p *Paragraph) int {
+	if p == nil || p.Properties == nil || p.Properties.Style == nil {
+		return 0
+	}
+	id := p.Properties.Style.Val
+	if f != nil {
+		s, err := f.readStyles()
+		if err == nil {
+			for i := 0; i < 16 && id != ""; i++ {
+				st := s.Get(id)
+				if st == nil {
+					break
+				}
+				sub := headingStyleRe.FindStringSubmatch(st.Name)
+				if len(sub) > 1 {
+					return int(sub[1][0] - '0')
+				}
+				id = st.BasedOn
+			}
+		}
+	}
+	sub := headingStyleRe.FindStringSubmatch(p.Properties.Style.Val)
+	if len(sub) > 1 {
+		return int(sub[1][0] - '0')
+	}
+	return 0
+}
+
+// ListLevel returns the numId, level and numFmt (like decimal or bullet)
+// of p, or an empty numID if p is not in a list.
+func (f *Docx) ListLevel(p *Paragraph) (numID string, ilvl int, numFmt string) {
+	if p == nil || p.Properties == nil || p.Properties.NumProperties == nil ||
+		p.Properties.NumProperties.NumID == nil {
+		return
+	}
+	numID = p.Properties.NumProperties.NumID.Val
+	if numID == "" || numID == "0" { // numId 0 removes the numbering
+		return "", 0, ""
+	}
+	if p.Properties.NumProperties.Ilvl != nil {
+		ilvl, _ = strconv.Atoi(p.Properties.NumProperties.Ilvl.Val)
+	}
+	if f == nil || !f.hasNumbering() {
+		return
+	}
+	n, err := f.readNumbering()
+	if err != nil {
+		return
+	}
+	if num := n.Num(numID); num != nil {
+		if a := n.AbstractNum(num.AbstractNumID); a != nil {
+			numFmt = a.NumFmt(ilvl)
+		}
+	}
+	return
+}
+
+// listStart returns the start value of level ilvl of numID, taking its startOverride
+func (f *Docx) listStart(numID string, ilvl int) int {
+	if f == nil || !f.hasNumbering() {
+		return 1
+	}
+	n, err := f.readNumbering()
+	if err != nil {
+		return 1
+	}
+	num := n.Num(numID)
+	if num == nil {
+		return 1
+	}
+	if s := num.startOverride(ilvl); s > 0 {
+		return s
+	}
+	if a := n.AbstractNum(num.AbstractNumID); a != nil {
+		return a.start(ilvl)
+	}
+	return 1
+}
+
+// addStyle adds a style of typ with name and raw props (<w:pPr>, <w:rPr>...),
+// or returns the id of the existing style of the same type and name.
+// The id is suffixed by a number if it is taken by another style.
+func (f *Docx) addStyle(typ, id, name string, custom bool, props string) (string, error) {
+	s, err := f.Styles()
+	if err != nil {
+		return "", err
+	}
+	if st := s.GetByName(typ, name); st != nil {
+		return st.StyleID, nil
+	}
+	nid := id
+	for i := 1; s.Get(nid) != nil; i++ {
+		nid = id + strconv.Itoa(i)
+	}
+	elems, err := splitRawElements(`<w:qFormat/>` + props)
+	if err != nil {
+		return "", err
+	}
+	st := &StyleDefinition{Type: typ, StyleID: nid, CustomStyle: custom, Name: name, elems: elems}
+	if def := s.Default(typ); def != nil {
+		st.BasedOn = def.StyleID
+		if typ == "paragraph" {
+			st.Next = def.StyleID
+		}
+	}
+	s.Styles = append(s.Styles, st)
+	return nid, nil
+}
+
+const (
+	listBulletNsid  = "4D440001"
+	listOrderedNsid = "4D440002"
+)
+
+// AddListNumbering adds a new list into the numbering and returns
+// its numId to be used in Paragraph.NumPr.
+//
+// Ordered lists are numbered as decimal, lowerLetter and lowerRoman
+// by levels, others use bullets. Level i of an ordered list starts
+// from starts[i], or from 1 if it is missing or not positive.
+func (f *Docx) AddListNumbering(ordered bool, starts ...int) (string, error) {
+	n, err := f.Numbering()
+	if err != nil {
+		return "", err
+	}
+	nsid := listBulletNsid
+	if ordered {
+		nsid = listOrderedNsid
+	}
+	var a *AbstractNum
+	for _, x := range n.AbstractNums {
+		if x.Nsid == nsid {
+			a = x
+			break
+		}
+	}
+	if a == nil {
+		sb := strings.Builder{}
+		sb.WriteString(`<w:multiLevelType w:val="hybridMultilevel"/>`)
+		for i := 0; i < 9; i++ {
+			lvl := strconv.Itoa(i)
+			numFmt, text := "bullet", [...]string{"•", "◦", "▪"}[i%3]
+			if ordered {
+				numFmt, text = [...]string{"decimal", "lowerLetter", "lowerRoman"}[i%3], "%"+strconv.Itoa(i+1)+"."
+			}
+			sb.WriteString(`<w:lvl w:ilvl="` + lvl + `"><w:start w:val="1"/><w:numFmt w:val="` + numFmt +
+				`"/><w:lvlText w:val="` + text + `"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="` +
+				strconv.Itoa(720*(i+1)) + `" w:hanging="360"/></w:pPr></w:lvl>`)
+		}
+		elems, err := splitRawElements(sb.String())
+		if err != nil {
+			return "", err
+		}
+		a = &AbstractNum{AbstractNumID: strconv.Itoa(n.nextAbstractNumID()), Nsid: nsid, elems: elems}
+		n.AbstractNums = append(n.AbstractNums, a)
+	}
+	num := &Num{NumID: strconv.Itoa(n.nextNumID()), AbstractNumID: a.AbstractNumID}
+	if ordered {
+		for i := 0; i < 9; i++ {
+			start := 1
+			if i < len(starts) && starts[i] > 0 {
+				start = starts[i]
+			}
+			num.StartOverride(i, start)
+		}
+	}
+	n.Nums = append(n.Nums, num)
+	return num.NumID, nil
+}
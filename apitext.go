@@ -42,6 +42,16 @@ func (p *Paragraph) AddText(text string) *Run {
 	if text == "\t" {
 		return p.AddTab()
 	}
+	run := &Run{
+		RunProperties: &RunProperties{},
+		Children:      textChildren(text),
+	}
+	p.Children = append(p.Children, run)
+	return run
+}
+
+// textChildren converts text into run children of Text, Tab and BarterRabbet
+func textChildren(text string) []interface{} {
 	c := make([]interface{}, 0, 64)
 	for i, s := range strings.Split(text, "\n") {
 		if i > 0 {
@@ -58,10 +68,5 @@ func (p *Paragraph) AddText(text string) *Run {
 			}
 		}
 	}
-	run := &Run{
-		RunProperties: &RunProperties{},
-		Children:      c,
-	}
-	p.Children = append(p.Children, run)
-	return run
+	return c
 }
@@ -0,0 +1,111 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// BodyReader decodes the items in the body of a docx one by one
+// without loading the whole document into memory
+type BodyReader struct {
+	f    *Docx
+	file io.ReadCloser
+	d    *xml.Decoder
+	body bool // body is true after <w:body> being read
+	done bool
+}
+
+// NewBodyReader opens the docx in r for reading its body items by Next.
+//
+// The relationships are parsed at once while the media are read
+// from r on demand, so r should be kept open while they are used.
+func NewBodyReader(r io.ReaderAt, size int64) (*BodyReader, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(zipReader.File))
+	for _, f := range zipReader.File {
+		if !strings.HasSuffix(f.Name, "/") && f.Name != "word/document.xml" {
+			names = append(names, f.Name)
+		}
+	}
+	f, err := unpackFS(zipReader, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	f.Document.Body.file = f
+	f.docID = 100000
+	file, err := zipReader.Open("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	return &BodyReader{f: f, file: file, d: xml.NewDecoder(file)}, nil
+}
+
+// Next returns the next *Paragraph, *Table or *SectPr in the body,
+// or io.EOF if there are no more items.
+func (br *BodyReader) Next() (interface{}, error) {
+	for !br.done {
+		t, err := br.d.Token()
+		if err == io.EOF {
+			br.done = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			if !br.body {
+				br.body = tt.Name.Local == "body"
+				continue
+			}
+			item, err := decodeBodyItem(br.d, &tt, br.f)
+			if err != nil {
+				return nil, err
+			}
+			if item != nil {
+				return item, nil
+			}
+		case xml.EndElement:
+			if br.body && tt.Name.Local == "body" {
+				br.done = true
+			}
+		}
+	}
+	return nil, io.EOF
+}
+
+// Docx returns the file without body items that the items belong to,
+// holding the relationships and media of the document
+func (br *BodyReader) Docx() *Docx {
+	return br.f
+}
+
+// Close closes the underlying word/document.xml
+func (br *BodyReader) Close() error {
+	return br.file.Close()
+}
@@ -0,0 +1,79 @@
+package docx
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBodyReader(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("first")
+	tbl := f.AddTable(2, 2, 0, nil)
+	tbl.TableRows[0].TableCells[0].AddParagraph().AddText("cell")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddParagraph().AddText("last")
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	br, err := NewBodyReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	for _, m := range br.Docx().media {
+		if m.Data != nil {
+			t.Fatal("media loaded in advance", m.Name)
+		}
+	}
+	var items []interface{}
+	for {
+		item, err := br.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != len(f.Document.Body.Items) {
+		t.Fatal("unexpected item count", len(items))
+	}
+	if p, ok := items[0].(*Paragraph); !ok || p.String() != "first" {
+		t.Fatal("unexpected first item", items[0])
+	}
+	if _, ok := items[1].(*Table); !ok {
+		t.Fatal("unexpected second item", items[1])
+	}
+	if p, ok := items[3].(*Paragraph); !ok || p.String() != "last" {
+		t.Fatal("unexpected last item", items[3])
+	}
+	var name string
+	for _, c := range items[2].(*Paragraph).Children {
+		r, ok := c.(*Run)
+		if !ok {
+			continue
+		}
+		for _, rc := range r.Children {
+			if d, ok := rc.(*Drawing); ok {
+				tgt, err := br.Docx().ReferTarget(d.Inline.Graphic.GraphicData.Pic.BlipFill.Blip.Embed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				name = tgt[len("media/"):]
+			}
+		}
+	}
+	if !bytes.Equal(br.Docx().mediaData(name), f.media[0].Data) {
+		t.Fatal("unexpected media", name)
+	}
+	if _, err = br.Next(); err != io.EOF {
+		t.Fatal("unexpected", err)
+	}
+}
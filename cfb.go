@@ -0,0 +1,202 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrInvalidCFB the data is not a valid Compound File Binary
+var ErrInvalidCFB = errors.New("invalid compound file data")
+
+// cfbSignature starts the header of a Compound File Binary
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbNoStream   = 0xFFFFFFFF
+)
+
+// cfbEntry is an entry in the directory of a Compound File Binary
+type cfbEntry struct {
+	name               string
+	typ                byte // typ is 1 for storages, 2 for streams and 5 for the root
+	left, right, child uint32
+	start              uint32
+	size               uint64
+}
+
+// cfbFile is a Compound File Binary (OLE2 structured storage) read in memory
+type cfbFile struct {
+	data       []byte
+	shift      uint // shift is the sector shift
+	fat        []uint32
+	minifat    []uint32
+	entries    []cfbEntry
+	ministream []byte
+}
+
+// openCFB reads the FAT, the directory and the mini stream of data
+func openCFB(data []byte) (*cfbFile, error) {
+	if len(data) < 512 || !bytes.Equal(data[:8], cfbSignature) {
+		return nil, ErrInvalidCFB
+	}
+	le := binary.LittleEndian
+	c := &cfbFile{data: data, shift: uint(le.Uint16(data[0x1E:]))}
+	if c.shift != 9 && c.shift != 12 {
+		return nil, ErrInvalidCFB
+	}
+	ssz := 1 << c.shift
+	// the FAT sectors are listed by the 109 DIFAT entries in the header and the DIFAT sectors
+	var fatSectors []uint32
+	for i := 0; i < 109; i++ {
+		fatSectors = append(fatSectors, le.Uint32(data[0x4C+i*4:]))
+	}
+	next := le.Uint32(data[0x44:])
+	for n := int(le.Uint32(data[0x48:])); n > 0 && next < cfbEndOfChain; n-- {
+		sec := c.sector(next)
+		if sec == nil {
+			return nil, ErrInvalidCFB
+		}
+		for i := 0; i < ssz/4-1; i++ {
+			fatSectors = append(fatSectors, le.Uint32(sec[i*4:]))
+		}
+		next = le.Uint32(sec[ssz-4:])
+	}
+	for _, s := range fatSectors {
+		if s >= cfbEndOfChain {
+			continue
+		}
+		sec := c.sector(s)
+		if sec == nil {
+			return nil, ErrInvalidCFB
+		}
+		for i := 0; i < ssz/4; i++ {
+			c.fat = append(c.fat, le.Uint32(sec[i*4:]))
+		}
+	}
+	dir := c.readChain(le.Uint32(data[0x30:]), c.fat, ssz, nil, 0)
+	for i := 0; i+128 <= len(dir); i += 128 {
+		e := dir[i : i+128]
+		n := int(le.Uint16(e[0x40:]))
+		if n > 64 {
+			n = 64
+		}
+		u := make([]uint16, 0, 32)
+		for k := 0; k+2 <= n; k += 2 {
+			if v := le.Uint16(e[k:]); v != 0 {
+				u = append(u, v)
+			}
+		}
+		c.entries = append(c.entries, cfbEntry{
+			name:  string(utf16.Decode(u)),
+			typ:   e[0x42],
+			left:  le.Uint32(e[0x44:]),
+			right: le.Uint32(e[0x48:]),
+			child: le.Uint32(e[0x4C:]),
+			start: le.Uint32(e[0x74:]),
+			size:  le.Uint64(e[0x78:]),
+		})
+	}
+	if len(c.entries) == 0 || c.entries[0].typ != 5 {
+		return nil, ErrInvalidCFB
+	}
+	if c.shift == 9 {
+		// the high part of the size may be garbage in version 3
+		for i := range c.entries {
+			c.entries[i].size &= 0xFFFFFFFF
+		}
+	}
+	minifat := c.readChain(le.Uint32(data[0x3C:]), c.fat, ssz, nil, 0)
+	for i := 0; i+4 <= len(minifat); i += 4 {
+		c.minifat = append(c.minifat, le.Uint32(minifat[i:]))
+	}
+	root := &c.entries[0]
+	c.ministream = c.readChain(root.start, c.fat, ssz, nil, root.size)
+	return c, nil
+}
+
+// sector returns the sector n, or nil if it is out of the file
+func (c *cfbFile) sector(n uint32) []byte {
+	start := (uint64(n) + 1) << c.shift
+	end := start + 1<<c.shift
+	if end > uint64(len(c.data)) {
+		return nil
+	}
+	return c.data[start:end]
+}
+
+// readChain reads the sectors of size ssz in the chain of fat from start,
+// up to size bytes if it is not 0. The sectors are in src, or in the file if src is nil.
+func (c *cfbFile) readChain(start uint32, fat []uint32, ssz int, src []byte, size uint64) []byte {
+	var buf []byte
+	// a chain cannot be longer than the table, which also breaks the loops
+	for n := 0; start < cfbEndOfChain && n <= len(fat); n++ {
+		var sec []byte
+		if src == nil {
+			sec = c.sector(start)
+		} else if end := (int(start) + 1) * ssz; end <= len(src) {
+			sec = src[end-ssz : end]
+		}
+		if sec == nil {
+			break
+		}
+		buf = append(buf, sec...)
+		if size > 0 && uint64(len(buf)) >= size {
+			return buf[:size]
+		}
+		if int(start) >= len(fat) {
+			break
+		}
+		start = fat[start]
+	}
+	return buf
+}
+
+// stream returns the data of the stream name (case-insensitive) in the root storage
+func (c *cfbFile) stream(name string) ([]byte, bool) {
+	var find func(id uint32, depth int) *cfbEntry
+	find = func(id uint32, depth int) *cfbEntry {
+		if id == cfbNoStream || int(id) >= len(c.entries) || depth > len(c.entries) {
+			return nil
+		}
+		e := &c.entries[id]
+		if strings.EqualFold(e.name, name) {
+			return e
+		}
+		if f := find(e.left, depth+1); f != nil {
+			return f
+		}
+		return find(e.right, depth+1)
+	}
+	e := find(c.entries[0].child, 0)
+	if e == nil || e.typ != 2 {
+		return nil, false
+	}
+	if e.size < 4096 {
+		return c.readChain(e.start, c.minifat, 64, c.ministream, e.size), true
+	}
+	return c.readChain(e.start, c.fat, 1<<c.shift, nil, e.size), true
+}
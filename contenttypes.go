@@ -0,0 +1,265 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+//nolint:revive,stylecheck
+const (
+	XMLNS_CONTENT_TYPES = `http://schemas.openxmlformats.org/package/2006/content-types`
+
+	CONTENT_TYPE_RELS = "application/vnd.openxmlformats-package.relationships+xml"
+	CONTENT_TYPE_XML  = "application/xml"
+)
+
+// partContentTypes is the content types of the known parts by their names without numbers
+var partContentTypes = map[string]string{
+	"word/document.xml":          "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml",
+	"word/styles.xml":            "application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml",
+	"word/stylesWithEffects.xml": "application/vnd.ms-word.stylesWithEffects+xml",
+	"word/numbering.xml":         "application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml",
+	"word/settings.xml":          "application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml",
+	"word/webSettings.xml":       "application/vnd.openxmlformats-officedocument.wordprocessingml.webSettings+xml",
+	"word/fontTable.xml":         "application/vnd.openxmlformats-officedocument.wordprocessingml.fontTable+xml",
+	"word/header.xml":            "application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml",
+	"word/footer.xml":            "application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml",
+	"word/footnotes.xml":         "application/vnd.openxmlformats-officedocument.wordprocessingml.footnotes+xml",
+	"word/endnotes.xml":          "application/vnd.openxmlformats-officedocument.wordprocessingml.endnotes+xml",
+	"word/comments.xml":          "application/vnd.openxmlformats-officedocument.wordprocessingml.comments+xml",
+	"word/commentsExtended.xml":  "application/vnd.openxmlformats-officedocument.wordprocessingml.commentsExtended+xml",
+	"word/commentsIds.xml":       "application/vnd.openxmlformats-officedocument.wordprocessingml.commentsIds+xml",
+	"word/people.xml":            "application/vnd.openxmlformats-officedocument.wordprocessingml.people+xml",
+	"word/glossary/document.xml": "application/vnd.openxmlformats-officedocument.wordprocessingml.document.glossary+xml",
+	"word/theme/theme.xml":       "application/vnd.openxmlformats-officedocument.theme+xml",
+	"docProps/core.xml":          "application/vnd.openxmlformats-package.core-properties+xml",
+	"docProps/app.xml":           "application/vnd.openxmlformats-officedocument.extended-properties+xml",
+	"docProps/custom.xml":        "application/vnd.openxmlformats-officedocument.custom-properties+xml",
+}
+
+// extContentTypes is the content types of the media by their extensions
+var extContentTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"jpg":  "image/jpeg",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tif":  "image/tiff",
+	"tiff": "image/tiff",
+	"webp": "image/webp",
+	"svg":  "image/svg+xml",
+	"emf":  "image/x-emf",
+	"wmf":  "image/x-wmf",
+	"ico":  "image/x-icon",
+	"bin":  "application/vnd.openxmlformats-officedocument.oleObject",
+	"rels": CONTENT_TYPE_RELS,
+	"xml":  CONTENT_TYPE_XML,
+}
+
+// ContentTypes is [Content_Types].xml, which gives the content type
+// of each part by the extension of its name or by its name.
+// It is regenerated from the parts and the media on packing.
+type ContentTypes struct {
+	XMLName   xml.Name              `xml:"http://schemas.openxmlformats.org/package/2006/content-types Types"`
+	Defaults  []ContentTypeDefault  `xml:"Default"`
+	Overrides []ContentTypeOverride `xml:"Override"`
+}
+
+// ContentTypeDefault is the content type of the parts by the extension
+type ContentTypeDefault struct {
+	Extension   string `xml:"Extension,attr"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+// ContentTypeOverride is the content type of a part by its name like /word/document.xml
+type ContentTypeOverride struct {
+	PartName    string `xml:"PartName,attr"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+// partName returns name in the form of /word/document.xml
+func partName(name string) string {
+	return "/" + strings.TrimPrefix(name, "/")
+}
+
+// Lookup returns the content type of the part name, or "" if there is none
+func (c *ContentTypes) Lookup(name string) string {
+	name = partName(name)
+	for _, o := range c.Overrides {
+		if strings.EqualFold(o.PartName, name) {
+			return o.ContentType
+		}
+	}
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	for _, d := range c.Defaults {
+		if strings.EqualFold(d.Extension, ext) {
+			return d.ContentType
+		}
+	}
+	return ""
+}
+
+// SetDefault sets the content type of the extension ext
+func (c *ContentTypes) SetDefault(ext, contenttype string) {
+	ext = strings.TrimPrefix(ext, ".")
+	for i, d := range c.Defaults {
+		if strings.EqualFold(d.Extension, ext) {
+			c.Defaults[i].ContentType = contenttype
+			return
+		}
+	}
+	c.Defaults = append(c.Defaults, ContentTypeDefault{Extension: ext, ContentType: contenttype})
+}
+
+// SetOverride sets the content type of the part name
+func (c *ContentTypes) SetOverride(name, contenttype string) {
+	name = partName(name)
+	for i, o := range c.Overrides {
+		if strings.EqualFold(o.PartName, name) {
+			c.Overrides[i].ContentType = contenttype
+			return
+		}
+	}
+	c.Overrides = append(c.Overrides, ContentTypeOverride{PartName: name, ContentType: contenttype})
+}
+
+// RemoveOverride removes the content type of the part name
+func (c *ContentTypes) RemoveOverride(name string) {
+	name = partName(name)
+	for i, o := range c.Overrides {
+		if strings.EqualFold(o.PartName, name) {
+			c.Overrides = append(c.Overrides[:i], c.Overrides[i+1:]...)
+			return
+		}
+	}
+}
+
+// knownContentType returns the content type of the part name known
+// by the library, or "" if unknown
+func knownContentType(name string) string {
+	ext := path.Ext(name)
+	if ext == ".xml" {
+		// header1.xml and theme1.xml are the same as header.xml and theme.xml
+		return partContentTypes[strings.TrimRight(strings.TrimSuffix(name, ext), "0123456789")+ext]
+	}
+	return extContentType(ext)
+}
+
+// extContentType returns the content type of the extension, or "" if unknown
+func extContentType(ext string) string {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ct, ok := extContentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension("." + ext); ct != "" {
+		ct, _, _ = strings.Cut(ct, ";")
+		return ct
+	}
+	return ""
+}
+
+// ContentTypes parses [Content_Types].xml on first call and returns it.
+// Changes to it will be kept on packing, except that the overrides of the
+// missing parts are removed and the parts without a content type get one.
+func (f *Docx) ContentTypes() (*ContentTypes, error) {
+	if f.contentTypes != nil {
+		return f.contentTypes, nil
+	}
+	c := &ContentTypes{}
+	err := f.decodeTemplate("[Content_Types].xml", c)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	c.XMLName = xml.Name{Space: XMLNS_CONTENT_TYPES, Local: "Types"}
+	f.contentTypes = c
+	return c, nil
+}
+
+// packContentTypes regenerates [Content_Types].xml in files from the names of the parts,
+// together with the parts streamed which are written out of files
+func (f *Docx) packContentTypes(files map[string]io.Reader, streamed ...string) error {
+	c, err := f.ContentTypes()
+	if err != nil {
+		return err
+	}
+	delete(files, "[Content_Types].xml")
+	exists := func(name string) bool {
+		if _, ok := files[name]; ok {
+			return true
+		}
+		for _, s := range streamed {
+			if s == name {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(c.Overrides); i++ {
+		name := strings.TrimPrefix(c.Overrides[i].PartName, "/")
+		if !exists(name) {
+			c.Overrides = append(c.Overrides[:i], c.Overrides[i+1:]...)
+			i--
+		}
+	}
+	for _, ext := range []string{"rels", "xml"} {
+		if c.Lookup("a."+ext) == "" {
+			c.SetDefault(ext, extContentTypes[ext])
+		}
+	}
+	cts := make(map[string]string, len(f.modelParts))
+	for _, m := range f.modelParts {
+		if m.ct != "" {
+			cts[m.name] = m.ct
+		}
+	}
+	names := make([]string, 0, len(files)+len(streamed))
+	for name := range files {
+		names = append(names, name)
+	}
+	names = append(names, streamed...)
+	sort.Strings(names)
+	for _, name := range names {
+		known, ok := cts[name]
+		if !ok {
+			known = knownContentType(name)
+		}
+		ext := path.Ext(name)
+		switch ct := c.Lookup(name); {
+		case known == "" || ct == known:
+			continue
+		case ct == "" && ext != ".xml" && ext != "":
+			c.SetDefault(ext, known)
+		case ct == "" || (ct == CONTENT_TYPE_XML && ext == ".xml"):
+			// the parts in xml are known by their names
+			c.SetOverride(name, known)
+		}
+	}
+	files["[Content_Types].xml"] = marshaller{data: c}
+	return nil
+}
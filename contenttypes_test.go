@@ -0,0 +1,64 @@
+package docx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContentTypes(t *testing.T) {
+	f, err := FromMarkdown([]byte("- one\n- two"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := f.AddParagraph()
+	p.AddText("commented")
+	r, err := p.Range(0, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.AddComment("someone", "check this")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama2x.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := f.ContentTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct.SetOverride("word/removed.xml", "application/xml")
+
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nct, err := nf.ContentTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, exp := range map[string]string{
+		"word/document.xml":      partContentTypes["word/document.xml"],
+		"word/numbering.xml":     partContentTypes["word/numbering.xml"],
+		"word/comments.xml":      partContentTypes["word/comments.xml"],
+		"word/theme/theme1.xml":  partContentTypes["word/theme/theme.xml"],
+		"word/media/image1.webp": "image/webp",
+		"_rels/.rels":            CONTENT_TYPE_RELS,
+		"word/removed.xml":       CONTENT_TYPE_XML,
+	} {
+		if got := nct.Lookup(name); got != exp {
+			t.Fatal("unexpected content type of", name, got)
+		}
+	}
+	for _, o := range nct.Overrides {
+		if o.PartName == "/word/removed.xml" {
+			t.Fatal("unexpected override of missing part")
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ParseFS generates a new docx file in memory from the extracted package
+// in the root of fsys, such as os.DirFS of an unzipped .docx.
+// The directories beginning with a dot, like .git, are skipped,
+// and the media are read from fsys on demand.
+func ParseFS(fsys fs.FS) (*Docx, error) {
+	names := make([]string, 0, 64)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unpackFS(fsys, names, nil)
+}
+
+// WriteToDir writes each part of the package into the directory dir,
+// with the XML parts and the relationships pretty-printed.
+func (f *Docx) WriteToDir(dir string) error {
+	files, err := f.packFiles(false)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 65536))
+	for name, r := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(p), 0o755)
+		if err != nil {
+			return err
+		}
+		buf.Reset()
+		_, err = io.Copy(buf, r)
+		if err != nil {
+			return err
+		}
+		data := buf.Bytes()
+		if ext := path.Ext(name); ext == ".xml" || ext == ".rels" {
+			out := bytes.NewBuffer(make([]byte, 0, buf.Len()*5/4))
+			err = indentXML(out, bytes.NewReader(data))
+			if err != nil {
+				return err
+			}
+			data = out.Bytes()
+		}
+		err = os.WriteFile(p, data, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indentXML writes the xml in r into w with each element on a line.
+// Elements of only text are kept on one line so that their spaces are preserved.
+func indentXML(w io.Writer, r io.Reader) error {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	var text []byte
+	depth := 0
+	open := false // open is set if the start tag in progress is not closed by '>'
+	newline := func() {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+			for i := 0; i < depth; i++ {
+				buf.WriteString("  ")
+			}
+		}
+	}
+	flushText := func() {
+		if len(bytes.TrimSpace(text)) > 0 {
+			_ = xml.EscapeText(buf, text)
+		}
+		text = text[:0]
+	}
+	name := func(n xml.Name) string {
+		if n.Space != "" {
+			return n.Space + ":" + n.Local
+		}
+		return n.Local
+	}
+	for {
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if open {
+				buf.WriteByte('>')
+			}
+			flushText()
+			newline()
+			buf.WriteString("<" + name(t.Name))
+			for _, a := range t.Attr {
+				buf.WriteString(" " + name(a.Name) + `="`)
+				_ = xml.EscapeText(buf, StringToBytes(a.Value))
+				buf.WriteByte('"')
+			}
+			open = true
+			depth++
+		case xml.EndElement:
+			depth--
+			if open {
+				if len(text) == 0 {
+					buf.WriteString("/>")
+				} else {
+					buf.WriteByte('>')
+					_ = xml.EscapeText(buf, text)
+					buf.WriteString("</" + name(t.Name) + ">")
+				}
+				text, open = text[:0], false
+				continue
+			}
+			flushText()
+			newline()
+			buf.WriteString("</" + name(t.Name) + ">")
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.ProcInst:
+			if open {
+				buf.WriteByte('>')
+				open = false
+			}
+			flushText()
+			newline()
+			buf.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Comment:
+			if open {
+				buf.WriteByte('>')
+				open = false
+			}
+			flushText()
+			newline()
+			buf.WriteString("<!--" + string(t) + "-->")
+		case xml.Directive:
+			newline()
+			buf.WriteString("<!" + string(t) + ">")
+		}
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,72 @@
+package docx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText(" spaced  & <text> ")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = f.WriteToDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := os.ReadFile(filepath.Join(dir, "word", "document.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<w:document", "\n    <w:p>\n",
+		`<w:t> spaced  &amp; &lt;text&gt; </w:t>`,
+	} {
+		if !strings.Contains(string(doc), exp) {
+			t.Fatal("missing", exp, "in", string(doc))
+		}
+	}
+	rels, err := os.ReadFile(filepath.Join(dir, "_rels", ".rels"))
+	if err != nil || !bytes.Contains(rels, []byte("\n  <Relationship ")) {
+		t.Fatal("unexpected rels", err, string(rels))
+	}
+	err = os.MkdirAll(filepath.Join(dir, ".git"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nf, err := ParseFS(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PlainText(nf.Document.Body.Items[0].(*Paragraph)) != " spaced  & <text> " {
+		t.Fatal("unexpected text", PlainText(nf.Document.Body.Items[0].(*Paragraph)))
+	}
+	if len(nf.media) != 1 || !bytes.Equal(nf.mediaData(nf.media[0].Name), f.media[0].Data) {
+		t.Fatal("unexpected media")
+	}
+	for _, name := range nf.tmpfslst {
+		if strings.HasPrefix(name, ".git") {
+			t.Fatal("unexpected part", name)
+		}
+	}
+	buf := bytes.NewBuffer(nil)
+	_, err = nf.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,243 @@
+package docx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testCFB packs the streams into a Compound File Binary of 512-byte sectors
+func testCFB(streams map[string][]byte, names ...string) []byte {
+	le := binary.LittleEndian
+	head := make([]byte, 512)
+	copy(head, cfbSignature)
+	le.PutUint16(head[0x18:], 0x3E)
+	le.PutUint16(head[0x1A:], 3)
+	le.PutUint16(head[0x1C:], 0xFFFE)
+	le.PutUint16(head[0x1E:], 9)
+	le.PutUint16(head[0x20:], 6)
+	le.PutUint32(head[0x2C:], 1)
+	le.PutUint32(head[0x30:], 1)
+	le.PutUint32(head[0x38:], 4096)
+	le.PutUint32(head[0x3C:], cfbEndOfChain)
+	le.PutUint32(head[0x44:], cfbEndOfChain)
+	for i := 0; i < 109; i++ {
+		le.PutUint32(head[0x4C+i*4:], cfbNoStream)
+	}
+	le.PutUint32(head[0x4C:], 0)
+	fat := make([]byte, 512)
+	for i := 0; i < 128; i++ {
+		le.PutUint32(fat[i*4:], cfbNoStream)
+	}
+	le.PutUint32(fat, 0xFFFFFFFD)
+	le.PutUint32(fat[4:], cfbEndOfChain)
+	dir := make([]byte, 512)
+	entry := func(i int, name string, typ byte, start uint32, size int) {
+		e := dir[i*128:]
+		u := utf16.Encode([]rune(name))
+		for k, v := range u {
+			le.PutUint16(e[k*2:], v)
+		}
+		le.PutUint16(e[0x40:], uint16(len(u)*2+2))
+		e[0x42] = typ
+		le.PutUint32(e[0x44:], cfbNoStream)
+		le.PutUint32(e[0x48:], cfbNoStream)
+		le.PutUint32(e[0x4C:], cfbNoStream)
+		if i > 0 && i < len(names) {
+			le.PutUint32(e[0x48:], uint32(i+1))
+		}
+		le.PutUint32(e[0x74:], start)
+		le.PutUint64(e[0x78:], uint64(size))
+	}
+	entry(0, "Root Entry", 5, cfbEndOfChain, 0)
+	le.PutUint32(dir[0x4C:], 1)
+	var body []byte
+	sec := uint32(2)
+	for i, name := range names {
+		data := streams[name]
+		n := (len(data) + 511) / 512
+		entry(i+1, name, 2, sec, len(data))
+		for k := 0; k < n; k++ {
+			next := sec + 1
+			if k == n-1 {
+				next = cfbEndOfChain
+			}
+			le.PutUint32(fat[sec*4:], next)
+			sec++
+		}
+		body = append(body, data...)
+		body = append(body, make([]byte, n*512-len(data))...)
+	}
+	return append(append(append(head, fat...), dir...), body...)
+}
+
+// testDoc writes a Word 97 document of a heading, a paragraph with bold, a table, a link and a picture
+func testDoc(t *testing.T) []byte {
+	le := binary.LittleEndian
+	u16 := func(v ...int) []byte {
+		b := make([]byte, len(v)*2)
+		for i, x := range v {
+			le.PutUint16(b[i*2:], uint16(x))
+		}
+		return b
+	}
+	u32 := func(v ...int) []byte {
+		b := make([]byte, len(v)*4)
+		for i, x := range v {
+			le.PutUint32(b[i*4:], uint32(x))
+		}
+		return b
+	}
+	cat := func(b ...[]byte) []byte { return bytes.Join(b, nil) }
+
+	text := "Title\rHello bold world\rA\x07B\x07\x07\x13 HYPERLINK \"http://a.b\" \x14go\x15\r\x01\r"
+	ccp := len(text)
+	pic := strings.IndexByte(text, 1)
+	const textFC = 0xC00
+	fc := func(cp int) int { return textFC + cp*2 }
+
+	// the stylesheet of Normal in 12pt and Heading 1 in bold 16pt
+	std := func(sti, base int, name string, istd int, papx, chpx []byte) []byte {
+		b := cat(u16(sti, 1|base<<4, 2, 0, 0), u16(len(name)), u16(utf16ToInts(name)...), u16(0))
+		for _, upx := range [][]byte{cat(u16(istd), papx), chpx} {
+			b = append(cat(b, u16(len(upx)), upx), make([]byte, len(upx)&1)...)
+		}
+		return cat(u16(len(b)), b)
+	}
+	stsh := cat(u16(18, 2, 10), make([]byte, 14),
+		std(0, 0xFFF, "Normal", 0, nil, cat(u16(0x4A43, 24))),
+		std(1, 0, "heading 1", 1, cat(u16(0x2640), []byte{0}), cat(u16(0x0835), []byte{1}, u16(0x4A43, 32))),
+	)
+	clx := cat([]byte{2}, u32(16, 0, ccp), u16(0), u32(textFC), u16(0))
+	ffn := cat([]byte{0}, make([]byte, 38), u16(utf16ToInts("Arial")...), u16(0))
+	fonts := cat(u16(1, 0), []byte{byte(len(ffn))}, ffn)
+	var table []byte
+	pairs := make([]byte, 93*8)
+	add := func(i int, b []byte) {
+		le.PutUint32(pairs[i*8:], uint32(len(table)))
+		le.PutUint32(pairs[i*8+4:], uint32(len(b)))
+		table = append(table, b...)
+	}
+	add(1, stsh)
+	add(12, cat(u32(fc(0), fc(ccp)), u32(4)))
+	add(13, cat(u32(fc(0), fc(ccp)), u32(5)))
+	add(15, fonts)
+	add(33, clx)
+
+	word := make([]byte, 0x1000)
+	copy(word, u16(0xA5EC, 0xC1))
+	le.PutUint16(word[0x0A:], 0x0200)
+	copy(word[32:], u16(14))
+	copy(word[62:], u16(22))
+	le.PutUint32(word[64+12:], uint32(ccp))
+	copy(word[152:], u16(93))
+	copy(word[154:], pairs)
+	// the FKPs of the runs and the paragraphs
+	fkp := func(page int, runs [][2]int, props [][]byte, width int) {
+		b := word[page*512 : (page+1)*512]
+		off := 0x100
+		for i, r := range runs {
+			le.PutUint32(b[i*4:], uint32(fc(r[0])))
+			le.PutUint32(b[(i+1)*4:], uint32(fc(r[1])))
+			if props[i] != nil {
+				b[(len(runs)+1)*4+i*width] = byte(off / 2)
+				copy(b[off:], props[i])
+				off += (len(props[i]) + 1) &^ 1
+			}
+		}
+		b[511] = byte(len(runs))
+	}
+	chpx := func(grpprl ...byte) []byte { return append([]byte{byte(len(grpprl))}, grpprl...) }
+	fkp(4, [][2]int{{0, 12}, {12, 16}, {16, pic}, {pic, pic + 1}, {pic + 1, ccp}}, [][]byte{
+		nil, chpx(0x35, 0x08, 1), nil, chpx(0x55, 0x08, 1, 0x03, 0x6A, 0, 0, 0, 0), nil,
+	}, 1)
+	papx := func(istd int, grpprl ...byte) []byte {
+		b := cat(u16(istd), grpprl)
+		if len(b)&1 != 0 {
+			return append([]byte{byte(len(b)+1) / 2}, b...)
+		}
+		return append([]byte{0, byte(len(b) / 2)}, b...)
+	}
+	tc := make([]byte, 40)
+	def := cat(u16(0xD608, 1+6+40+1), []byte{2}, u16(0, 1440, 2880), tc)
+	fkp(5, [][2]int{{0, 6}, {6, 23}, {23, 25}, {25, 27}, {27, 28}, {28, pic}, {pic, ccp}}, [][]byte{
+		papx(1), papx(0, 0x03, 0x24, 1), papx(0, 0x16, 0x24, 1), papx(0, 0x16, 0x24, 1),
+		papx(0, append([]byte{0x16, 0x24, 1, 0x17, 0x24, 1}, def...)...), nil, nil,
+	}, 13)
+	for i, c := range utf16.Encode([]rune(text)) {
+		le.PutUint16(word[fc(i):], c)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	buf := bytes.NewBuffer(nil)
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blip := cat(u16(0x6E0<<4, 0xF01E), u32(17+buf.Len()), make([]byte, 17), buf.Bytes())
+	fbse := cat(u16(2, 0xF007), u32(36+len(blip)), make([]byte, 36), blip)
+	picf := make([]byte, 0x44)
+	copy(picf[4:], u16(0x44, 0x64))
+	copy(picf[28:], u16(1440, 720, 1000, 1000))
+	le.PutUint32(picf, uint32(0x44+len(fbse)))
+	data := make([]byte, 0x1000)
+	copy(data, cat(picf, fbse))
+
+	return testCFB(map[string][]byte{
+		"WordDocument": word, "1Table": append(table, make([]byte, 0x1000-len(table))...), "Data": data,
+	}, "WordDocument", "1Table", "Data")
+}
+
+func utf16ToInts(s string) []int {
+	var v []int
+	for _, c := range utf16.Encode([]rune(s)) {
+		v = append(v, int(c))
+	}
+	return v
+}
+
+func TestFromDoc(t *testing.T) {
+	doc := testDoc(t)
+	f, err := FromDoc(bytes.NewReader(doc), int64(len(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := bytes.NewBuffer(nil)
+	err = f.WriteMarkdownTo(md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"# Title\n\n", "Hello **bold** world\n\n", "| A | B |", "[go](http://a.b)", "](media/image1.png)",
+	} {
+		if !strings.Contains(md.String(), exp) {
+			t.Fatal("missing", exp, "in", md.String())
+		}
+	}
+	for _, it := range f.Document.Body.Items {
+		p, ok := it.(*Paragraph)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(PlainText(p), "Hello") {
+			if p.Properties.Justification.Val != "center" {
+				t.Fatal("unexpected paragraph")
+			}
+			if rp := p.Children[0].(*Run).RunProperties; rp != nil && rp.Size != nil {
+				t.Fatal("unexpected size", rp.Size.Val)
+			}
+		}
+		if PlainText(p) == "Title" && f.HeadingLevel(p) != 1 {
+			t.Fatal("unexpected heading")
+		}
+	}
+
+	_, err = FromDoc(bytes.NewReader(doc[:512]), 512)
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+}
@@ -0,0 +1,902 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var (
+	// ErrInvalidDoc the data is not a Word binary document
+	ErrInvalidDoc = errors.New("invalid doc data")
+	// ErrUnsupportedDoc the Word binary document is encrypted or older than Word 97
+	ErrUnsupportedDoc = errors.New("unsupported doc version or encryption")
+)
+
+// docColors is the colors of ico
+var docColors = [...]string{
+	"", "#000000", "#0000ff", "#00ffff", "#00ff00", "#ff00ff", "#ff0000", "#ffff00", "#ffffff",
+	"#000080", "#008080", "#008000", "#800080", "#800000", "#808000", "#808080", "#c0c0c0",
+}
+
+// FromDoc creates a docx in the default theme from the Word 97-2003 binary document in r
+func FromDoc(r io.ReaderAt, size int64) (*Docx, error) {
+	f := New().WithDefaultTheme()
+	err := f.AddDoc(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddDoc appends the main text of the Word 97-2003 binary document in r
+// to the body of f.
+//
+// Paragraphs, headings, the basic character formatting, lists, tables
+// with merged cells, inline PNG, JPEG and bitmap pictures and hyperlinks
+// are converted in the same way as AddRTF. Floating shapes, headers,
+// footers and notes are skipped.
+func (f *Docx) AddDoc(r io.ReaderAt, size int64) error {
+	data := make([]byte, size)
+	_, err := r.ReadAt(data, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	c, err := openCFB(data)
+	if err != nil {
+		return err
+	}
+	d := docReader{rtfReader: newRTFReader()}
+	d.word, _ = c.stream("WordDocument")
+	if len(d.word) < 0x200 {
+		return ErrInvalidDoc
+	}
+	le := binary.LittleEndian
+	if le.Uint16(d.word) != 0xA5EC {
+		return ErrInvalidDoc
+	}
+	flags := le.Uint16(d.word[0x0A:])
+	if le.Uint16(d.word[2:]) < 0xC1 || flags&0x0100 != 0 {
+		return ErrUnsupportedDoc
+	}
+	table := "0Table"
+	if flags&0x0200 != 0 {
+		table = "1Table"
+	}
+	d.table, _ = c.stream(table)
+	d.data, _ = c.stream("Data")
+	err = d.fib()
+	if err != nil {
+		return err
+	}
+	d.deff = -1
+	d.fontTable()
+	d.stylesheet()
+	d.lists()
+	d.chpx = d.bte(d.fc(12), false)
+	d.papx = d.bte(d.fc(13), true)
+	err = d.pieces()
+	if err != nil {
+		return err
+	}
+	d.text()
+	return d.build(f)
+}
+
+// docRun is the properties of the file positions [start, end)
+type docRun struct {
+	start, end uint32
+	istd       int
+	grpprl     []byte
+}
+
+// docPiece is a piece of the text in the piece table
+type docPiece struct {
+	cp, cpEnd  uint32
+	fc         uint32
+	compressed bool // compressed is set if the text is 8-bit
+	grpprl     []byte
+}
+
+// docStyle is a style in the stylesheet
+type docStyle struct {
+	level       int // level is the heading level
+	base        int
+	papx, chpx  []byte
+	resolved    bool
+	para, char  []byte // para and char is the sprms through the base styles
+	isCharacter bool
+}
+
+// docField is a field in progress
+type docField struct {
+	inst   strings.Builder
+	result bool // result is set after the separator
+	mark   int
+	paras  int
+}
+
+// docChar is the formatting of a run
+type docChar struct {
+	fm   rtfFormat
+	spec bool
+	pic  int64 // pic is the offset of the picture in the Data stream, -1 for none
+	ole  bool
+}
+
+// docReader reads a Word binary document by the FIB into an rtfReader
+type docReader struct {
+	*rtfReader
+	word, table, data []byte
+
+	ccpText uint32
+	size    int    // size is the font size of Normal
+	fcLcb   []byte // fcLcb is FibRgFcLcb
+	styles  []docStyle
+	chpx    []docRun
+	papx    []docRun
+	pcds    []docPiece
+	fields  []*docField
+}
+
+func (d *docReader) fib() error {
+	le := binary.LittleEndian
+	off := 32
+	csw := int(le.Uint16(d.word[off:]))
+	off += 2 + csw*2
+	if off+2 > len(d.word) {
+		return ErrInvalidDoc
+	}
+	cslw := int(le.Uint16(d.word[off:]))
+	lw := off + 2
+	off = lw + cslw*4
+	if cslw < 4 || off+2 > len(d.word) {
+		return ErrInvalidDoc
+	}
+	d.ccpText = le.Uint32(d.word[lw+12:])
+	n := int(le.Uint16(d.word[off:]))
+	off += 2
+	if off+n*8 > len(d.word) {
+		return ErrInvalidDoc
+	}
+	d.fcLcb = d.word[off : off+n*8]
+	return nil
+}
+
+// fc returns the part of the table stream at the i-th pair of FibRgFcLcb
+func (d *docReader) fc(i int) []byte {
+	if (i+1)*8 > len(d.fcLcb) {
+		return nil
+	}
+	le := binary.LittleEndian
+	fc, lcb := uint64(le.Uint32(d.fcLcb[i*8:])), uint64(le.Uint32(d.fcLcb[i*8+4:]))
+	if lcb == 0 || fc+lcb > uint64(len(d.table)) {
+		return nil
+	}
+	return d.table[fc : fc+lcb]
+}
+
+// docSprms calls fn with each sprm and its operand in grpprl
+func docSprms(grpprl []byte, fn func(sprm uint16, op []byte)) {
+	le := binary.LittleEndian
+	for i := 0; i+2 <= len(grpprl); {
+		sprm := le.Uint16(grpprl[i:])
+		i += 2
+		n := 0
+		switch sprm >> 13 {
+		case 0, 1:
+			n = 1
+		case 2, 4, 5:
+			n = 2
+		case 3:
+			n = 4
+		case 7:
+			n = 3
+		case 6:
+			if i >= len(grpprl) {
+				return
+			}
+			n = int(grpprl[i]) + 1
+			if sprm == 0xD608 && i+2 <= len(grpprl) {
+				// the size of sprmTDefTable is in 2 bytes
+				n = int(le.Uint16(grpprl[i:])) + 1
+			}
+		}
+		if i+n > len(grpprl) {
+			return
+		}
+		fn(sprm, grpprl[i:i+n])
+		i += n
+	}
+}
+
+// docXst reads a string of UTF-16 prefixed by its length in 2 bytes, returning the bytes read
+func docXst(b []byte) (string, int) {
+	if len(b) < 2 {
+		return "", len(b)
+	}
+	le := binary.LittleEndian
+	n := int(le.Uint16(b))
+	if 2+n*2 > len(b) {
+		return "", len(b)
+	}
+	u := make([]uint16, n)
+	for i := range u {
+		u[i] = le.Uint16(b[2+i*2:])
+	}
+	return string(utf16.Decode(u)), 2 + n*2
+}
+
+// stylesheet reads the heading levels and the sprms of the styles
+func (d *docReader) stylesheet() {
+	b := d.fc(1)
+	if len(b) < 6 {
+		return
+	}
+	le := binary.LittleEndian
+	cb := int(le.Uint16(b))
+	if 2+cb > len(b) || cb < 4 {
+		return
+	}
+	cstd, cbBase := int(le.Uint16(b[2:])), int(le.Uint16(b[4:]))
+	p := 2 + cb
+	for istd := 0; istd < cstd && p+2 <= len(b); istd++ {
+		n := int(le.Uint16(b[p:]))
+		p += 2
+		st := docStyle{base: 0xFFF}
+		if n > 0 && p+n <= len(b) && n >= cbBase && cbBase >= 10 {
+			std := b[p : p+n]
+			sti := int(le.Uint16(std) & 0x0FFF)
+			stk := int(le.Uint16(std[2:]) & 0xF)
+			st.base = int(le.Uint16(std[2:]) >> 4)
+			cupx := int(le.Uint16(std[4:]) & 0xF)
+			if sti >= 1 && sti <= 9 {
+				st.level = sti
+			}
+			_, q := docXst(std[cbBase:])
+			q += cbBase + 2 // the terminating zero
+			var upx [][]byte
+			for k := 0; k < cupx && q+2 <= len(std); k++ {
+				m := int(le.Uint16(std[q:]))
+				q += 2
+				if q+m > len(std) {
+					break
+				}
+				upx = append(upx, std[q:q+m])
+				q += m + m&1
+			}
+			switch {
+			case stk == 1 && len(upx) > 0:
+				if len(upx[0]) >= 2 {
+					st.papx = upx[0][2:]
+				}
+				if len(upx) > 1 {
+					st.chpx = upx[1]
+				}
+			case stk == 2 && len(upx) > 0:
+				st.isCharacter = true
+				st.chpx = upx[0]
+			}
+		}
+		d.styles = append(d.styles, st)
+		p += n
+	}
+	for i := range d.styles {
+		d.resolve(i, 0)
+		st := &d.styles[i]
+		if st.level == 0 {
+			docSprms(st.para, func(sprm uint16, op []byte) {
+				if sprm == 0x2640 && op[0] < 9 {
+					st.level = int(op[0]) + 1
+				}
+			})
+		}
+		if st.level > 0 && !st.isCharacter {
+			d.headings[i] = st.level
+		}
+	}
+	// the font and the size of Normal are the defaults
+	normal := d.char(nil, nil, 0)
+	d.deff, d.size = normal.fm.font, normal.fm.size
+	for istd, level := range d.headings {
+		if level > 0 {
+			fm := d.char(nil, nil, istd).fm
+			d.styleCSS[istd] = d.css(&fm)
+		}
+	}
+}
+
+// resolve joins the sprms of the style istd after its base styles
+func (d *docReader) resolve(istd, depth int) ([]byte, []byte) {
+	if istd < 0 || istd >= len(d.styles) || depth > 16 {
+		return nil, nil
+	}
+	st := &d.styles[istd]
+	if !st.resolved {
+		st.resolved = true
+		para, char := d.resolve(st.base, depth+1)
+		st.para = append(append([]byte(nil), para...), st.papx...)
+		st.char = append(append([]byte(nil), char...), st.chpx...)
+	}
+	return st.para, st.char
+}
+
+// fontTable reads SttbfFfn
+func (d *docReader) fontTable() {
+	b := d.fc(15)
+	if len(b) < 4 {
+		return
+	}
+	le := binary.LittleEndian
+	n := int(le.Uint16(b))
+	p := 4
+	for i := 0; i < n && p < len(b); i++ {
+		m := int(b[p])
+		ffn := b[p+1 : minInt(p+1+m, len(b))]
+		p += 1 + m
+		if len(ffn) <= 39 {
+			continue
+		}
+		u := make([]uint16, 0, 16)
+		for k := 39; k+2 <= len(ffn); k += 2 {
+			v := le.Uint16(ffn[k:])
+			if v == 0 {
+				break
+			}
+			u = append(u, v)
+		}
+		d.fonts[i] = rtfFont{name: string(utf16.Decode(u))}
+	}
+}
+
+// lists reads the kinds and the starts of the levels in PlfLst and the lists of PlfLfo
+func (d *docReader) lists() {
+	le := binary.LittleEndian
+	lst := d.fc(73)
+	if len(lst) >= 2 {
+		n := int(int16(le.Uint16(lst)))
+		type lstf struct {
+			id     int
+			simple bool
+		}
+		var lstfs []lstf
+		for i := 0; i < n && 2+i*28+28 <= len(lst); i++ {
+			l := lst[2+i*28:]
+			lstfs = append(lstfs, lstf{id: int(int32(le.Uint32(l))), simple: l[26]&1 != 0})
+		}
+		// the levels follow PlfLst in the table stream
+		p := int(le.Uint32(d.fcLcb[73*8:])) + len(lst)
+		for _, l := range lstfs {
+			count := 9
+			if l.simple {
+				count = 1
+			}
+			levels := make([]odtLevel, 0, count)
+			for k := 0; k < count && p+28 <= len(d.table); k++ {
+				lvl := d.table[p:]
+				nfc := lvl[4]
+				levels = append(levels, odtLevel{ordered: nfc != 23 && nfc != 255, start: int(int32(le.Uint32(lvl)))})
+				p += 28 + int(lvl[24]) + int(lvl[25])
+				if p > len(d.table) {
+					break
+				}
+				_, m := docXst(d.table[p:])
+				p += m
+			}
+			d.levels[l.id] = levels
+		}
+	}
+	lfo := d.fc(74)
+	if len(lfo) >= 4 {
+		n := int(le.Uint32(lfo))
+		for i := 0; i < n && 4+i*16+16 <= len(lfo); i++ {
+			d.overrides[i+1] = int(int32(le.Uint32(lfo[4+i*16:])))
+		}
+	}
+}
+
+// bte reads the runs of the FKPs in the bin table b, of paragraphs if papx is set
+func (d *docReader) bte(b []byte, papx bool) []docRun {
+	if len(b) < 4 {
+		return nil
+	}
+	le := binary.LittleEndian
+	n := (len(b) - 4) / 8
+	var runs []docRun
+	for i := 0; i < n; i++ {
+		pn := int(le.Uint32(b[(n+1)*4+i*4:]) & 0x3FFFFF)
+		if (pn+1)*512 > len(d.word) {
+			continue
+		}
+		fkp := d.word[pn*512 : (pn+1)*512]
+		crun := int(fkp[511])
+		for k := 0; k < crun && (crun+1)*4+k*13 < 511; k++ {
+			run := docRun{start: le.Uint32(fkp[k*4:]), end: le.Uint32(fkp[(k+1)*4:])}
+			var off int
+			if papx {
+				off = int(fkp[(crun+1)*4+k*13]) * 2
+			} else {
+				off = int(fkp[(crun+1)*4+k]) * 2
+			}
+			if off == 0 || off >= 511 {
+				runs = append(runs, run)
+				continue
+			}
+			if papx {
+				cb := int(fkp[off]) * 2
+				off++
+				if cb == 0 && off < 511 {
+					cb = int(fkp[off]) * 2
+					off++
+				} else {
+					cb--
+				}
+				if off+cb > 511 || cb < 2 {
+					runs = append(runs, run)
+					continue
+				}
+				run.istd = int(le.Uint16(fkp[off:]))
+				run.grpprl = fkp[off+2 : off+cb]
+			} else {
+				cb := int(fkp[off])
+				run.grpprl = fkp[off+1 : minInt(off+1+cb, 511)]
+			}
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].start < runs[j].start })
+	return runs
+}
+
+// find returns the run containing fc, or nil
+func docFind(runs []docRun, fc uint32) *docRun {
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].end > fc })
+	if i < len(runs) && runs[i].start <= fc {
+		return &runs[i]
+	}
+	return nil
+}
+
+// pieces reads the piece table in Clx
+func (d *docReader) pieces() error {
+	clx := d.fc(33)
+	le := binary.LittleEndian
+	var prcs [][]byte
+	i := 0
+	for i+3 <= len(clx) && clx[i] == 1 {
+		n := int(int16(le.Uint16(clx[i+1:])))
+		if n < 0 || i+3+n > len(clx) {
+			return ErrInvalidDoc
+		}
+		prcs = append(prcs, clx[i+3:i+3+n])
+		i += 3 + n
+	}
+	if i+5 > len(clx) || clx[i] != 2 {
+		return ErrInvalidDoc
+	}
+	lcb := int(le.Uint32(clx[i+1:]))
+	plc := clx[i+5:]
+	if lcb < 4 || lcb > len(plc) {
+		return ErrInvalidDoc
+	}
+	n := (lcb - 4) / 12
+	for k := 0; k < n; k++ {
+		pcd := plc[(n+1)*4+k*8:]
+		fc := le.Uint32(pcd[2:])
+		p := docPiece{cp: le.Uint32(plc[k*4:]), cpEnd: le.Uint32(plc[(k+1)*4:]), fc: fc & 0x3FFFFFFF}
+		if fc&0x40000000 != 0 {
+			p.compressed = true
+			p.fc /= 2
+		}
+		if prm := le.Uint16(pcd[6:]); prm&1 != 0 && int(prm>>1) < len(prcs) {
+			p.grpprl = prcs[prm>>1]
+		}
+		d.pcds = append(d.pcds, p)
+	}
+	return nil
+}
+
+// char resolves the formatting of the run in piece p of a paragraph in the style istd
+func (d *docReader) char(run *docRun, p *docPiece, istd int) docChar {
+	c := docChar{fm: rtfFormat{font: -1}, pic: -1}
+	le := binary.LittleEndian
+	apply := func(sprm uint16, op []byte) {
+		on := op[0] == 1 || op[0] == 0x81
+		switch sprm {
+		case 0x0835:
+			c.fm.b = on
+		case 0x0836:
+			c.fm.i = on
+		case 0x0837, 0x2A53:
+			c.fm.s = on
+		case 0x083C, 0x0800:
+			c.fm.hidden = on
+		case 0x2A3E:
+			c.fm.u = op[0] != 0
+		case 0x4A43:
+			c.fm.size = int(le.Uint16(op))
+		case 0x2A42:
+			if int(op[0]) < len(docColors) {
+				c.fm.color = 0
+				if op[0] > 0 {
+					c.fm.color = d.colorIndex(docColors[op[0]])
+				}
+			}
+		case 0x6870:
+			c.fm.color = 0
+			if op[3] != 0xFF {
+				c.fm.color = d.colorIndex("#" + hex.EncodeToString(op[:3]))
+			}
+		case 0x2A0C:
+			if int(op[0]) < len(docColors) {
+				c.fm.bg = 0
+				if op[0] > 0 {
+					c.fm.bg = d.colorIndex(docColors[op[0]])
+				}
+			}
+		case 0x4A4F:
+			c.fm.font = int(le.Uint16(op))
+		case 0x2A48:
+			switch op[0] {
+			case 1:
+				c.fm.vert = "super"
+			case 2:
+				c.fm.vert = "sub"
+			default:
+				c.fm.vert = ""
+			}
+		case 0x0855:
+			c.spec = on
+		case 0x6A03:
+			c.pic = int64(le.Uint32(op))
+		case 0x080A:
+			c.ole = on
+		}
+	}
+	var grpprl []byte
+	if run != nil {
+		grpprl = run.grpprl
+	}
+	if istd >= 0 && istd < len(d.styles) {
+		_, char := d.resolve(istd, 0)
+		docSprms(char, apply)
+	}
+	// the character style goes before the direct formatting
+	docSprms(grpprl, func(sprm uint16, op []byte) {
+		if sprm == 0x4A30 {
+			if istd := int(le.Uint16(op)); istd < len(d.styles) && d.styles[istd].isCharacter {
+				_, char := d.resolve(istd, 0)
+				docSprms(char, apply)
+			}
+		}
+	})
+	docSprms(grpprl, apply)
+	if p != nil {
+		docSprms(p.grpprl, apply)
+	}
+	if c.fm.size == d.size {
+		c.fm.size = 0 // the size of Normal
+	}
+	return c
+}
+
+// docParagraph is the properties of a paragraph
+type docParagraph struct {
+	rtfPara
+	ttp, innerTtp bool
+	grpprl        [][]byte // grpprl is the sprms applied, for the properties of the table rows
+}
+
+// paragraph resolves the properties of the paragraph ended at fc in piece p
+func (d *docReader) paragraph(fc uint32, p *docPiece) docParagraph {
+	var pp docParagraph
+	run := docFind(d.papx, fc)
+	if run == nil {
+		return pp
+	}
+	pp.style = run.istd
+	pp.grpprl = [][]byte{run.grpprl, p.grpprl}
+	if run.istd < len(d.styles) {
+		para, _ := d.resolve(run.istd, 0)
+		pp.grpprl = append([][]byte{para}, pp.grpprl...)
+	}
+	le := binary.LittleEndian
+	for _, g := range pp.grpprl {
+		docSprms(g, func(sprm uint16, op []byte) {
+			switch sprm {
+			case 0x2403, 0x2461:
+				pp.align = [...]string{"left", "center", "right", "justify", "justify"}[minInt(int(op[0]), 4)]
+			case 0x2416:
+				pp.intbl = op[0] != 0
+			case 0x2417:
+				pp.ttp = op[0] != 0
+			case 0x244C:
+				pp.innerTtp = op[0] != 0
+			case 0x6649:
+				pp.intbl = le.Uint32(op) > 0
+			case 0x2640:
+				pp.outline = 0
+				if op[0] < 9 {
+					pp.outline = int(op[0]) + 1
+				}
+			case 0x260A:
+				pp.ilvl = int(op[0])
+			case 0x460B:
+				pp.ls = int(int16(le.Uint16(op)))
+			}
+		})
+	}
+	if pp.ls >= 2000 {
+		pp.ls = 0 // the list is removed
+	}
+	return pp
+}
+
+// row sets the cells defined in the sprms of the row ended by pp
+func (d *docReader) row(pp *docParagraph) {
+	le := binary.LittleEndian
+	var defs []rtfCellDef
+	left := 0
+	for _, g := range pp.grpprl {
+		docSprms(g, func(sprm uint16, op []byte) {
+			switch sprm {
+			case 0xD608:
+				if len(op) < 3 {
+					return
+				}
+				n := int(op[2])
+				if 3+(n+1)*2 > len(op) {
+					return
+				}
+				defs = make([]rtfCellDef, n)
+				left = int(int16(le.Uint16(op[3:])))
+				tc := op[3+(n+1)*2:]
+				for i := range defs {
+					defs[i].right = int(int16(le.Uint16(op[3+(i+1)*2:]))) - left
+					if len(tc) >= (i+1)*20 {
+						flags := le.Uint16(tc[i*20:])
+						defs[i].hmerge = flags&0x2 != 0
+						defs[i].vfirst = flags&0x60 == 0x60
+						defs[i].vmerge = flags&0x60 == 0x20
+					}
+				}
+			case 0xD62B:
+				if len(op) >= 3 && int(op[1]) < len(defs) {
+					defs[op[1]].vfirst = op[2] == 3
+					defs[op[1]].vmerge = op[2] == 1
+				}
+			case 0x5624:
+				for i := int(op[0]) + 1; i < int(op[1]) && i < len(defs); i++ {
+					defs[i].hmerge = true
+				}
+			}
+		})
+	}
+	d.defs = defs
+}
+
+// text converts the main text through the pieces
+func (d *docReader) text() {
+	sb := strings.Builder{}
+	var cur docChar
+	var curRun *docRun
+	var curPiece *docPiece
+	curStyle := -1
+	flush := func() {
+		if sb.Len() > 0 {
+			d.rtfReader.text(sb.String(), &cur.fm)
+			sb.Reset()
+		}
+	}
+	le := binary.LittleEndian
+	for k := range d.pcds {
+		p := &d.pcds[k]
+		for cp := p.cp; cp < p.cpEnd && cp < d.ccpText; cp++ {
+			var c rune
+			var fc uint32
+			if p.compressed {
+				fc = p.fc + cp - p.cp
+				if int(fc) >= len(d.word) {
+					break
+				}
+				c = charmap.Windows1252.DecodeByte(d.word[fc])
+			} else {
+				fc = p.fc + (cp-p.cp)*2
+				if int(fc)+2 > len(d.word) {
+					break
+				}
+				c = rune(le.Uint16(d.word[fc:]))
+				if utf16.IsSurrogate(c) && c < 0xDC00 && cp+1 < p.cpEnd && int(fc)+4 <= len(d.word) {
+					c = utf16.DecodeRune(c, rune(le.Uint16(d.word[fc+2:])))
+					cp++
+				}
+			}
+			istd := 0
+			if para := docFind(d.papx, fc); para != nil {
+				istd = para.istd
+			}
+			if run := docFind(d.chpx, fc); run != curRun || p != curPiece || istd != curStyle {
+				flush()
+				curRun, curPiece, curStyle = run, p, istd
+				cur = d.char(run, p, istd)
+			}
+			if n := len(d.fields); n > 0 && !d.fields[n-1].result && c != 0x13 && c != 0x14 && c != 0x15 {
+				d.fields[n-1].inst.WriteRune(c)
+				continue
+			}
+			switch c {
+			case 0x13:
+				flush()
+				d.fields = append(d.fields, &docField{})
+			case 0x14:
+				flush()
+				if n := len(d.fields); n > 0 {
+					f := d.fields[n-1]
+					f.result, f.mark, f.paras = true, len(d.inline), d.paras
+					d.merge = false
+				}
+			case 0x15:
+				flush()
+				if n := len(d.fields); n > 0 {
+					f := d.fields[n-1]
+					d.fields = d.fields[:n-1]
+					if f.result {
+						d.link(f.mark, f.paras, rtfHyperlink(f.inst.String()))
+					}
+				}
+			case 0x0D:
+				flush()
+				pp := d.paragraph(fc, p)
+				if pp.innerTtp {
+					d.inline, d.merge = nil, false
+					continue
+				}
+				d.para = pp.rtfPara
+				d.endPara()
+			case 0x07:
+				flush()
+				pp := d.paragraph(fc, p)
+				d.para = pp.rtfPara
+				if pp.ttp {
+					d.row(&pp)
+					d.inline, d.merge = nil, false
+					d.endRow()
+					continue
+				}
+				d.endCell()
+			case 0x0B:
+				flush()
+				d.inline = append(d.inline, &htmlNode{tag: "br"})
+				d.merge = false
+			case 0x09:
+				sb.WriteByte('\t')
+			case 0x01:
+				flush()
+				if cur.spec && cur.pic >= 0 && !cur.fm.hidden {
+					d.picture(cur.pic)
+				}
+			case 0x1E:
+				sb.WriteString("‑")
+			default:
+				if c >= 0x20 {
+					sb.WriteRune(c)
+				}
+			}
+		}
+	}
+	flush()
+}
+
+// picture adds the picture at off of the Data stream
+func (d *docReader) picture(off int64) {
+	le := binary.LittleEndian
+	if off < 0 || off+0x44 > int64(len(d.data)) {
+		return
+	}
+	pic := d.data[off:]
+	lcb, cbHeader := int64(le.Uint32(pic)), int(le.Uint16(pic[4:]))
+	if lcb > int64(len(pic)) {
+		lcb = int64(len(pic))
+	}
+	if int64(cbHeader) >= lcb {
+		return
+	}
+	pic = pic[:lcb]
+	w := int(int16(le.Uint16(pic[28:]))) * int(le.Uint16(pic[32:])) / 1000
+	h := int(int16(le.Uint16(pic[30:]))) * int(le.Uint16(pic[34:])) / 1000
+	p := cbHeader
+	if le.Uint16(pic[6:]) == 0x66 && p < len(pic) {
+		p += 1 + int(pic[p]) // the name of the linked file
+	}
+	for p+8 <= len(pic) {
+		typ, n := le.Uint16(pic[p+2:]), int(le.Uint32(pic[p+4:]))
+		body := pic[p+8 : minInt(p+8+n, len(pic))]
+		switch typ {
+		case 0xF007:
+			// OfficeArtFBSE holds the blip after its name
+			if len(body) > 36 && 36+int(body[33])+8 <= len(body) {
+				body = body[36+int(body[33]):]
+				if data, ext := docBlip(body); data != nil {
+					d.image(data, ext, w, h)
+					return
+				}
+			}
+		default:
+			if data, ext := docBlip(pic[p:]); data != nil {
+				d.image(data, ext, w, h)
+				return
+			}
+		}
+		p += 8 + n
+	}
+}
+
+// docBlip returns the image in the blip record b and its extension, converting DIB into BMP
+func docBlip(b []byte) ([]byte, string) {
+	if len(b) < 8 {
+		return nil, ""
+	}
+	le := binary.LittleEndian
+	inst, typ, n := le.Uint16(b)>>4, le.Uint16(b[2:]), int(le.Uint32(b[4:]))
+	b = b[8:minInt(8+n, len(b))]
+	skip := 17 // rgbUid1 and tag
+	if inst&1 != 0 {
+		skip += 16 // rgbUid2
+	}
+	if len(b) <= skip {
+		return nil, ""
+	}
+	b = b[skip:]
+	switch typ {
+	case 0xF01D, 0xF02A:
+		return b, ".jpeg"
+	case 0xF01E:
+		return b, ".png"
+	case 0xF029:
+		return b, ".tiff"
+	case 0xF01F:
+		if len(b) < 40 {
+			return nil, ""
+		}
+		hsize, bits, colors := le.Uint32(b), le.Uint16(b[14:]), le.Uint32(b[32:])
+		if colors == 0 && bits <= 8 {
+			colors = 1 << bits
+		}
+		offset := 14 + hsize + colors*4
+		if le.Uint32(b[16:]) == 3 && hsize == 40 {
+			offset += 12 // the masks of BI_BITFIELDS
+		}
+		bmp := make([]byte, 14, 14+len(b))
+		bmp[0], bmp[1] = 'B', 'M'
+		le.PutUint32(bmp[2:], uint32(14+len(b)))
+		le.PutUint32(bmp[10:], offset)
+		return append(bmp, b...), ".bmp"
+	}
+	return nil, ""
+}
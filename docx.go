@@ -29,6 +29,7 @@ import (
 	"io/fs"
 	"os"
 	"sync"
+	"time"
 )
 
 // Docx is the structure that allow to access the internal represntation
@@ -38,6 +39,19 @@ type Docx struct {
 
 	docRelation Relationships // docRelation is word/_rels/document.xml.rels
 
+	styles    *Styles    // styles is word/styles.xml, nil before being loaded
+	numbering *Numbering // numbering is word/numbering.xml, nil before being loaded
+
+	cachedStyles    *Styles    // cachedStyles is parsed for reading only and not written back
+	cachedNumbering *Numbering // cachedNumbering is parsed for reading only and not written back
+	headers         []*Header  // headers is nil before being loaded
+	footers         []*Footer  // footers is nil before being loaded
+	comments        *Comments  // comments is nil before being loaded or created
+	footnotes       *Footnotes // footnotes is nil before being loaded
+
+	modelParts   []*modelPart
+	contentTypes *ContentTypes // contentTypes is [Content_Types].xml, nil before being loaded
+
 	media        []Media
 	mediaNameIdx map[string]int
 
@@ -47,9 +61,13 @@ type Docx struct {
 	slowIDs   map[string]uintptr
 	slowIDsMu sync.Mutex
 
+	fixedTime time.Time // fixedTime is used instead of the current time if not zero
+
 	template string
 	tmplfs   fs.FS
 	tmpfslst []string
+	parts    map[string][]byte // parts is the raw parts put over the template
+	limits   *ParseOptions     // limits is the options of ParseWithOptions, nil if unlimited
 
 	io.Reader
 	io.WriterTo
@@ -90,12 +108,17 @@ func New() *Docx {
 //		defer file.Close()
 //		docxlib.Parse(file, handler.Size)
 //	}
+//
+// The media are read from reader on demand by Media.Load or on writing,
+// so reader should be kept open while they are used.
+//
+// Use ParseWithOptions to limit the resources used on untrusted files.
 func Parse(reader io.ReaderAt, size int64) (doc *Docx, err error) {
 	zipReader, err := zip.NewReader(reader, size)
 	if err != nil {
 		return nil, err
 	}
-	doc, err = unpack(zipReader)
+	doc, err = unpack(zipReader, nil)
 	return
 }
 
@@ -148,12 +171,39 @@ func LoadBodyItems(items []interface{}, media []Media) *Docx {
 	return doc
 }
 
+// WithFixedTime makes the output reproducible by using t as the date
+// of the new comments and as the modification time of the zip entries,
+// which is 1980-01-01 if not fixed. Other ids are always allocated in order.
+func (f *Docx) WithFixedTime(t time.Time) *Docx {
+	f.fixedTime = t
+	return f
+}
+
+// now returns the fixed time, or the current time if not fixed
+func (f *Docx) now() time.Time {
+	if !f.fixedTime.IsZero() {
+		return f.fixedTime
+	}
+	return time.Now()
+}
+
 // WriteTo allows to save a docx to a writer
 func (f *Docx) WriteTo(writer io.Writer) (_ int64, err error) {
 	zipWriter := zip.NewWriter(writer)
 	defer zipWriter.Close()
 
-	return 0, f.pack(zipWriter)
+	return 0, f.pack(zipWriter, nil)
+}
+
+// WriteToWithOptions saves the docx to w like WriteTo in the compression of opt
+func (f *Docx) WriteToWithOptions(w io.Writer, opt *WriteOptions) error {
+	zipWriter := zip.NewWriter(w)
+	err := f.pack(zipWriter, opt)
+	if err != nil {
+		_ = zipWriter.Close()
+		return err
+	}
+	return zipWriter.Close()
 }
 
 // Read is a fake function and cannot be used
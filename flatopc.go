@@ -0,0 +1,197 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+//nolint:revive,stylecheck
+const XMLNS_PKG = `http://schemas.microsoft.com/office/2006/xmlPackage`
+
+// flatPackage is the pkg:package of Flat OPC
+type flatPackage struct {
+	XMLName xml.Name   `xml:"http://schemas.microsoft.com/office/2006/xmlPackage package"`
+	Parts   []flatPart `xml:"part"`
+}
+
+// flatPart is the pkg:part of Flat OPC
+type flatPart struct {
+	Name        string `xml:"name,attr"`
+	ContentType string `xml:"contentType,attr"`
+	XMLData     *struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"xmlData"`
+	BinaryData string `xml:"binaryData"`
+}
+
+// ParseFlatOPC generates a new docx file in memory from the Flat OPC
+// (pkg:package) single XML file in r, which Word saves as "Word XML Document".
+func ParseFlatOPC(r io.Reader) (*Docx, error) {
+	var pkg flatPackage
+	err := xml.NewDecoder(r).Decode(&pkg)
+	if err != nil {
+		return nil, err
+	}
+	fsys := make(memFS, len(pkg.Parts)+1)
+	names := make([]string, 0, len(pkg.Parts)+1)
+	types := ContentTypes{Defaults: []ContentTypeDefault{
+		{Extension: "rels", ContentType: CONTENT_TYPE_RELS},
+		{Extension: "xml", ContentType: CONTENT_TYPE_XML},
+	}}
+	for _, p := range pkg.Parts {
+		name := strings.TrimPrefix(p.Name, "/")
+		if name == "" || name == "[Content_Types].xml" {
+			continue
+		}
+		var data []byte
+		if p.XMLData != nil {
+			data = append([]byte(xml.Header), bytes.TrimSpace(p.XMLData.Inner)...)
+		} else {
+			data, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(p.BinaryData), ""))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := fsys[name]; !ok {
+			names = append(names, name)
+		}
+		fsys[name] = data
+		if p.ContentType == "" || types.Lookup(name) == p.ContentType {
+			continue
+		}
+		if ext := path.Ext(name); p.XMLData == nil && ext != "" && types.Lookup(name) == "" {
+			types.SetDefault(ext, p.ContentType)
+			continue
+		}
+		types.SetOverride(name, p.ContentType)
+	}
+	data, err := xml.Marshal(&types)
+	if err != nil {
+		return nil, err
+	}
+	fsys["[Content_Types].xml"] = append([]byte(xml.Header), data...)
+	names = append(names, "[Content_Types].xml")
+	return unpackFS(fsys, names, nil)
+}
+
+// WriteFlatOPCTo writes the package as a Flat OPC single XML file,
+// with the XML parts inlined and the other parts in base64.
+func (f *Docx) WriteFlatOPCTo(w io.Writer) error {
+	files, err := f.packFiles(false)
+	if err != nil {
+		return err
+	}
+	types, err := f.ContentTypes()
+	if err != nil {
+		return err
+	}
+	delete(files, "[Content_Types].xml")
+	buf := bytes.NewBuffer(make([]byte, 0, 65536))
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString(xml.Header + `<?mso-application progid="Word.Document"?>` + "\n")
+	_, _ = bw.WriteString(`<pkg:package xmlns:pkg="` + XMLNS_PKG + `">`)
+	for _, name := range names {
+		buf.Reset()
+		_, err = io.Copy(buf, files[name])
+		if err != nil {
+			return err
+		}
+		typ := types.Lookup(name)
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		_, _ = bw.WriteString(`<pkg:part pkg:name="`)
+		_ = xml.EscapeText(bw, []byte("/"+name))
+		_, _ = bw.WriteString(`" pkg:contentType="`)
+		_ = xml.EscapeText(bw, []byte(typ))
+		if strings.HasSuffix(typ, "xml") {
+			_, _ = bw.WriteString(`"><pkg:xmlData>`)
+			_, _ = bw.Write(stripXMLDecl(buf.Bytes()))
+			_, _ = bw.WriteString(`</pkg:xmlData></pkg:part>`)
+			continue
+		}
+		_, _ = bw.WriteString(`" pkg:compression="store"><pkg:binaryData>`)
+		data := base64.StdEncoding.EncodeToString(buf.Bytes())
+		for len(data) > 76 {
+			_, _ = bw.WriteString(data[:76] + "\n")
+			data = data[76:]
+		}
+		_, _ = bw.WriteString(data + `</pkg:binaryData></pkg:part>`)
+	}
+	_, _ = bw.WriteString(`</pkg:package>`)
+	return bw.Flush()
+}
+
+// stripXMLDecl removes the byte order mark and the xml declaration of data
+func stripXMLDecl(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("<?xml")) {
+		if i := bytes.Index(data, []byte("?>")); i >= 0 {
+			data = bytes.TrimSpace(data[i+2:])
+		}
+	}
+	return data
+}
+
+// memFS is a read-only file system of the files in memory by their names
+type memFS map[string][]byte
+
+// Open implements fs.FS
+func (m memFS) Open(name string) (fs.File, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{Reader: bytes.NewReader(data), name: name, size: int64(len(data))}, nil
+}
+
+// memFile is an opened file of memFS
+type memFile struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (m *memFile) Stat() (fs.FileInfo, error) { return m, nil }
+func (m *memFile) Close() error               { return nil }
+func (m *memFile) Name() string               { return path.Base(m.name) }
+func (m *memFile) Size() int64                { return m.size }
+func (m *memFile) Mode() fs.FileMode          { return 0o444 }
+func (m *memFile) ModTime() time.Time         { return time.Time{} }
+func (m *memFile) IsDir() bool                { return false }
+func (m *memFile) Sys() interface{}           { return nil }
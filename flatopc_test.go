@@ -0,0 +1,82 @@
+package docx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestFlatOPC(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("flat & <opc>").Bold()
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteFlatOPCTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat := buf.String()
+	for _, exp := range []string{
+		`<?mso-application progid="Word.Document"?>`, `<pkg:package xmlns:pkg="` + XMLNS_PKG + `">`,
+		`<pkg:part pkg:name="/word/document.xml" pkg:contentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"><pkg:xmlData><w:document`,
+		`<pkg:part pkg:name="/_rels/.rels" pkg:contentType="` + CONTENT_TYPE_RELS + `"><pkg:xmlData>`,
+		`pkg:contentType="image/png" pkg:compression="store"><pkg:binaryData>`, `flat &amp; &lt;opc&gt;`,
+	} {
+		if !strings.Contains(flat, exp) {
+			t.Fatal("missing", exp, "in", flat)
+		}
+	}
+	if strings.Contains(flat, "[Content_Types].xml") {
+		t.Fatal("unexpected content types part")
+	}
+
+	nf, err := ParseFlatOPC(strings.NewReader(flat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := nf.Document.Body.Items[0].(*Paragraph)
+	if PlainText(p) != "flat & <opc>" || p.Children[0].(*Run).RunProperties.Bold == nil {
+		t.Fatal("unexpected paragraph", PlainText(p))
+	}
+	if len(nf.media) != 1 || !bytes.Equal(nf.mediaData(nf.media[0].Name), f.media[0].Data) {
+		t.Fatal("unexpected media")
+	}
+	styles, err := nf.Styles()
+	if err != nil || len(styles.Styles) == 0 {
+		t.Fatal("unexpected styles", err)
+	}
+
+	// the content types are restored for the zip package
+	buf.Reset()
+	_, err = nf.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := zf.openTemplate("[Content_Types].xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ct.Close()
+	var types ContentTypes
+	err = xml.NewDecoder(ct).Decode(&types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types.Lookup("word/media/image1.png") != "image/png" ||
+		types.Lookup("word/document.xml") != "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml" {
+		t.Fatal("unexpected content types", types)
+	}
+
+	_, err = ParseFlatOPC(strings.NewReader("<w:document/>"))
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+}
@@ -3,3 +3,8 @@ module github.com/fumiama/go-docx
 go 1.20
 
 require github.com/fumiama/imgsz v0.0.2
+
+require (
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0
+)
@@ -0,0 +1,755 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bufio"
+	"encoding/base64"
+	"html"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HTMLOptions controls WriteHTMLTo
+type HTMLOptions struct {
+	// Fragment writes only the converted body, without <html> and the style sheet
+	Fragment bool
+	// MediaDir is the folder of images in the src, media by default
+	MediaDir string
+	// SaveMedia is called once on each image referred by the document,
+	// name is the file name in MediaDir. Images are embedded as data URIs
+	// if it is nil.
+	SaveMedia func(name string, data []byte) error
+}
+
+const htmlStyleSheet = `body{font-family:sans-serif}` +
+	`p,h1,h2,h3,h4,h5,h6,li{margin:0;white-space:pre-wrap}` +
+	`table{border-collapse:collapse}td{vertical-align:top;padding:0 5.4pt}`
+
+// WriteHTMLTo writes the body of f to w as HTML.
+//
+// Paragraphs keep their justification, indent and spacing, runs keep
+// their fonts, colors, highlight, underline and shading, and tables keep
+// their merged cells and borders. Headings and lists are converted into
+// <h1>-<h6>, <ul> and <ol>. Inline and anchored images are both placed
+// inline, and the paragraphs in text boxes are written after the paragraph
+// holding them. Formatting inherited from styles is not resolved.
+func (f *Docx) WriteHTMLTo(w io.Writer, opt *HTMLOptions) error {
+	if opt == nil {
+		opt = &HTMLOptions{}
+	}
+	h := htmlWriter{
+		f:     f,
+		opt:   opt,
+		w:     bufio.NewWriter(w),
+		nums:  make(map[string][]int, 8),
+		saved: make(map[string]struct{}, 8),
+	}
+	if !opt.Fragment {
+		h.w.WriteString(`<!DOCTYPE html>` + "\n" + `<html><head><meta charset="utf-8"><style>`)
+		h.w.WriteString(htmlStyleSheet)
+		h.w.WriteString("</style></head><body>\n")
+	}
+	h.blocks(f.Document.Body.Items)
+	if h.err != nil {
+		return h.err
+	}
+	if !opt.Fragment {
+		h.w.WriteString("</body></html>\n")
+	}
+	return h.w.Flush()
+}
+
+type htmlWriter struct {
+	f   *Docx
+	opt *HTMLOptions
+	w   *bufio.Writer
+	err error
+
+	lists []htmlList          // lists is the stack of the opened lists
+	nums  map[string][]int    // nums is the counters of each level of numId
+	saved map[string]struct{} // saved media names
+}
+
+// htmlList is an opened <ul> or <ol>
+type htmlList struct {
+	numID  string
+	tag    string
+	itemed bool // itemed is set if an <li> is opened in it
+}
+
+// blocks writes the paragraphs and tables in items
+func (h *htmlWriter) blocks(items []interface{}) {
+	for _, it := range items {
+		switch o := it.(type) {
+		case *Paragraph:
+			h.paragraph(o)
+		case *Table:
+			h.closeLists(0)
+			h.table(o)
+		}
+		if h.err != nil {
+			return
+		}
+	}
+	h.closeLists(0)
+}
+
+// closeLists closes the opened lists until n left
+func (h *htmlWriter) closeLists(n int) {
+	for len(h.lists) > n {
+		l := h.lists[len(h.lists)-1]
+		if l.itemed {
+			h.w.WriteString("</li>")
+		}
+		h.w.WriteString("</" + l.tag + ">\n")
+		h.lists = h.lists[:len(h.lists)-1]
+	}
+}
+
+// listTag returns the tag and its type attribute of numFmt
+func listTag(numFmt string) (tag, typ string) {
+	switch numFmt {
+	case "", "bullet", "none":
+		return "ul", ""
+	case "lowerLetter":
+		return "ol", "a"
+	case "upperLetter":
+		return "ol", "A"
+	case "lowerRoman":
+		return "ol", "i"
+	case "upperRoman":
+		return "ol", "I"
+	}
+	return "ol", ""
+}
+
+// count counts the item at ilvl of numID and returns its number
+func (h *htmlWriter) count(numID string, ilvl int) int {
+	c, ok := h.nums[numID]
+	if !ok {
+		c = make([]int, 9)
+		h.nums[numID] = c
+	}
+	if c[ilvl] == 0 {
+		c[ilvl] = h.f.listStart(numID, ilvl)
+	} else {
+		c[ilvl]++
+	}
+	for i := ilvl + 1; i < len(c); i++ {
+		c[i] = 0
+	}
+	return c[ilvl]
+}
+
+// item opens an <li> at ilvl of numID, opening and closing the lists on need
+func (h *htmlWriter) item(numID string, ilvl int, numFmt string) {
+	if ilvl < 0 || ilvl > 8 {
+		ilvl = 0
+	}
+	n := h.count(numID, ilvl)
+	tag, typ := listTag(numFmt)
+	h.closeLists(ilvl + 1)
+	if len(h.lists) == ilvl+1 {
+		if l := h.lists[ilvl]; l.numID != numID || l.tag != tag {
+			h.closeLists(ilvl)
+		}
+	}
+	for len(h.lists) < ilvl+1 {
+		h.w.WriteString("<" + tag)
+		if len(h.lists) == ilvl {
+			if typ != "" {
+				h.w.WriteString(` type="` + typ + `"`)
+			}
+			if tag == "ol" && n != 1 {
+				h.w.WriteString(` start="` + strconv.Itoa(n) + `"`)
+			}
+		}
+		h.w.WriteString(">")
+		h.lists = append(h.lists, htmlList{numID: numID, tag: tag})
+	}
+	l := &h.lists[ilvl]
+	if l.itemed {
+		h.w.WriteString("</li>\n")
+	}
+	l.itemed = true
+	h.w.WriteString("<li>")
+}
+
+func (h *htmlWriter) paragraph(p *Paragraph) {
+	tag := "p"
+	if lv := h.f.HeadingLevel(p); lv > 0 {
+		if lv > 6 {
+			lv = 6
+		}
+		tag = "h" + strconv.Itoa(lv)
+	}
+	numID, ilvl, numFmt := h.f.ListLevel(p)
+	if numID != "" && tag == "p" {
+		h.item(numID, ilvl, numFmt)
+	} else {
+		h.closeLists(0)
+	}
+	content, boxes := h.inline(p)
+	if content == "" {
+		content = "<br>"
+	}
+	h.w.WriteString("<" + tag)
+	if style := paragraphCSS(p.Properties, numID != ""); style != "" {
+		h.w.WriteString(` style="` + html.EscapeString(style) + `"`)
+	}
+	h.w.WriteString(">" + content + "</" + tag + ">")
+	if numID == "" {
+		h.w.WriteByte('\n')
+	}
+	for _, b := range boxes {
+		h.paragraph(b)
+	}
+}
+
+// twipsToPt formats twips (1/20 point) as css points
+func twipsToPt(twips int64) string {
+	return strconv.FormatFloat(float64(twips)/20, 'f', -1, 64) + "pt"
+}
+
+// paragraphCSS converts the paragraph properties into css, leaving
+// the indent of list items to the list
+func paragraphCSS(pp *ParagraphProperties, list bool) string {
+	if pp == nil {
+		return ""
+	}
+	var css []string
+	if pp.Justification != nil {
+		switch pp.Justification.Val {
+		case "center":
+			css = append(css, "text-align:center")
+		case "end", "right":
+			css = append(css, "text-align:right")
+		case "both", "distribute":
+			css = append(css, "text-align:justify")
+		}
+	}
+	if pp.Ind != nil && !list {
+		if pp.Ind.Left != 0 {
+			css = append(css, "margin-left:"+twipsToPt(int64(pp.Ind.Left)))
+		}
+		switch {
+		case pp.Ind.FirstLine != 0:
+			css = append(css, "text-indent:"+twipsToPt(int64(pp.Ind.FirstLine)))
+		case pp.Ind.Hanging != 0:
+			css = append(css, "text-indent:"+twipsToPt(-int64(pp.Ind.Hanging)))
+		case pp.Ind.FirstLineChars != 0:
+			css = append(css, "text-indent:"+strconv.FormatFloat(float64(pp.Ind.FirstLineChars)/100, 'f', -1, 64)+"em")
+		}
+	}
+	if pp.Spacing != nil {
+		if pp.Spacing.Before != 0 {
+			css = append(css, "margin-top:"+twipsToPt(int64(pp.Spacing.Before)))
+		}
+		if pp.Spacing.After != 0 {
+			css = append(css, "margin-bottom:"+twipsToPt(int64(pp.Spacing.After)))
+		}
+		if pp.Spacing.Line != 0 {
+			switch pp.Spacing.LineRule {
+			case "", "auto":
+				css = append(css, "line-height:"+strconv.FormatFloat(float64(pp.Spacing.Line)/240, 'f', -1, 64))
+			default:
+				css = append(css, "line-height:"+twipsToPt(int64(pp.Spacing.Line)))
+			}
+		}
+	}
+	if c := shadeColor(pp.Shade); c != "" {
+		css = append(css, "background-color:"+c)
+	}
+	return strings.Join(css, ";")
+}
+
+var hexColorRe = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+
+// htmlColor converts a hex color of docx into css, or returns "" if invalid
+func htmlColor(c string) string {
+	c = strings.TrimPrefix(c, "#")
+	if !hexColorRe.MatchString(c) {
+		return ""
+	}
+	return "#" + c
+}
+
+func shadeColor(s *Shade) string {
+	if s == nil {
+		return ""
+	}
+	return htmlColor(s.Fill)
+}
+
+// highlightColor converts a highlight of docx into css
+func highlightColor(h *Highlight) string {
+	if h == nil {
+		return ""
+	}
+	switch h.Val {
+	case "", "none":
+		return ""
+	case "darkYellow":
+		return "#808000"
+	}
+	for _, c := range h.Val {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return ""
+		}
+	}
+	return strings.ToLower(h.Val)
+}
+
+// runTags returns the opening tags of the formatting of rp
+func runTags(rp *RunProperties) []string {
+	if rp == nil {
+		return nil
+	}
+	var tags []string
+	var css []string
+	if rp.Fonts != nil {
+		font := rp.Fonts.ASCII
+		if font == "" {
+			font = rp.Fonts.EastAsia
+		}
+		if font != "" {
+			css = append(css, "font-family:'"+strings.ReplaceAll(font, "'", "")+"'")
+		}
+	}
+	if rp.Color != nil {
+		if c := htmlColor(rp.Color.Val); c != "" {
+			css = append(css, "color:"+c)
+		}
+	}
+	if rp.Size != nil {
+		if sz, err := strconv.Atoi(rp.Size.Val); err == nil {
+			css = append(css, "font-size:"+strconv.FormatFloat(float64(sz)/2, 'f', -1, 64)+"pt")
+		}
+	}
+	if c := highlightColor(rp.Highlight); c != "" {
+		css = append(css, "background-color:"+c)
+	} else if c := shadeColor(rp.Shade); c != "" {
+		css = append(css, "background-color:"+c)
+	}
+	if len(css) > 0 {
+		tags = append(tags, `span style="`+html.EscapeString(strings.Join(css, ";"))+`"`)
+	}
+	if rp.Bold != nil && toggleOn(rp.Bold.Val) {
+		tags = append(tags, "b")
+	}
+	if rp.Italic != nil && toggleOn(rp.Italic.Val) {
+		tags = append(tags, "i")
+	}
+	if rp.Underline != nil && rp.Underline.Val != "none" {
+		tags = append(tags, "u")
+	}
+	if rp.Strike != nil && toggleOn(rp.Strike.Val) {
+		tags = append(tags, "s")
+	}
+	if rp.VertAlign != nil {
+		switch rp.VertAlign.Val {
+		case "superscript":
+			tags = append(tags, "sup")
+		case "subscript":
+			tags = append(tags, "sub")
+		}
+	}
+	return tags
+}
+
+// wrapTags wraps s by tags
+func wrapTags(sb *strings.Builder, tags []string, s string) {
+	for _, t := range tags {
+		sb.WriteString("<" + t + ">")
+	}
+	sb.WriteString(s)
+	for i := len(tags) - 1; i >= 0; i-- {
+		t := tags[i]
+		if k := strings.IndexByte(t, ' '); k >= 0 {
+			t = t[:k]
+		}
+		sb.WriteString("</" + t + ">")
+	}
+}
+
+// safeHref checks whether the link target is http, https, mailto
+// or a fragment, so that links like javascript: are not written
+func safeHref(target string) bool {
+	t := strings.ToLower(target)
+	return strings.HasPrefix(t, "http://") || strings.HasPrefix(t, "https://") ||
+		strings.HasPrefix(t, "mailto:") || strings.HasPrefix(t, "#")
+}
+
+// inline renders the children of p, and returns the paragraphs of its text boxes
+func (h *htmlWriter) inline(p *Paragraph) (string, []*Paragraph) {
+	sb := strings.Builder{}
+	var boxes []*Paragraph
+	for _, c := range p.Children {
+		switch o := c.(type) {
+		case *Hyperlink:
+			target, err := h.f.ReferTarget(o.ID)
+			if err != nil {
+				target = "#" + o.ID
+			}
+			if !safeHref(target) {
+				wrapTags(&sb, runTags(o.Run.RunProperties), html.EscapeString(PlainText(o)))
+				continue
+			}
+			sb.WriteString(`<a href="` + html.EscapeString(target) + `">`)
+			wrapTags(&sb, runTags(o.Run.RunProperties), html.EscapeString(PlainText(o)))
+			sb.WriteString("</a>")
+		case *Run:
+			rs := strings.Builder{}
+			for _, x := range o.Children {
+				switch y := x.(type) {
+				case *Text:
+					rs.WriteString(html.EscapeString(y.Text))
+				case *Tab:
+					rs.WriteByte('\t')
+				case *BarterRabbet:
+					switch y.Type {
+					case "", "textWrapping":
+						rs.WriteString("<br>")
+					case "page":
+						rs.WriteString(`<br style="page-break-before:always">`)
+					}
+				case *Drawing:
+					for _, pic := range FindIn[*Picture](y) {
+						rs.WriteString(h.image(y, pic))
+					}
+					for _, sp := range FindIn[*WordprocessingShape](y) {
+						if sp.TextBox != nil && sp.TextBox.Content != nil {
+							for k := range sp.TextBox.Content.Paragraphs {
+								boxes = append(boxes, &sp.TextBox.Content.Paragraphs[k])
+							}
+						}
+					}
+				}
+			}
+			if rs.Len() > 0 {
+				wrapTags(&sb, runTags(o.RunProperties), rs.String())
+			}
+		}
+	}
+	return sb.String(), boxes
+}
+
+// emuToPx converts EMU into css pixels
+func emuToPx(emu int64) string {
+	return strconv.FormatInt((emu+4762)/9525, 10)
+}
+
+// image returns the <img> of pic and saves its media
+func (h *htmlWriter) image(d *Drawing, pic *Picture) string {
+	if pic.BlipFill == nil || pic.BlipFill.Blip.Embed == "" {
+		return ""
+	}
+	target, err := h.f.ReferTarget(pic.BlipFill.Blip.Embed)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(target)
+	media := h.f.Media(name)
+	var alt string
+	var ext *WPExtent
+	switch {
+	case d.Inline != nil:
+		ext = d.Inline.Extent
+		if d.Inline.DocPr != nil {
+			alt = d.Inline.DocPr.Name
+		}
+	case d.Anchor != nil:
+		ext = d.Anchor.Extent
+		if d.Anchor.DocPr != nil {
+			alt = d.Anchor.DocPr.Name
+		}
+	}
+	src := ""
+	switch {
+	case h.opt.SaveMedia == nil:
+		if media == nil {
+			return ""
+		}
+		typ := mime.TypeByExtension(path.Ext(name))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		data, err := media.Load()
+		if err != nil && h.err == nil {
+			h.err = err
+		}
+		src = "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data)
+	default:
+		dir := h.opt.MediaDir
+		if dir == "" {
+			dir = "media"
+		}
+		if _, ok := h.saved[name]; !ok && media != nil {
+			h.saved[name] = struct{}{}
+			data, err := media.Load()
+			if err == nil {
+				err = h.opt.SaveMedia(name, data)
+			}
+			if err != nil && h.err == nil {
+				h.err = err
+			}
+		}
+		src = path.Join(dir, name)
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `"`)
+	cx, cy := int64(0), int64(0)
+	if pic.SpPr != nil {
+		cx, cy = pic.SpPr.Xfrm.Ext.CX, pic.SpPr.Xfrm.Ext.CY
+	}
+	if (cx == 0 || cy == 0) && ext != nil {
+		cx, cy = ext.CX, ext.CY
+	}
+	if cx > 0 && cy > 0 {
+		sb.WriteString(` width="` + emuToPx(cx) + `" height="` + emuToPx(cy) + `"`)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+// borderCSS converts a border of docx into css
+func borderCSS(b *WTableBorder) string {
+	if b == nil {
+		return ""
+	}
+	style := "solid"
+	switch b.Val {
+	case "", "nil", "none":
+		return "none"
+	case "double":
+		style = "double"
+	case "dotted":
+		style = "dotted"
+	case "dashed", "dashSmallGap", "dotDash", "dotDotDash":
+		style = "dashed"
+	}
+	width := "0.5pt"
+	if b.Size > 0 {
+		width = strconv.FormatFloat(float64(b.Size)/8, 'f', -1, 64) + "pt"
+	}
+	color := htmlColor(b.Color)
+	if color == "" {
+		color = "#000000"
+	}
+	return width + " " + style + " " + color
+}
+
+// side gets the border by its name like top or insideH
+func (w *WTableBorders) side(name string) *WTableBorder {
+	switch name {
+	case "top":
+		return w.Top
+	case "left":
+		return w.Left
+	case "bottom":
+		return w.Bottom
+	case "right":
+		return w.Right
+	case "insideH":
+		return w.InsideH
+	case "insideV":
+		return w.InsideV
+	}
+	return nil
+}
+
+// gridSpan is the number of grid columns taken by c
+func gridSpan(c *WTableCell) int {
+	if c.TableCellProperties != nil && c.TableCellProperties.GridSpan != nil && c.TableCellProperties.GridSpan.Val > 1 {
+		return c.TableCellProperties.GridSpan.Val
+	}
+	return 1
+}
+
+// vMerge returns whether c is vertically merged, and whether it starts the merge
+func vMerge(c *WTableCell) (merged, restart bool) {
+	if c.TableCellProperties == nil || c.TableCellProperties.VMerge == nil {
+		return false, false
+	}
+	return true, c.TableCellProperties.VMerge.Val == "restart"
+}
+
+// cellAt finds the cell of row r at grid column col
+func cellAt(r *WTableRow, col int) *WTableCell {
+	g := 0
+	for _, c := range r.TableCells {
+		if g == col {
+			return c
+		}
+		g += gridSpan(c)
+		if g > col {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (h *htmlWriter) table(t *Table) {
+	var css []string
+	var borders *WTableBorders
+	if tp := t.TableProperties; tp != nil {
+		borders = tp.TableBorders
+		if tp.Width != nil && tp.Width.W > 0 {
+			switch tp.Width.Type {
+			case "pct":
+				css = append(css, "width:"+strconv.FormatFloat(float64(tp.Width.W)/50, 'f', -1, 64)+"%")
+			case "", "dxa":
+				css = append(css, "width:"+twipsToPt(tp.Width.W))
+			}
+		}
+		if tp.Justification != nil {
+			switch tp.Justification.Val {
+			case "center":
+				css = append(css, "margin-left:auto;margin-right:auto")
+			case "end", "right":
+				css = append(css, "margin-left:auto")
+			}
+		}
+	}
+	h.w.WriteString("<table")
+	if len(css) > 0 {
+		h.w.WriteString(` style="` + html.EscapeString(strings.Join(css, ";")) + `"`)
+	}
+	h.w.WriteString(">\n")
+	for i, r := range t.TableRows {
+		h.w.WriteString("<tr>")
+		col := 0
+		cols := 0
+		for _, c := range r.TableCells {
+			cols += gridSpan(c)
+		}
+		for _, c := range r.TableCells {
+			span := gridSpan(c)
+			merged, restart := vMerge(c)
+			if merged && !restart {
+				col += span
+				continue
+			}
+			h.w.WriteString("<td")
+			if span > 1 {
+				h.w.WriteString(` colspan="` + strconv.Itoa(span) + `"`)
+			}
+			rows := 1
+			if restart {
+				for _, nr := range t.TableRows[i+1:] {
+					nc := cellAt(nr, col)
+					if nc == nil {
+						break
+					}
+					if m, rs := vMerge(nc); !m || rs {
+						break
+					}
+					rows++
+				}
+				if rows > 1 {
+					h.w.WriteString(` rowspan="` + strconv.Itoa(rows) + `"`)
+				}
+			}
+			if style := h.cellCSS(c, borders, i == 0, i+rows >= len(t.TableRows), col == 0, col+span >= cols); style != "" {
+				h.w.WriteString(` style="` + html.EscapeString(style) + `"`)
+			}
+			h.w.WriteString(">")
+			h.cell(c)
+			h.w.WriteString("</td>")
+			col += span
+		}
+		h.w.WriteString("</tr>\n")
+	}
+	h.w.WriteString("</table>\n")
+}
+
+// cellCSS converts the cell properties and the table borders into css
+func (h *htmlWriter) cellCSS(c *WTableCell, tb *WTableBorders, top, bottom, left, right bool) string {
+	var css []string
+	var cb *WTableBorders
+	if tcp := c.TableCellProperties; tcp != nil {
+		cb = tcp.TableBorders
+		if tcp.TableCellWidth != nil && tcp.TableCellWidth.W > 0 && tcp.TableCellWidth.Type == "dxa" {
+			css = append(css, "width:"+twipsToPt(tcp.TableCellWidth.W))
+		}
+		if col := shadeColor(tcp.Shade); col != "" {
+			css = append(css, "background-color:"+col)
+		}
+		if tcp.VAlign != nil {
+			switch tcp.VAlign.Val {
+			case "center":
+				css = append(css, "vertical-align:middle")
+			case "bottom":
+				css = append(css, "vertical-align:bottom")
+			}
+		}
+	}
+	for i, b := range cellBorders(cb, tb, top, bottom, left, right) {
+		if v := borderCSS(b); v != "" {
+			css = append(css, "border-"+cellSides[i]+":"+v)
+		}
+	}
+	return strings.Join(css, ";")
+}
+
+var cellSides = [...]string{"top", "bottom", "left", "right"}
+
+// cellBorders resolves the top, bottom, left and right borders of a cell
+// by its borders cb, or the outer or inside borders tb of the table
+func cellBorders(cb, tb *WTableBorders, top, bottom, left, right bool) (borders [4]*WTableBorder) {
+	outer := [...]bool{top, bottom, left, right}
+	for i, side := range cellSides {
+		if cb != nil {
+			borders[i] = cb.side(side)
+		}
+		if borders[i] == nil && tb != nil {
+			switch {
+			case outer[i]:
+				borders[i] = tb.side(side)
+			case i < 2:
+				borders[i] = tb.InsideH
+			default:
+				borders[i] = tb.InsideV
+			}
+		}
+	}
+	return
+}
+
+// cell writes the paragraphs and tables in c
+func (h *htmlWriter) cell(c *WTableCell) {
+	items := make([]interface{}, 0, len(c.Paragraphs)+len(c.Tables))
+	for _, p := range c.Paragraphs {
+		items = append(items, p)
+	}
+	for _, t := range c.Tables {
+		items = append(items, t)
+	}
+	lists := h.lists
+	h.lists = nil
+	h.blocks(items)
+	h.lists = lists
+}
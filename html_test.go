@@ -0,0 +1,110 @@
+package docx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTMLTo(t *testing.T) {
+	f, err := FromMarkdown([]byte("# Title\n\n- one\n- two\n  1. sub\n\nend"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := f.AddParagraph().Justification("center")
+	p.AddText("red").Color("FF0000").Size("28")
+	p.AddText(" <u>").Underline("single").Highlight("yellow")
+	p.AddLink("link", "https://example.com?a=1&b=2")
+	tbl := f.AddTable(3, 3, 0, nil)
+	c := tbl.TableRows[0].TableCells[0]
+	c.TableCellProperties.GridSpan = &WGridSpan{Val: 2}
+	tbl.TableRows[0].TableCells = tbl.TableRows[0].TableCells[:2]
+	c.AddParagraph().AddText("wide")
+	tbl.TableRows[0].TableCells[1].TableCellProperties.VMerge = &WvMerge{Val: "restart"}
+	tbl.TableRows[1].TableCells[2].TableCellProperties.VMerge = &WvMerge{}
+	tbl.TableRows[2].TableCells[0].Shade("clear", "auto", "EEEEEE")
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteHTMLTo(buf, &HTMLOptions{Fragment: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, exp := range []string{
+		"<h1>Title</h1>\n",
+		"<ul><li><p>one</p></li>\n<li><p>two</p><ol type=\"a\"><li><p>sub</p></li></ol>\n</li></ul>\n<p>end</p>\n",
+		`<p style="text-align:center"><span style="color:#FF0000;font-size:14pt">red</span>` +
+			`<span style="background-color:yellow"><u> &lt;u&gt;</u></span>` +
+			`<a href="https://example.com?a=1&amp;b=2">link</a></p>`,
+		`<td colspan="2" style="`, `<td rowspan="2" style="`, "background-color:#EEEEEE;border-top:0.5pt solid #000000",
+		`<img src="data:image/jpeg;base64,/9j/`,
+	} {
+		if !strings.Contains(s, exp) {
+			t.Fatal("missing", exp, "in", s)
+		}
+	}
+	if strings.Count(s, "<td") != 7 {
+		t.Fatal("unexpected cells", strings.Count(s, "<td"))
+	}
+}
+
+func TestWriteHTMLToEscapes(t *testing.T) {
+	f := New().WithDefaultTheme()
+	p := f.AddParagraph()
+	p.Properties = &ParagraphProperties{Shade: &Shade{Fill: `000000"><script>alert(1)</script>`}}
+	p.AddText("shaded").Color(`red" onclick="x`).Highlight(`red;background:url(x)`)
+	f.AddParagraph().AddLink("bad", "javascript:alert(1)")
+	f.AddParagraph().AddLink("data", "DATA:text/html,<b>")
+	f.AddParagraph().AddLink("mail", "mailto:a@b.c")
+	f.AddParagraph().AddLink("anchor", "#top")
+	tbl := f.AddTable(1, 1, 0, nil)
+	tbl.TableRows[0].TableCells[0].Shade("clear", "auto", `"><img src=x onerror=alert(1)>`)
+	tbl.TableRows[0].TableCells[0].TableCellProperties.TableBorders = &WTableBorders{
+		Top: &WTableBorder{Val: "single", Color: `"onmouseover="x`},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := f.WriteHTMLTo(buf, &HTMLOptions{Fragment: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, bad := range []string{"<script", "onclick", "url(", "javascript:", "DATA:", "<img", "onmouseover"} {
+		if strings.Contains(s, bad) {
+			t.Fatal("unexpected", bad, "in", s)
+		}
+	}
+	for _, exp := range []string{"<p>shaded</p>", "<p>bad</p>", "<p>data</p>", `<a href="mailto:a@b.c">mail</a>`, `<a href="#top">anchor</a>`} {
+		if !strings.Contains(s, exp) {
+			t.Fatal("missing", exp, "in", s)
+		}
+	}
+}
+
+func TestWriteHTMLToStartAndSpacing(t *testing.T) {
+	f := New().WithDefaultTheme()
+	numID, err := f.AddListNumbering(true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddParagraph().NumPr(numID, "0").AddText("three")
+	f.AddParagraph().NumPr(numID, "0").AddText("four")
+	p := f.AddParagraph()
+	p.Properties = &ParagraphProperties{Spacing: &Spacing{Before: 240, After: 120}}
+	p.AddText("spaced")
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteHTMLTo(buf, &HTMLOptions{Fragment: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, exp := range []string{`<ol start="3">`, `<p style="margin-top:12pt;margin-bottom:6pt">spaced</p>`} {
+		if !strings.Contains(s, exp) {
+			t.Fatal("missing", exp, "in", s)
+		}
+	}
+}
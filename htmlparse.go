@@ -0,0 +1,995 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"errors"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrIndexOutOfBounds the index is not in the body
+var ErrIndexOutOfBounds = errors.New("index out of bounds")
+
+// HTMLImportOptions controls AddHTML in the same way as MarkdownImportOptions
+type HTMLImportOptions MarkdownImportOptions
+
+// FromHTML creates a docx in the default theme from the HTML in r
+func FromHTML(r io.Reader, opt *HTMLImportOptions) (*Docx, error) {
+	f := New().WithDefaultTheme()
+	err := f.AddHTML(r, opt)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddHTML appends the HTML in r to the body of f.
+//
+// p, div, h1-h6, blockquote, pre, hr, ul, ol, li, table (with colspan
+// and rowspan), a, img (data URI, or local file allowed by opt), br and
+// the inline formatting of b, i, u, s, sup, sub, code and span/font with
+// css colors, fonts and sizes are supported. Other tags are treated as
+// their contents. Nothing is added into f if an error is returned.
+func (f *Docx) AddHTML(r io.Reader, opt *HTMLImportOptions) error {
+	root, err := parseHTML(r)
+	if err != nil {
+		return err
+	}
+	if opt == nil {
+		opt = &HTMLImportOptions{}
+	}
+	st := f.saveImport()
+	b := htmlBuilder{
+		mdBuilder: mdBuilder{f: f, opt: (*MarkdownImportOptions)(opt), styles: make(map[string]string, 8)},
+		ctx:       &mdContext{ilvl: -1},
+	}
+	err = b.children(root, htmlFormat{})
+	b.end()
+	if err != nil {
+		f.rollbackImport(st)
+	}
+	return err
+}
+
+// InsertHTML inserts the HTML in r into the body of f before the item at index at
+func (f *Docx) InsertHTML(at int, r io.Reader, opt *HTMLImportOptions) error {
+	items := f.Document.Body.Items
+	if at < 0 || at > len(items) {
+		return ErrIndexOutOfBounds
+	}
+	n := len(items)
+	err := f.AddHTML(r, opt)
+	if err != nil {
+		return err
+	}
+	items = f.Document.Body.Items
+	added := append([]interface{}(nil), items[n:]...)
+	copy(items[at+len(added):], items[at:n])
+	copy(items[at:], added)
+	return nil
+}
+
+// htmlNode is an element or a text (with empty tag) of HTML
+type htmlNode struct {
+	tag      string
+	attr     map[string]string
+	text     string
+	raw      bool // raw keeps the white spaces of the text
+	children []*htmlNode
+}
+
+var htmlVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlClosedBy lists the open tags implicitly closed by a tag, up to the stop tags
+var htmlClosedBy = map[string][2][]string{
+	"li": {{"li"}, {"ul", "ol"}},
+	"tr": {{"tr", "td", "th"}, {"table", "thead", "tbody", "tfoot"}},
+	"td": {{"td", "th"}, {"tr", "table"}},
+	"th": {{"td", "th"}, {"tr", "table"}},
+	"p":  {{"p"}, {"li", "td", "th", "div", "blockquote", "body"}},
+}
+
+// htmlRawTags are the elements whose contents are raw text up to their end tag,
+// mapping to whether the text is kept
+var htmlRawTags = map[string]bool{"script": false, "style": false, "title": false, "textarea": true}
+
+// parseHTML parses r loosely into a tree of htmlNode
+func parseHTML(r io.Reader) (*htmlNode, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := string(data)
+	root := &htmlNode{tag: "#root"}
+	stack := []*htmlNode{root}
+	inHead := false
+	text := func(t string, raw bool) {
+		top := stack[len(stack)-1]
+		if k := len(top.children) - 1; k >= 0 && top.children[k].tag == "" && top.children[k].raw == raw {
+			top.children[k].text += t
+			return
+		}
+		top.children = append(top.children, &htmlNode{text: t, raw: raw})
+	}
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			if !inHead {
+				text(html.UnescapeString(s[i:i+j]), false)
+			}
+			i += j
+			continue
+		}
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			i += htmlSkipTo(rest[4:], "-->") + 4
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			i += htmlSkipTo(rest, ">")
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isHTMLLetter(rest[2]):
+			tag, _, _, n := htmlTag(rest[2:])
+			i += n + 2
+			if tag == "head" {
+				inHead = false
+			}
+			for k := len(stack) - 1; k > 0; k-- {
+				if stack[k].tag == tag {
+					stack = stack[:k]
+					break
+				}
+			}
+		case len(rest) > 1 && isHTMLLetter(rest[1]):
+			tag, attr, closed, n := htmlTag(rest[1:])
+			i += n + 1
+			if keep, ok := htmlRawTags[tag]; ok && !closed {
+				j := htmlEndTag(s[i:], tag)
+				if keep && !inHead {
+					stack[len(stack)-1].children = append(stack[len(stack)-1].children, &htmlNode{
+						tag: tag, attr: attr, children: []*htmlNode{{text: html.UnescapeString(s[i : i+j]), raw: true}},
+					})
+				}
+				i += j
+				i += htmlSkipTo(s[i:], ">")
+				continue
+			}
+			switch tag {
+			case "head":
+				inHead = true
+				continue
+			case "body":
+				inHead = false
+			}
+			if inHead {
+				continue
+			}
+			n0 := &htmlNode{tag: tag, attr: attr}
+			if c, ok := htmlClosedBy[tag]; ok {
+				for k := len(stack) - 1; k > 0; k-- {
+					if hasTag(c[1], stack[k].tag) {
+						break
+					}
+					if hasTag(c[0], stack[k].tag) {
+						stack = stack[:k]
+						break
+					}
+				}
+			}
+			top := stack[len(stack)-1]
+			top.children = append(top.children, n0)
+			if !htmlVoidTags[tag] && !closed {
+				stack = append(stack, n0)
+			}
+		default: // a < not starting a tag is text
+			if !inHead {
+				text("<", false)
+			}
+			i++
+		}
+	}
+	return root, nil
+}
+
+// htmlTag parses the tag name and attributes after < or </ at the start of s,
+// and returns the count of bytes up to and including the closing >
+func htmlTag(s string) (tag string, attr map[string]string, closed bool, n int) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '/' && s[i] != '>' {
+		i++
+	}
+	tag, attr = strings.ToLower(s[:i]), make(map[string]string, 4)
+	for i < len(s) {
+		switch {
+		case isHTMLSpace(s[i]):
+			i++
+			continue
+		case s[i] == '>':
+			return tag, attr, closed, i + 1
+		case s[i] == '/':
+			closed = strings.HasPrefix(s[i:], "/>")
+			i++
+			continue
+		}
+		j := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		if i == j { // a stray =
+			i++
+			continue
+		}
+		name := strings.ToLower(s[j:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) || s[i] != '=' {
+			attr[name] = ""
+			continue
+		}
+		i++
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+			q := s[i]
+			i++
+			j = i
+			for i < len(s) && s[i] != q {
+				i++
+			}
+			attr[name] = html.UnescapeString(s[j:i])
+			if i < len(s) {
+				i++
+			}
+			continue
+		}
+		j = i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+			i++
+		}
+		attr[name] = html.UnescapeString(s[j:i])
+	}
+	return tag, attr, closed, len(s)
+}
+
+// htmlEndTag returns the index of the end tag of tag in s, or len(s) if missing
+func htmlEndTag(s, tag string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			return len(s)
+		}
+		i += j
+		k := i + 2 + len(tag)
+		if k <= len(s) && strings.EqualFold(s[i+2:k], tag) && (k == len(s) || isHTMLSpace(s[k]) || s[k] == '>' || s[k] == '/') {
+			return i
+		}
+		i += 2
+	}
+}
+
+// htmlSkipTo returns the count of bytes in s up to and including sep, or len(s) if missing
+func htmlSkipTo(s, sep string) int {
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return len(s)
+	}
+	return i + len(sep)
+}
+
+func isHTMLLetter(c byte) bool {
+	return c|0x20 >= 'a' && c|0x20 <= 'z'
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSS parses the declarations in a style attribute
+func parseCSS(style string) map[string]string {
+	m := make(map[string]string, 4)
+	for _, decl := range strings.Split(style, ";") {
+		k, v, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+		m[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	return m
+}
+
+var cssColorNames = map[string]string{
+	"black": "000000", "white": "FFFFFF", "red": "FF0000", "green": "008000", "blue": "0000FF",
+	"yellow": "FFFF00", "cyan": "00FFFF", "aqua": "00FFFF", "magenta": "FF00FF", "fuchsia": "FF00FF",
+	"gray": "808080", "grey": "808080", "silver": "C0C0C0", "maroon": "800000", "olive": "808000",
+	"lime": "00FF00", "navy": "000080", "purple": "800080", "teal": "008080", "orange": "FFA500",
+}
+
+// cssColor converts a css color into hex of docx, or "" if unsupported
+func cssColor(c string) string {
+	c = strings.ToLower(strings.TrimSpace(c))
+	if hex, ok := cssColorNames[c]; ok {
+		return hex
+	}
+	switch {
+	case strings.HasPrefix(c, "#") && len(c) == 4:
+		return strings.ToUpper(string([]byte{c[1], c[1], c[2], c[2], c[3], c[3]}))
+	case strings.HasPrefix(c, "#") && len(c) == 7:
+		return strings.ToUpper(c[1:])
+	case strings.HasPrefix(c, "rgb(") && strings.HasSuffix(c, ")"):
+		parts := strings.Split(c[4:len(c)-1], ",")
+		if len(parts) != 3 {
+			return ""
+		}
+		sb := strings.Builder{}
+		for _, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || v < 0 || v > 255 {
+				return ""
+			}
+			sb.WriteString(strings.ToUpper(strconv.FormatInt(int64(v)|0x100, 16)[1:]))
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+// cssLength converts a css length into points, or 0 if unsupported
+func cssLength(l string) float64 {
+	l = strings.ToLower(strings.TrimSpace(l))
+	for _, u := range [...]struct {
+		unit  string
+		scale float64
+	}{{"pt", 1}, {"px", 0.75}, {"cm", 72 / 2.54}, {"mm", 72 / 25.4}, {"in", 72}} {
+		if strings.HasSuffix(l, u.unit) {
+			v, err := strconv.ParseFloat(strings.TrimSpace(l[:len(l)-len(u.unit)]), 64)
+			if err != nil {
+				return 0
+			}
+			return v * u.scale
+		}
+	}
+	return 0
+}
+
+// htmlFormat is the inline formatting inherited by the texts
+type htmlFormat struct {
+	b, i, u, s, code bool
+	vertAlign        string
+	color, font, bg  string
+	size             int // size is in half points
+	highlight        string
+	link             string
+}
+
+// withCSS adds the formatting in the style attribute of n
+func (fm htmlFormat) withCSS(n *htmlNode) htmlFormat {
+	style, ok := n.attr["style"]
+	if !ok {
+		return fm
+	}
+	css := parseCSS(style)
+	if c := cssColor(css["color"]); c != "" {
+		fm.color = c
+	}
+	if c := cssColor(css["background-color"]); c != "" {
+		fm.bg = c
+	} else if c := cssColor(css["background"]); c != "" {
+		fm.bg = c
+	}
+	if ff := css["font-family"]; ff != "" {
+		ff, _, _ = strings.Cut(ff, ",")
+		fm.font = strings.Trim(strings.TrimSpace(ff), `'"`)
+	}
+	if pt := cssLength(css["font-size"]); pt > 0 {
+		fm.size = int(pt*2 + 0.5)
+	}
+	switch w := css["font-weight"]; w {
+	case "bold", "bolder", "600", "700", "800", "900":
+		fm.b = true
+	case "normal", "lighter", "400":
+		fm.b = false
+	}
+	switch css["font-style"] {
+	case "italic", "oblique":
+		fm.i = true
+	case "normal":
+		fm.i = false
+	}
+	if td := css["text-decoration"] + " " + css["text-decoration-line"]; td != " " {
+		if strings.Contains(td, "underline") {
+			fm.u = true
+		}
+		if strings.Contains(td, "line-through") {
+			fm.s = true
+		}
+	}
+	switch css["vertical-align"] {
+	case "super":
+		fm.vertAlign = "superscript"
+	case "sub":
+		fm.vertAlign = "subscript"
+	}
+	return fm
+}
+
+// apply sets the formatting into r
+func (fm *htmlFormat) apply(b *htmlBuilder, r *Run) error {
+	if r.RunProperties == nil {
+		r.RunProperties = &RunProperties{}
+	}
+	if fm.b {
+		r.Bold()
+	}
+	if fm.i {
+		r.Italic()
+	}
+	if fm.u {
+		r.Underline("single")
+	}
+	if fm.s {
+		r.Strike(true)
+	}
+	if fm.vertAlign != "" {
+		r.RunProperties.VertAlign = &VertAlign{Val: fm.vertAlign}
+	}
+	if fm.color != "" {
+		r.Color(fm.color)
+	}
+	if fm.size > 0 {
+		r.Size(strconv.Itoa(fm.size))
+	}
+	if fm.font != "" {
+		r.Font(fm.font, fm.font, fm.font, "")
+	}
+	if fm.highlight != "" {
+		r.Highlight(fm.highlight)
+	} else if fm.bg != "" {
+		r.Shade("clear", "auto", fm.bg)
+	}
+	if fm.code {
+		id, err := b.style("verb")
+		if err != nil {
+			return err
+		}
+		r.RunProperties.RunStyle = &RunStyle{Val: id}
+	}
+	return nil
+}
+
+// htmlBlock is the properties of the paragraphs in the current block
+type htmlBlock struct {
+	style string // style is the key in markdownStyles
+	jc    string
+	pre   bool
+}
+
+// htmlBuilder adds the HTML nodes into a docx
+type htmlBuilder struct {
+	mdBuilder
+	ctx   *mdContext
+	block htmlBlock
+	p     *Paragraph // p is the paragraph receiving the inline contents
+	space bool       // space is set if p is empty or ends with a space
+}
+
+// ensure returns the current paragraph, adding one on need
+func (b *htmlBuilder) ensure() (*Paragraph, error) {
+	if b.p != nil {
+		return b.p, nil
+	}
+	p := b.para(b.ctx)
+	if b.block.style != "" {
+		id, err := b.style(b.block.style)
+		if err != nil {
+			return nil, err
+		}
+		p.Style(id)
+	}
+	if b.block.jc != "" {
+		p.Justification(b.block.jc)
+	}
+	b.p, b.space = p, true
+	return p, nil
+}
+
+// end ends the current paragraph, trimming its trailing spaces
+func (b *htmlBuilder) end() {
+	if b.p == nil {
+		return
+	}
+	if !b.block.pre {
+	trim:
+		for i := len(b.p.Children) - 1; i >= 0; i-- {
+			r, ok := b.p.Children[i].(*Run)
+			if !ok {
+				break
+			}
+			for j := len(r.Children) - 1; j >= 0; j-- {
+				t, ok := r.Children[j].(*Text)
+				if !ok {
+					break trim
+				}
+				t.Text = strings.TrimRight(t.Text, " ")
+				if t.Text != "" {
+					break trim
+				}
+				r.Children = r.Children[:j]
+			}
+		}
+	}
+	b.p = nil
+}
+
+// enter starts a block of n, returning the previous block to be restored
+func (b *htmlBuilder) enter(n *htmlNode) htmlBlock {
+	b.end()
+	prev := b.block
+	if style, ok := n.attr["style"]; ok {
+		switch css := parseCSS(style); css["text-align"] {
+		case "left", "start":
+			b.block.jc = "left"
+		case "center":
+			b.block.jc = "center"
+		case "right", "end":
+			b.block.jc = "right"
+		case "justify":
+			b.block.jc = "both"
+		}
+	}
+	if a := strings.ToLower(n.attr["align"]); a == "left" || a == "center" || a == "right" {
+		b.block.jc = a
+	}
+	return prev
+}
+
+// leave ends the block and restores prev
+func (b *htmlBuilder) leave(prev htmlBlock) {
+	b.end()
+	b.block = prev
+}
+
+func (b *htmlBuilder) children(n *htmlNode, fm htmlFormat) error {
+	for _, c := range n.children {
+		err := b.node(c, fm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *htmlBuilder) node(n *htmlNode, fm htmlFormat) error {
+	if n.tag == "" {
+		if n.raw && !b.block.pre {
+			b.block.pre = true
+			defer func() { b.block.pre = false }()
+		}
+		return b.text(n.text, fm)
+	}
+	fm = fm.withCSS(n)
+	switch n.tag {
+	case "b", "strong":
+		fm.b = true
+	case "i", "em", "cite", "var", "dfn":
+		fm.i = true
+	case "u", "ins":
+		fm.u = true
+	case "s", "strike", "del":
+		fm.s = true
+	case "sup":
+		fm.vertAlign = "superscript"
+	case "sub":
+		fm.vertAlign = "subscript"
+	case "code", "kbd", "samp", "tt":
+		fm.code = true
+	case "mark":
+		fm.highlight = "yellow"
+	case "font":
+		if c := cssColor(n.attr["color"]); c != "" {
+			fm.color = c
+		}
+		if face := n.attr["face"]; face != "" {
+			fm.font, _, _ = strings.Cut(face, ",")
+		}
+	case "a":
+		if href := n.attr["href"]; href != "" {
+			return b.link(n, href, fm)
+		}
+	case "br":
+		p, err := b.ensure()
+		if err != nil {
+			return err
+		}
+		p.Children = append(p.Children, &Run{
+			RunProperties: &RunProperties{},
+			Children:      []interface{}{&BarterRabbet{}},
+		})
+		b.space = true
+		return nil
+	case "img":
+		return b.img(n, fm)
+	case "hr":
+		prev := b.enter(n)
+		_, err := b.styledPara(b.ctx, "rule")
+		b.leave(prev)
+		return err
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		prev := b.enter(n)
+		b.block.style = n.tag
+		_, err := b.ensure()
+		if err == nil {
+			err = b.children(n, fm)
+		}
+		b.leave(prev)
+		return err
+	case "pre":
+		prev := b.enter(n)
+		b.block.style, b.block.pre = "code", true
+		if len(n.children) > 0 && n.children[0].tag == "" {
+			n.children[0].text = strings.TrimPrefix(n.children[0].text, "\n")
+		}
+		_, err := b.ensure()
+		if err == nil {
+			err = b.children(n, fm)
+		}
+		b.leave(prev)
+		return err
+	case "blockquote":
+		prev := b.enter(n)
+		b.block.style = "quote"
+		err := b.children(n, fm)
+		b.leave(prev)
+		return err
+	case "ul", "ol":
+		return b.list(n, fm)
+	case "table":
+		return b.table(n, fm)
+	case "p", "div", "li", "html", "body", "section", "article", "header", "footer", "main", "nav",
+		"aside", "figure", "figcaption", "address", "center", "dl", "dt", "dd", "form", "fieldset":
+		prev := b.enter(n)
+		if n.tag == "center" {
+			b.block.jc = "center"
+		}
+		err := b.children(n, fm)
+		b.leave(prev)
+		return err
+	}
+	return b.children(n, fm)
+}
+
+// text adds s into the current paragraph, collapsing the white spaces out of pre
+func (b *htmlBuilder) text(s string, fm htmlFormat) error {
+	if !b.block.pre {
+		words := strings.Fields(s)
+		sb := strings.Builder{}
+		if s != "" && isHTMLSpace(s[0]) && b.p != nil && !b.space {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(strings.Join(words, " "))
+		if len(words) > 0 && isHTMLSpace(s[len(s)-1]) {
+			sb.WriteByte(' ')
+		}
+		s = sb.String()
+		if s == "" {
+			return nil
+		}
+	}
+	p, err := b.ensure()
+	if err != nil {
+		return err
+	}
+	r := p.AddText(s)
+	preserveSpace(r)
+	b.space = strings.HasSuffix(s, " ")
+	return fm.apply(b, r)
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// plainHTML is the collapsed text in n
+func plainHTML(n *htmlNode) string {
+	sb := strings.Builder{}
+	var walk func(n *htmlNode)
+	walk = func(n *htmlNode) {
+		if n.tag == "" {
+			sb.WriteString(n.text)
+			return
+		}
+		if n.tag == "img" {
+			sb.WriteString(n.attr["alt"])
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// link adds n as a hyperlink to href
+func (b *htmlBuilder) link(n *htmlNode, href string, fm htmlFormat) error {
+	text := plainHTML(n)
+	if text == "" {
+		text = href
+	}
+	p, err := b.ensure()
+	if err != nil {
+		return err
+	}
+	h := p.AddLink(text, href)
+	b.space = false
+	return fm.apply(b, &h.Run)
+}
+
+// img adds the image of n, or a link to it if it is remote or not an image
+func (b *htmlBuilder) img(n *htmlNode, fm htmlFormat) error {
+	src := n.attr["src"]
+	if src == "" {
+		return nil
+	}
+	data, err := b.image(src)
+	if err != nil {
+		data = nil // fall back to a link
+	}
+	p, err := b.ensure()
+	if err != nil {
+		return err
+	}
+	b.space = false
+	if data != nil {
+		r, err := p.AddInlineDrawing(data)
+		if err == nil {
+			b.sizeImage(r, n)
+			return nil
+		}
+	}
+	text := n.attr["alt"]
+	if text == "" {
+		text = src
+	}
+	return fm.apply(b, &p.AddLink(text, src).Run)
+}
+
+// sizeImage applies the width and height of n to the image in r, keeping its aspect ratio
+func (b *htmlBuilder) sizeImage(r *Run, n *htmlNode) {
+	d, ok := r.Children[0].(*Drawing)
+	if !ok || d.Inline == nil || d.Inline.Extent == nil {
+		return
+	}
+	css := parseCSS(n.attr["style"])
+	length := func(key string) int64 {
+		if v := cssLength(css[key]); v > 0 {
+			return int64(v * 12700)
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(n.attr[key], "px"), 64); err == nil && v > 0 {
+			return int64(v * 9525)
+		}
+		return 0
+	}
+	w, h := length("width"), length("height")
+	cx, cy := d.Inline.Extent.CX, d.Inline.Extent.CY
+	switch {
+	case w > 0 && h > 0:
+	case w > 0 && cx > 0:
+		h = w * cy / cx
+	case h > 0 && cy > 0:
+		w = h * cx / cy
+	default:
+		return
+	}
+	d.Inline.Size(w, h)
+}
+
+// list adds the items of n in a new list
+func (b *htmlBuilder) list(n *htmlNode, fm htmlFormat) error {
+	if b.ctx.first {
+		_, err := b.ensure() // the item holding only a nested list
+		if err != nil {
+			return err
+		}
+	}
+	prev := b.enter(n)
+	defer b.leave(prev)
+	start := 1
+	if v, err := strconv.Atoi(n.attr["start"]); err == nil {
+		start = v
+	}
+	ilvl := b.ctx.ilvl + 1
+	if ilvl > 8 {
+		ilvl = 8
+	}
+	starts := make([]int, ilvl+1)
+	starts[ilvl] = start
+	numID, err := b.f.AddListNumbering(n.tag == "ol", starts...)
+	if err != nil {
+		return err
+	}
+	ctx := b.ctx
+	defer func() { b.ctx = ctx }()
+	for _, c := range n.children {
+		if c.tag != "li" {
+			if c.tag != "" {
+				err = b.node(c, fm)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		b.ctx = &mdContext{ilvl: ilvl, numID: numID, first: true}
+		iprev := b.enter(c)
+		err = b.children(c, fm.withCSS(c))
+		if err == nil && b.ctx.first {
+			_, err = b.ensure()
+		}
+		b.leave(iprev)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// htmlCell is a cell in the grid of a table
+type htmlCell struct {
+	node *htmlNode
+	span int // span is the colspan
+	rows int // rows is the rowspan
+}
+
+// htmlRows collects the tr of a table through thead, tbody and tfoot
+func htmlRows(n *htmlNode) []*htmlNode {
+	var rows []*htmlNode
+	for _, c := range n.children {
+		switch c.tag {
+		case "tr":
+			rows = append(rows, c)
+		case "thead", "tbody", "tfoot":
+			rows = append(rows, htmlRows(c)...)
+		}
+	}
+	return rows
+}
+
+// table adds n as a table, merging the cells by colspan and rowspan
+func (b *htmlBuilder) table(n *htmlNode, fm htmlFormat) error {
+	prev := b.enter(n)
+	defer b.leave(prev)
+	trs := htmlRows(n)
+	if len(trs) == 0 {
+		return nil
+	}
+	grid := make([][]*htmlCell, len(trs))
+	cols := 0
+	for i, tr := range trs {
+		col := 0
+		for _, td := range tr.children {
+			if td.tag != "td" && td.tag != "th" {
+				continue
+			}
+			for col < len(grid[i]) && grid[i][col] != nil {
+				col++
+			}
+			// clamped as HTML does
+			span, _ := strconv.Atoi(td.attr["colspan"])
+			if span < 1 {
+				span = 1
+			} else if span > 1000 {
+				span = 1000
+			}
+			rows, _ := strconv.Atoi(td.attr["rowspan"])
+			if rows < 1 {
+				rows = 1
+			} else if rows > 65534 {
+				rows = 65534
+			}
+			if i+rows > len(trs) {
+				rows = len(trs) - i
+			}
+			cell := &htmlCell{node: td, span: span, rows: rows}
+			for r := i; r < i+rows; r++ {
+				for len(grid[r]) < col+span {
+					grid[r] = append(grid[r], nil)
+				}
+				for k := col; k < col+span; k++ {
+					grid[r][k] = cell
+				}
+			}
+			col += span
+		}
+		if len(grid[i]) > cols {
+			cols = len(grid[i])
+		}
+	}
+	if cols == 0 {
+		return nil
+	}
+	t := b.f.AddTable(len(trs), cols, 0, nil)
+	if b.cell != nil {
+		items := b.f.Document.Body.Items
+		b.f.Document.Body.Items = items[:len(items)-1]
+		b.cell.Tables = append(b.cell.Tables, t)
+	}
+	cell, ctx := b.cell, b.ctx
+	defer func() { b.cell, b.ctx = cell, ctx }()
+	for i, row := range grid {
+		tcs := t.TableRows[i].TableCells
+		cells := tcs[:0]
+		for col := 0; col < cols; {
+			tc := tcs[len(cells)]
+			hc := (*htmlCell)(nil)
+			if col < len(row) {
+				hc = row[col]
+			}
+			cells = append(cells, tc)
+			if hc == nil {
+				tc.AddParagraph()
+				col++
+				continue
+			}
+			if hc.span > 1 {
+				tc.TableCellProperties.GridSpan = &WGridSpan{Val: hc.span}
+			}
+			col += hc.span
+			if hc.rows > 1 {
+				if i > 0 && col-hc.span < len(grid[i-1]) && grid[i-1][col-hc.span] == hc {
+					tc.TableCellProperties.VMerge = &WvMerge{}
+					tc.AddParagraph()
+					continue
+				}
+				tc.TableCellProperties.VMerge = &WvMerge{Val: "restart"}
+			}
+			b.cell, b.ctx = tc, &mdContext{ilvl: -1}
+			cfm := fm.withCSS(hc.node)
+			if hc.node.tag == "th" {
+				cfm.b = true
+			}
+			cprev := b.enter(hc.node)
+			err := b.children(hc.node, cfm)
+			b.leave(cprev)
+			if err != nil {
+				return err
+			}
+			if len(tc.Paragraphs) == 0 {
+				tc.AddParagraph()
+			}
+		}
+		t.TableRows[i].TableCells = cells
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package docx
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromHTML(t *testing.T) {
+	src := `<!DOCTYPE html><html><head><title>x</title><style>p{color:red}</style></head><body>
+<h1>Title &amp; more</h1>
+<p style="text-align:center">plain <b>bold</b> <em>it</em> <u>under</u> <s>gone</s> x<sup>2</sup>
+ <span style="color:#f00;font-family:'Times New Roman', serif;font-size:12pt;background-color:rgb(255,255,0)">styled</span><br>
+<a href="https://example.com">link</a></p>
+<ul><li>one<li>two<ol start="3"><li>three</ol></ul>
+<table><tr><th colspan="2">head</th><th rowspan="2">tall</th></tr><tr><td>a</td><td>b</td></tr></table>
+<hr>
+<p>end<img src="testdata/fumiama.JPG" width="100">
+</body></html>`
+	f, err := FromHTML(strings.NewReader(src), &HTMLImportOptions{BaseDir: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := f.Document.Body.Items
+	if len(items) != 8 {
+		t.Fatal("unexpected items", len(items))
+	}
+	h := items[0].(*Paragraph)
+	if f.HeadingLevel(h) != 1 || h.String() != "Title & more" {
+		t.Fatal("unexpected heading", h.String())
+	}
+	p := items[1].(*Paragraph)
+	if p.String() != "plain bold it under gone x2 styled\n[link](https://example.com)" {
+		t.Fatal("unexpected text", p.String())
+	}
+	if p.Properties.Justification.Val != "center" {
+		t.Fatal("unexpected justification")
+	}
+	var styled *Run
+	for _, c := range p.Children {
+		if r, ok := c.(*Run); ok && PlainText(r) == "styled" {
+			styled = r
+		}
+	}
+	rp := styled.RunProperties
+	if rp.Color.Val != "FF0000" || rp.Size.Val != "24" || rp.Fonts.ASCII != "Times New Roman" || rp.Shade.Fill != "FFFF00" {
+		t.Fatal("unexpected run properties", rp.Color, rp.Size, rp.Fonts, rp.Shade)
+	}
+	for i, exp := range []struct {
+		text string
+		ilvl int
+	}{{"one", 0}, {"two", 0}, {"three", 1}} {
+		p := items[2+i].(*Paragraph)
+		numID, ilvl, _ := f.ListLevel(p)
+		if p.String() != strings.Repeat("  ", exp.ilvl)+exp.text || numID == "" || ilvl != exp.ilvl {
+			t.Fatal("unexpected item", p.String(), numID, ilvl)
+		}
+	}
+	tbl := items[5].(*Table)
+	r0, r1 := tbl.TableRows[0].TableCells, tbl.TableRows[1].TableCells
+	if len(r0) != 2 || r0[0].TableCellProperties.GridSpan.Val != 2 || r0[1].TableCellProperties.VMerge.Val != "restart" {
+		t.Fatal("unexpected header row")
+	}
+	if len(r1) != 3 || r1[2].TableCellProperties.VMerge == nil || r1[2].TableCellProperties.VMerge.Val != "" || r1[1].Paragraphs[0].String() != "b" {
+		t.Fatal("unexpected second row")
+	}
+	if r0[0].Paragraphs[0].Children[0].(*Run).RunProperties.Bold == nil {
+		t.Fatal("th should be bold")
+	}
+	end := items[7].(*Paragraph)
+	d := end.Children[1].(*Run).Children[0].(*Drawing)
+	if d.Inline.Extent.CX != 100*9525 {
+		t.Fatal("unexpected image width", d.Inline.Extent.CX)
+	}
+
+	err = f.InsertHTML(1, strings.NewReader("<p>inserted</p><p>again</p>"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items = f.Document.Body.Items
+	if len(items) != 10 || items[1].(*Paragraph).String() != "inserted" || items[2].(*Paragraph).String() != "again" || items[3] != p {
+		t.Fatal("unexpected insertion")
+	}
+}
+
+func TestFromHTMLLocalImages(t *testing.T) {
+	src := `<p><img src="../secret.png"><img src="/etc/passwd"><img src="file:///etc/passwd"><img src="missing.png" alt="gone"><img src="fumiama.JPG"></p>`
+	var read []string
+	f, err := FromHTML(strings.NewReader(src), &HTMLImportOptions{BaseDir: "testdata", ReadFile: func(name string) ([]byte, error) {
+		read = append(read, name)
+		return os.ReadFile(name)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 || read[0] != filepath.Join("testdata", "missing.png") || read[1] != filepath.Join("testdata", "fumiama.JPG") {
+		t.Fatal("unexpected reads", read)
+	}
+	links := 0
+	for _, r := range f.docRelation.Relationship {
+		if r.Type == REL_HYPERLINK && r.Target == "missing.png" {
+			links++
+		}
+	}
+	if links != 1 || len(f.media) != 1 {
+		t.Fatal("unexpected images", links, len(f.media))
+	}
+	f, err = FromHTML(strings.NewReader(`<img src="testdata/fumiama.JPG">`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.media) != 0 {
+		t.Fatal("local file read without options")
+	}
+}
+
+func TestInsertHTMLRollback(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("kept")
+	f.parts = map[string][]byte{"word/numbering.xml": []byte("<w:numbering")}
+	rels, rID := len(f.docRelation.Relationship), f.rID
+	err := f.InsertHTML(0, strings.NewReader(`<h1>head</h1><p><a href="https://example.com">link</a><img src="data:image/png;base64,`+
+		base64.StdEncoding.EncodeToString(mustRead(t, "testdata/fumiamayoko.png"))+`"></p><ul><li>item</li></ul>`), nil)
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	if len(f.Document.Body.Items) != 1 || len(f.docRelation.Relationship) != rels || f.rID != rID ||
+		len(f.media) != 0 || len(f.mediaNameIdx) != 0 || f.styles != nil || f.numbering != nil {
+		t.Fatal("import not rolled back")
+	}
+}
+
+func mustRead(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFromHTMLLoose(t *testing.T) {
+	src := `<head><title>t</title><script>if (a < b && c) {}</script></head><p class=x data-y = 'z'>a < b &amp;&nbsp;c<!-- <p>gone</p> --></p>
+<textarea>  keep <b>raw</b></textarea><STYLE>p > a {}</STYLE><br/>end`
+	f, err := FromHTML(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, it := range f.Document.Body.Items {
+		texts = append(texts, it.(*Paragraph).String())
+	}
+	if len(texts) != 2 || texts[0] != "a < b & c" || texts[1] != "  keep <b>raw</b>\nend" {
+		t.Fatalf("unexpected paragraphs %q", texts)
+	}
+}
+
+func TestFromHTMLSpanLimit(t *testing.T) {
+	f, err := FromHTML(strings.NewReader(`<table><tr><td colspan="1000000" rowspan="99999999">a</td></tr><tr><td>b</td></tr></table>`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := f.Document.Body.Items[0].(*Table)
+	r0, r1 := tbl.TableRows[0].TableCells, tbl.TableRows[1].TableCells
+	if len(tbl.TableRows) != 2 || len(r0) != 2 || r0[0].TableCellProperties.GridSpan.Val != 1000 || len(r1) != 2 || r1[0].TableCellProperties.VMerge == nil {
+		t.Fatal("unexpected table")
+	}
+}
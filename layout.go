@@ -0,0 +1,1568 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"image/color"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// RenderOptions controls the page layout of WritePDFTo and RenderPages
+type RenderOptions struct {
+	// Fonts maps the font names used by the runs, like "Times New Roman"
+	// or "宋体", to TrueType or OpenType data (collections are allowed).
+	// The bold and italic variants are looked up as "Name Bold",
+	// "Name Italic" and "Name Bold Italic".
+	Fonts map[string][]byte
+	// Fallback fonts are tried in order for the characters missing in the
+	// font of a run, like CJK ones. The Go fonts are used at last.
+	Fallback [][]byte
+}
+
+// renderPage is a laid out page in points, with y going down from the top
+type renderPage struct {
+	width, height float64
+	items         []interface{} // items are *renderText, *renderRect, *renderLine and *renderImage in painting order
+	links         []*renderLink
+}
+
+// renderText is a piece of text in one font
+type renderText struct {
+	x, y     float64 // y is the baseline
+	font     *renderFont
+	size     float64
+	color    color.RGBA
+	fakeBold bool
+	glyphs   []sfnt.GlyphIndex
+	width    float64
+	field    string // field is PAGE or NUMPAGES that is filled on placing
+}
+
+// renderRect is a filled rectangle
+type renderRect struct {
+	x, y, w, h float64
+	fill       color.RGBA
+}
+
+// renderLine is a stroked line
+type renderLine struct {
+	x1, y1, x2, y2 float64
+	width          float64
+	color          color.RGBA
+	dash           bool
+}
+
+// renderImage is an image scaled into its box
+type renderImage struct {
+	x, y, w, h float64
+	media      *Media
+	absX, absY bool // absX and absY are set if the position is relative to the page
+}
+
+// renderLink is a clickable area of a hyperlink
+type renderLink struct {
+	x, y, w, h float64
+	uri        string
+}
+
+// translate moves an item by dx and dy
+func translate(item interface{}, dx, dy float64) {
+	switch o := item.(type) {
+	case *renderText:
+		o.x += dx
+		o.y += dy
+	case *renderRect:
+		o.x += dx
+		o.y += dy
+	case *renderLine:
+		o.x1 += dx
+		o.x2 += dx
+		o.y1 += dy
+		o.y2 += dy
+	case *renderImage:
+		if !o.absX {
+			o.x += dx
+		}
+		if !o.absY {
+			o.y += dy
+		}
+	case *renderLink:
+		o.x += dx
+		o.y += dy
+	}
+}
+
+// setText replaces the glyphs of t by s
+func (t *renderText) setText(s string) {
+	t.glyphs = t.glyphs[:0]
+	t.width = 0
+	for _, r := range s {
+		g := t.font.glyph(r)
+		t.font.used[g] = r
+		t.glyphs = append(t.glyphs, g)
+		t.width += t.font.advance(g) * t.size
+	}
+}
+
+// renderBlock is a laid out line or table row placed as a whole
+type renderBlock struct {
+	height    float64
+	items     []interface{} // items are relative to the top-left of the block
+	spacer    bool          // spacer is dropped at the top of a page
+	pageBreak bool          // pageBreak starts a new page after the block
+}
+
+// twipsPt converts twips into points
+func twipsPt(twips int) float64 {
+	return float64(twips) / 20
+}
+
+// emuPt converts EMU into points
+func emuPt(emu int64) float64 {
+	return float64(emu) / 12700
+}
+
+// hexColor parses a hex color of docx, black on "auto" or failure
+func hexColor(s string) color.RGBA {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 6 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+}
+
+var highlightColors = map[string]string{
+	"black": "000000", "blue": "0000FF", "cyan": "00FFFF", "green": "00FF00", "magenta": "FF00FF",
+	"red": "FF0000", "yellow": "FFFF00", "white": "FFFFFF", "darkBlue": "000080", "darkCyan": "008080",
+	"darkGreen": "008000", "darkMagenta": "800080", "darkRed": "800000", "darkYellow": "808000",
+	"darkGray": "808080", "lightGray": "C0C0C0",
+}
+
+// mergeRunProperties sets the non-nil properties of src into dst
+func mergeRunProperties(dst, src *RunProperties) {
+	if src == nil {
+		return
+	}
+	if src.Fonts != nil {
+		fonts := RunFonts{}
+		if dst.Fonts != nil {
+			fonts = *dst.Fonts
+		}
+		for _, kv := range [...][2]*string{
+			{&fonts.ASCII, &src.Fonts.ASCII}, {&fonts.EastAsia, &src.Fonts.EastAsia}, {&fonts.HAnsi, &src.Fonts.HAnsi},
+		} {
+			if *kv[1] != "" {
+				*kv[0] = *kv[1]
+			}
+		}
+		dst.Fonts = &fonts
+	}
+	if src.Bold != nil {
+		dst.Bold = src.Bold
+	}
+	if src.Italic != nil {
+		dst.Italic = src.Italic
+	}
+	if src.Highlight != nil {
+		dst.Highlight = src.Highlight
+	}
+	if src.Color != nil {
+		dst.Color = src.Color
+	}
+	if src.Size != nil {
+		dst.Size = src.Size
+	}
+	if src.Shade != nil {
+		dst.Shade = src.Shade
+	}
+	if src.Underline != nil {
+		dst.Underline = src.Underline
+	}
+	if src.VertAlign != nil {
+		dst.VertAlign = src.VertAlign
+	}
+	if src.Strike != nil {
+		dst.Strike = src.Strike
+	}
+}
+
+// mergeParagraphProperties sets the non-nil layout properties of src into dst
+func mergeParagraphProperties(dst, src *ParagraphProperties) {
+	if src == nil {
+		return
+	}
+	if src.Justification != nil {
+		dst.Justification = src.Justification
+	}
+	if src.Ind != nil {
+		dst.Ind = src.Ind
+	}
+	if src.Spacing != nil {
+		dst.Spacing = src.Spacing
+	}
+	if src.Shade != nil {
+		dst.Shade = src.Shade
+	}
+	if src.NumProperties != nil {
+		dst.NumProperties = src.NumProperties
+	}
+}
+
+// styleProperties parses the pPr and rPr of a style definition
+func styleProperties(st *StyleDefinition) (*ParagraphProperties, *RunProperties) {
+	var pp *ParagraphProperties
+	var rp *RunProperties
+	for _, e := range st.elems {
+		switch e.Name {
+		case "pPr":
+			var v ParagraphProperties
+			if xml.Unmarshal(StringToBytes(e.XML), &v) == nil {
+				pp = &v
+			}
+		case "rPr":
+			var v RunProperties
+			if xml.Unmarshal(StringToBytes(e.XML), &v) == nil {
+				rp = &v
+			}
+		}
+	}
+	return pp, rp
+}
+
+// docDefaults parses the default pPr and rPr in docDefaults
+func (s *Styles) docDefaults() (*ParagraphProperties, *RunProperties) {
+	for _, e := range s.prefix {
+		if e.Name != "docDefaults" {
+			continue
+		}
+		var dd struct {
+			RPr struct {
+				RPr *RunProperties `xml:"rPr"`
+			} `xml:"rPrDefault"`
+			PPr struct {
+				PPr *ParagraphProperties `xml:"pPr"`
+			} `xml:"pPrDefault"`
+		}
+		if xml.Unmarshal(StringToBytes(e.XML), &dd) != nil {
+			break
+		}
+		return dd.PPr.PPr, dd.RPr.RPr
+	}
+	return nil, nil
+}
+
+// renderSection is the page geometry of a section in points
+type renderSection struct {
+	width, height            float64
+	top, bottom, left, right float64
+	header, footer           float64 // header and footer are their distances to the page edges
+	headerItems, footerItems []interface{}
+}
+
+// layouter paginates a document into renderPages
+type layouter struct {
+	f     *Docx
+	fonts *renderFonts
+
+	styles    *Styles
+	numbering *Numbering
+	defPP     ParagraphProperties
+	defRP     RunProperties
+	props     map[string]styleProps // props is the parsed properties of styles by id
+	nums      map[string][]int      // nums is the counters of each level of numId
+
+	pages []*renderPage
+	page  *renderPage
+	sect  *renderSection
+	y     float64
+	err   error
+}
+
+// layoutDocument lays out the body of f into pages
+func (f *Docx) layoutDocument(opt *RenderOptions) ([]*renderPage, error) {
+	if opt == nil {
+		opt = &RenderOptions{}
+	}
+	fonts, err := newRenderFonts(opt)
+	if err != nil {
+		return nil, err
+	}
+	l := &layouter{f: f, fonts: fonts, props: make(map[string]styleProps, 16), nums: make(map[string][]int, 8)}
+	l.styles, err = f.readStyles()
+	if err != nil {
+		return nil, err
+	}
+	if pp, rp := l.styles.docDefaults(); pp != nil || rp != nil {
+		mergeParagraphProperties(&l.defPP, pp)
+		mergeRunProperties(&l.defRP, rp)
+	}
+	if f.hasNumbering() {
+		l.numbering, err = f.readNumbering()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the body sectPr governs the last section wherever it is put
+	items := f.Document.Body.Items
+	var body *SectPr
+	for _, it := range items {
+		if sp, ok := it.(*SectPr); ok {
+			body = sp
+		}
+	}
+	start := 0
+	for i, it := range items {
+		sp := body
+		if p, ok := it.(*Paragraph); ok && p.Properties != nil && p.Properties.SectPr != nil {
+			sp = p.Properties.SectPr
+		} else if i < len(items)-1 {
+			continue
+		}
+		l.section(sp, items[start:i+1])
+		if l.err != nil {
+			return nil, l.err
+		}
+		start = i + 1
+	}
+	if len(l.pages) == 0 {
+		l.section(body, nil)
+	}
+	total := strconv.Itoa(len(l.pages))
+	for _, p := range l.pages {
+		for _, it := range p.items {
+			if t, ok := it.(*renderText); ok && t.field == "NUMPAGES" {
+				t.setText(total)
+			}
+		}
+	}
+	return l.pages, l.err
+}
+
+// section lays out items on new pages of sp
+func (l *layouter) section(sp *SectPr, items []interface{}) {
+	s := &renderSection{width: 595.3, height: 841.9, top: 72, bottom: 72, left: 90, right: 90, header: 42.55, footer: 49.6}
+	if sp != nil {
+		if sp.PgSz != nil && sp.PgSz.W > 0 && sp.PgSz.H > 0 {
+			s.width, s.height = twipsPt(sp.PgSz.W), twipsPt(sp.PgSz.H)
+		}
+		if m := sp.PgMar; m != nil {
+			s.top, s.bottom, s.left, s.right = twipsPt(m.Top), twipsPt(m.Bottom), twipsPt(m.Left+m.Gutter), twipsPt(m.Right)
+			s.header, s.footer = twipsPt(m.Header), twipsPt(m.Footer)
+		}
+		s.headerItems, s.footerItems = l.headerFooter(sp)
+	}
+	l.sect = s
+	l.newPage()
+	width := s.width - s.left - s.right
+	for _, it := range items {
+		switch o := it.(type) {
+		case *Paragraph:
+			l.place(l.paragraph(o, width), s.left)
+		case *Table:
+			l.place(l.table(o, width), s.left)
+		}
+		if l.err != nil {
+			return
+		}
+	}
+}
+
+// headerFooter finds the items of the default header and footer of sp
+func (l *layouter) headerFooter(sp *SectPr) (header, footer []interface{}) {
+	for _, r := range sp.HeaderReferences {
+		if r.Type != "" && r.Type != "default" {
+			continue
+		}
+		headers, err := l.f.Headers()
+		if err != nil {
+			l.err = err
+			return
+		}
+		for _, h := range headers {
+			if h.ID() == r.ID {
+				header = h.Items
+			}
+		}
+	}
+	for _, r := range sp.FooterReferences {
+		if r.Type != "" && r.Type != "default" {
+			continue
+		}
+		footers, err := l.f.Footers()
+		if err != nil {
+			l.err = err
+			return
+		}
+		for _, h := range footers {
+			if h.ID() == r.ID {
+				footer = h.Items
+			}
+		}
+	}
+	return
+}
+
+// newPage starts a page of the current section with its header and footer
+func (l *layouter) newPage() {
+	s := l.sect
+	l.page = &renderPage{width: s.width, height: s.height}
+	l.pages = append(l.pages, l.page)
+	width := s.width - s.left - s.right
+	if len(s.headerItems) > 0 {
+		y := s.header
+		for _, b := range l.blocks(s.headerItems, width) {
+			l.emit(b, s.left, y)
+			y += b.height
+		}
+	}
+	if len(s.footerItems) > 0 {
+		blocks := l.blocks(s.footerItems, width)
+		h := 0.0
+		for _, b := range blocks {
+			h += b.height
+		}
+		y := s.height - s.footer - h
+		for _, b := range blocks {
+			l.emit(b, s.left, y)
+			y += b.height
+		}
+	}
+	l.y = s.top
+}
+
+// blocks lays out paragraphs and tables in width
+func (l *layouter) blocks(items []interface{}, width float64) []*renderBlock {
+	var blocks []*renderBlock
+	for _, it := range items {
+		switch o := it.(type) {
+		case *Paragraph:
+			blocks = append(blocks, l.paragraph(o, width)...)
+		case *Table:
+			blocks = append(blocks, l.table(o, width)...)
+		}
+	}
+	return blocks
+}
+
+// emit puts the items of b at x, y of the current page
+func (l *layouter) emit(b *renderBlock, x, y float64) {
+	for _, it := range b.items {
+		translate(it, x, y)
+		switch o := it.(type) {
+		case *renderLink:
+			l.page.links = append(l.page.links, o)
+			continue
+		case *renderText:
+			if o.field == "PAGE" {
+				o.setText(strconv.Itoa(len(l.pages)))
+			}
+		}
+		l.page.items = append(l.page.items, it)
+	}
+}
+
+// place puts the blocks one by one at x, breaking pages on need
+func (l *layouter) place(blocks []*renderBlock, x float64) {
+	for _, b := range blocks {
+		top := l.y <= l.sect.top
+		if b.spacer && top {
+			continue
+		}
+		if !top && l.y+b.height > l.sect.height-l.sect.bottom {
+			l.newPage()
+			if b.spacer {
+				continue
+			}
+		}
+		l.emit(b, x, l.y)
+		l.y += b.height
+		if b.pageBreak {
+			l.newPage()
+		}
+	}
+}
+
+// stack stacks the blocks into one
+func stack(blocks []*renderBlock) *renderBlock {
+	sb := &renderBlock{}
+	for i, b := range blocks {
+		if b.spacer && i == 0 {
+			continue
+		}
+		for _, it := range b.items {
+			translate(it, 0, sb.height)
+			sb.items = append(sb.items, it)
+		}
+		sb.height += b.height
+	}
+	return sb
+}
+
+// styleProps is the parsed properties of a style
+type styleProps struct {
+	pp *ParagraphProperties
+	rp *RunProperties
+}
+
+// styleChain returns the properties of style id and its bases, the base first
+func (l *layouter) styleChain(id string) []styleProps {
+	var chain []styleProps
+	for i := 0; i < 16 && id != ""; i++ {
+		st := l.styles.Get(id)
+		if st == nil {
+			break
+		}
+		sp, ok := l.props[id]
+		if !ok {
+			sp.pp, sp.rp = styleProperties(st)
+			l.props[id] = sp
+		}
+		chain = append([]styleProps{sp}, chain...)
+		id = st.BasedOn
+	}
+	return chain
+}
+
+// paragraphProperties resolves the properties of p through its style
+func (l *layouter) paragraphProperties(p *Paragraph) (pp ParagraphProperties, mark RunProperties, style string) {
+	pp = l.defPP
+	mark = l.defRP
+	if p.Properties != nil && p.Properties.Style != nil {
+		style = p.Properties.Style.Val
+	} else if def := l.styles.Default("paragraph"); def != nil {
+		style = def.StyleID
+	}
+	for _, sp := range l.styleChain(style) {
+		mergeParagraphProperties(&pp, sp.pp)
+		mergeRunProperties(&mark, sp.rp)
+	}
+	if p.Properties != nil {
+		mergeParagraphProperties(&pp, p.Properties)
+		mergeRunProperties(&mark, p.Properties.RunProperties)
+	}
+	return
+}
+
+// runProperties resolves rp of a run in a paragraph of style
+func (l *layouter) runProperties(style string, rp *RunProperties) *RunProperties {
+	r := l.defRP
+	for _, sp := range l.styleChain(style) {
+		mergeRunProperties(&r, sp.rp)
+	}
+	if rp != nil && rp.RunStyle != nil {
+		for _, sp := range l.styleChain(rp.RunStyle.Val) {
+			mergeRunProperties(&r, sp.rp)
+		}
+	}
+	mergeRunProperties(&r, rp)
+	return &r
+}
+
+// renderPiece is an unbreakable piece of a line
+type renderPiece struct {
+	text  *renderText  // text has its glyphs at x 0 and the baseline 0
+	image *renderImage // image is inline and has its bottom at the baseline
+
+	width, ascent, descent, height float64
+
+	space, tab, br, pageBreak bool
+	breakBefore               bool // breakBefore allows a line break before the piece
+
+	underline, strike bool
+	bg                color.RGBA // bg is not painted if its A is 0
+	link              string
+	rise              float64 // rise is the baseline shift of super and subscripts
+}
+
+// runFormat is the resolved formatting of a run
+type runFormat struct {
+	size, rise        float64
+	bold, italic      bool
+	color, bg         color.RGBA
+	underline, strike bool
+	latin, eastAsia   *renderFont
+	def               *renderFont // def is the Go font of the style
+	latinExact        bool
+	eastAsiaExact     bool
+	link              string
+}
+
+func (l *layouter) runFormat(rp *RunProperties, link string) *runFormat {
+	rf := &runFormat{size: 10.5, color: color.RGBA{A: 255}, link: link}
+	if rp.Size != nil {
+		if v, err := strconv.Atoi(rp.Size.Val); err == nil && v > 0 {
+			rf.size = float64(v) / 2
+		}
+	}
+	rf.bold = rp.Bold != nil && toggleOn(rp.Bold.Val)
+	rf.italic = rp.Italic != nil && toggleOn(rp.Italic.Val)
+	rf.def = l.fonts.goFonts[variant(rf.bold, rf.italic)]
+	if rp.Color != nil {
+		rf.color = hexColor(rp.Color.Val)
+	}
+	if rp.Highlight != nil {
+		if c, ok := highlightColors[rp.Highlight.Val]; ok {
+			rf.bg = hexColor(c)
+		}
+	} else if rp.Shade != nil && rp.Shade.Fill != "" && rp.Shade.Fill != "auto" {
+		rf.bg = hexColor(rp.Shade.Fill)
+	}
+	rf.underline = rp.Underline != nil && rp.Underline.Val != "none"
+	rf.strike = rp.Strike != nil && toggleOn(rp.Strike.Val)
+	if rp.VertAlign != nil {
+		switch rp.VertAlign.Val {
+		case "superscript":
+			rf.rise = -rf.size * 0.35
+			rf.size *= 0.65
+		case "subscript":
+			rf.rise = rf.size * 0.15
+			rf.size *= 0.65
+		}
+	}
+	if rp.Fonts != nil {
+		latin := rp.Fonts.ASCII
+		if latin == "" {
+			latin = rp.Fonts.HAnsi
+		}
+		var err error
+		rf.latin, rf.latinExact, err = l.fonts.lookup(latin, rf.bold, rf.italic)
+		if err == nil {
+			rf.eastAsia, rf.eastAsiaExact, err = l.fonts.lookup(rp.Fonts.EastAsia, rf.bold, rf.italic)
+		}
+		if err != nil && l.err == nil {
+			l.err = err
+		}
+	}
+	return rf
+}
+
+// isCJK checks whether r is a CJK character that lines can break around
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF
+}
+
+// noBreakBefore are the closing punctuations that cannot start a line
+const noBreakBefore = ",.;:!?)]}，。、；：？！）」』】》〉”’…—"
+
+// noBreakAfter are the opening punctuations that cannot end a line
+const noBreakAfter = "([{（「『【《〈“‘"
+
+// canBreak checks whether a line can break between prev and r
+func canBreak(prev, r rune) bool {
+	if prev == 0 || strings.ContainsRune(noBreakBefore, r) || strings.ContainsRune(noBreakAfter, prev) {
+		return false
+	}
+	return prev == ' ' || prev == '　' || isCJK(prev) || isCJK(r)
+}
+
+// pieceBuilder converts the contents of a paragraph into pieces
+type pieceBuilder struct {
+	l      *layouter
+	pieces []*renderPiece
+	prev   rune
+}
+
+// text adds s in format rf
+func (b *pieceBuilder) text(s string, rf *runFormat, field string) {
+	var cur *renderPiece
+	for _, r := range s {
+		switch r {
+		case '\t':
+			b.pieces = append(b.pieces, &renderPiece{tab: true, breakBefore: true})
+			cur, b.prev = nil, ' '
+			continue
+		case '\n':
+			b.pieces = append(b.pieces, &renderPiece{br: true})
+			cur, b.prev = nil, ' '
+			continue
+		}
+		primary, exact := rf.latin, rf.latinExact
+		if isCJK(r) {
+			primary, exact = rf.eastAsia, rf.eastAsiaExact
+		}
+		font, fake := b.l.fonts.forRune(primary, exact, r, rf.bold, rf.italic)
+		space := r == ' ' || r == '　'
+		if cur == nil || cur.text.font != font || cur.text.fakeBold != fake || space || cur.space ||
+			isCJK(r) || isCJK(b.prev) || field != "" && cur.text.field != field {
+			cur = &renderPiece{
+				text: &renderText{
+					font: font, size: rf.size, color: rf.color, fakeBold: fake, field: field,
+				},
+				ascent:      font.ascent * rf.size,
+				descent:     font.descent * rf.size,
+				height:      font.height * rf.size,
+				space:       space,
+				breakBefore: !space && canBreak(b.prev, r),
+				underline:   rf.underline,
+				strike:      rf.strike,
+				bg:          rf.bg,
+				link:        rf.link,
+				rise:        rf.rise,
+			}
+			b.pieces = append(b.pieces, cur)
+		}
+		g := font.glyph(r)
+		font.used[g] = r
+		cur.text.glyphs = append(cur.text.glyphs, g)
+		w := font.advance(g) * rf.size
+		cur.text.width += w
+		cur.width += w
+		b.prev = r
+	}
+}
+
+// run adds the children of r
+func (b *pieceBuilder) run(r *Run, rf *runFormat) {
+	if len(r.Children) == 0 && r.InstrText != "" {
+		switch strings.ToUpper(strings.Fields(r.InstrText + " _")[0]) {
+		case "PAGE":
+			b.text("0", rf, "PAGE")
+		case "NUMPAGES":
+			b.text("0", rf, "NUMPAGES")
+		}
+		return
+	}
+	for _, c := range r.Children {
+		switch o := c.(type) {
+		case *Text:
+			b.text(o.Text, rf, "")
+		case *Tab:
+			b.text("\t", rf, "")
+		case *BarterRabbet:
+			switch o.Type {
+			case "page":
+				b.pieces = append(b.pieces, &renderPiece{br: true, pageBreak: true})
+			default:
+				b.pieces = append(b.pieces, &renderPiece{br: true})
+			}
+			b.prev = ' '
+		case *Drawing:
+			b.drawing(o)
+		}
+	}
+}
+
+// media finds the image media of pic
+func (l *layouter) media(pic *Picture) *Media {
+	if pic.BlipFill == nil || pic.BlipFill.Blip.Embed == "" {
+		return nil
+	}
+	target, err := l.f.ReferTarget(pic.BlipFill.Blip.Embed)
+	if err != nil {
+		return nil
+	}
+	return l.f.Media(path.Base(target))
+}
+
+// drawing adds the inline pictures of d, or the anchored ones into anchors
+func (b *pieceBuilder) drawing(d *Drawing) {
+	for _, pic := range FindIn[*Picture](d) {
+		m := b.l.media(pic)
+		if m == nil {
+			continue
+		}
+		switch {
+		case d.Inline != nil && d.Inline.Extent != nil:
+			w, h := emuPt(d.Inline.Extent.CX), emuPt(d.Inline.Extent.CY)
+			b.pieces = append(b.pieces, &renderPiece{
+				image:       &renderImage{w: w, h: h, media: m},
+				width:       w,
+				ascent:      h,
+				height:      h,
+				breakBefore: b.prev != 0,
+			})
+			b.prev = 'I' // breaks are allowed around images
+		case d.Anchor != nil && d.Anchor.Extent != nil:
+			a := d.Anchor
+			img := &renderImage{w: emuPt(a.Extent.CX), h: emuPt(a.Extent.CY), media: m}
+			s := b.l.sect
+			if a.PositionH != nil {
+				img.x = emuPt(a.PositionH.PosOffset)
+				switch a.PositionH.RelativeFrom {
+				case "page":
+					img.absX = true
+				case "margin", "column", "leftMargin", "insideMargin":
+					img.x += s.left
+					img.absX = true
+				}
+			}
+			if a.PositionV != nil {
+				img.y = emuPt(a.PositionV.PosOffset)
+				switch a.PositionV.RelativeFrom {
+				case "page":
+					img.absY = true
+				case "margin", "topMargin", "insideMargin":
+					img.y += s.top
+					img.absY = true
+				}
+			}
+			b.pieces = append(b.pieces, &renderPiece{image: img, breakBefore: false, height: -1})
+		}
+	}
+}
+
+// anchored checks whether pc is an anchored image taking no space in lines
+func (pc *renderPiece) anchored() bool {
+	return pc.image != nil && pc.height < 0
+}
+
+// formatNumber formats n of a list level in numFmt
+func formatNumber(n int, numFmt string) string {
+	switch numFmt {
+	case "lowerLetter", "upperLetter":
+		if n < 1 {
+			break
+		}
+		s := strings.Repeat(string(rune('a'+(n-1)%26)), (n-1)/26+1)
+		if numFmt == "upperLetter" {
+			s = strings.ToUpper(s)
+		}
+		return s
+	case "lowerRoman", "upperRoman":
+		if n < 1 || n >= 4000 {
+			break
+		}
+		sb := strings.Builder{}
+		for _, v := range [...]struct {
+			n int
+			s string
+		}{
+			{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+			{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+		} {
+			for ; n >= v.n; n -= v.n {
+				sb.WriteString(v.s)
+			}
+		}
+		if numFmt == "upperRoman" {
+			return strings.ToUpper(sb.String())
+		}
+		return sb.String()
+	}
+	return strconv.Itoa(n)
+}
+
+// marker counts the list item of np and returns its text and indents in twips
+func (l *layouter) marker(np *NumProperties) (text string, left, hanging int, ok bool) {
+	if np.NumID == nil || np.NumID.Val == "" || np.NumID.Val == "0" {
+		return
+	}
+	num := l.numbering.Num(np.NumID.Val)
+	if num == nil {
+		return
+	}
+	a := l.numbering.AbstractNum(num.AbstractNumID)
+	if a == nil {
+		return
+	}
+	ilvl := 0
+	if np.Ilvl != nil {
+		ilvl, _ = strconv.Atoi(np.Ilvl.Val)
+	}
+	if ilvl < 0 || ilvl > 8 {
+		ilvl = 0
+	}
+	c, found := l.nums[num.NumID]
+	if !found {
+		c = make([]int, 9)
+		l.nums[num.NumID] = c
+	}
+	startOf := func(i int) int {
+		if s := num.startOverride(i); s > 0 {
+			return s
+		}
+		return a.start(i)
+	}
+	if c[ilvl] == 0 {
+		c[ilvl] = startOf(ilvl)
+	} else {
+		c[ilvl]++
+	}
+	for i := ilvl + 1; i < len(c); i++ {
+		c[i] = 0
+	}
+	left, hanging = a.lvlInd(ilvl)
+	text, ok = a.lvlText(ilvl)
+	if !ok {
+		text = "%" + strconv.Itoa(ilvl+1) + "."
+	}
+	if a.NumFmt(ilvl) == "bullet" {
+		return bulletText(text), left, hanging, true
+	}
+	for i := 8; i >= 0; i-- {
+		k := "%" + strconv.Itoa(i+1)
+		if !strings.Contains(text, k) {
+			continue
+		}
+		n := c[i]
+		if n == 0 {
+			n = startOf(i)
+		}
+		text = strings.ReplaceAll(text, k, formatNumber(n, a.NumFmt(i)))
+	}
+	return text, left, hanging, true
+}
+
+// bulletText maps the private use bullets of symbol fonts in lvlText into unicode
+func bulletText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0xF0A7:
+			return '▪'
+		case r >= 0xF000 && r <= 0xF0FF:
+			return '•'
+		}
+		return r
+	}, s)
+}
+
+// isDigits checks whether s is a non-empty number
+func isDigits(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// paragraph lays out p in width into blocks of its lines and spacings
+func (l *layouter) paragraph(p *Paragraph, width float64) []*renderBlock {
+	pp, markRP, style := l.paragraphProperties(p)
+	mark := l.runFormat(&markRP, "")
+	b := &pieceBuilder{l: l}
+	direct := p.Properties != nil && p.Properties.Ind != nil
+	var left, first float64
+	numbered := false
+	if pp.NumProperties != nil && l.numbering != nil {
+		if text, lf, hg, ok := l.marker(pp.NumProperties); ok {
+			numbered = true
+			left, first = twipsPt(lf), -twipsPt(hg)
+			mrp := markRP
+			mrp.Underline = nil
+			b.text(text, l.runFormat(&mrp, ""), "")
+			b.pieces = append(b.pieces, &renderPiece{tab: true})
+			b.prev = ' '
+		}
+	}
+	if ind := pp.Ind; ind != nil && (!numbered || direct) {
+		left = twipsPt(ind.Left)
+		if ind.Left == 0 && ind.LeftChars != 0 {
+			left = float64(ind.LeftChars) / 100 * mark.size
+		}
+		switch {
+		case ind.FirstLineChars != 0:
+			first = float64(ind.FirstLineChars) / 100 * mark.size
+		case ind.FirstLine != 0:
+			first = twipsPt(ind.FirstLine)
+		case ind.HangingChars != 0:
+			first = -float64(ind.HangingChars) / 100 * mark.size
+		case ind.Hanging != 0:
+			first = -twipsPt(ind.Hanging)
+		}
+	}
+	skipDigits := false
+	for _, c := range p.Children {
+		switch o := c.(type) {
+		case *Run:
+			if len(o.Children) == 0 && o.InstrText != "" {
+				skipDigits = true
+			} else if skipDigits {
+				if isDigits(PlainText(o)) {
+					continue
+				}
+				skipDigits = false
+			}
+			b.run(o, l.runFormat(l.runProperties(style, o.RunProperties), ""))
+		case *Hyperlink:
+			uri, err := l.f.ReferTarget(o.ID)
+			if err != nil {
+				uri = ""
+			}
+			b.text(PlainText(o), l.runFormat(l.runProperties(style, o.Run.RunProperties), uri), "")
+		}
+	}
+
+	var before, after float64
+	lineRule, line := "auto", 240
+	if sp := pp.Spacing; sp != nil {
+		before, after = twipsPt(sp.Before), twipsPt(sp.After)
+		if sp.Before == 0 && sp.BeforeLines != 0 {
+			before = float64(sp.BeforeLines) / 100 * mark.size * 1.2
+		}
+		if sp.After == 0 && sp.AfterLines != 0 {
+			after = float64(sp.AfterLines) / 100 * mark.size * 1.2
+		}
+		if sp.Line > 0 {
+			line = sp.Line
+			if sp.LineRule != "" {
+				lineRule = sp.LineRule
+			}
+		}
+	}
+	jc := ""
+	if pp.Justification != nil {
+		jc = pp.Justification.Val
+	}
+	var bg color.RGBA
+	if pp.Shade != nil && pp.Shade.Fill != "" && pp.Shade.Fill != "auto" {
+		bg = hexColor(pp.Shade.Fill)
+	}
+
+	var blocks []*renderBlock
+	if before > 0 {
+		blocks = append(blocks, &renderBlock{height: before, spacer: true})
+	}
+	lines, anchors := fillLines(b.pieces, width, left, first)
+	for i, ln := range lines {
+		start := left
+		if i == 0 {
+			start += first
+		}
+		blk := layoutLine(ln, start, width, jc, i == len(lines)-1, mark, lineRule, line)
+		if bg.A != 0 {
+			blk.items = append([]interface{}{&renderRect{x: left, y: 0, w: width - left, h: blk.height, fill: bg}}, blk.items...)
+		}
+		if i == 0 {
+			for _, a := range anchors {
+				blk.items = append(blk.items, a.image)
+			}
+		}
+		blocks = append(blocks, blk)
+	}
+	if after > 0 {
+		blocks = append(blocks, &renderBlock{height: after, spacer: true})
+	}
+	return blocks
+}
+
+// fillLines breaks the pieces into lines greedily, and returns the anchored images apart
+func fillLines(pieces []*renderPiece, width, left, first float64) (lines [][]*renderPiece, anchors []*renderPiece) {
+	q := make([]*renderPiece, 0, len(pieces))
+	for _, pc := range pieces {
+		if pc.anchored() {
+			anchors = append(anchors, pc)
+			continue
+		}
+		q = append(q, pc)
+	}
+	var ln []*renderPiece
+	x := 0.0
+	start := left + first
+	flush := func() {
+		lines = append(lines, ln)
+		ln = nil
+		x = 0
+		start = left
+	}
+	for len(q) > 0 {
+		pc := q[0]
+		lim := width - start
+		switch {
+		case pc.br:
+			ln = append(ln, pc)
+			flush()
+			q = q[1:]
+			continue
+		case pc.tab:
+			pc.width = nextTabStop(start+x, left) - start - x
+			if x+pc.width > lim && len(ln) > 0 {
+				flush()
+				continue
+			}
+			fallthrough
+		case pc.space, x+pc.width <= lim:
+			ln = append(ln, pc)
+			x += pc.width
+			q = q[1:]
+			continue
+		}
+		// pc overflows: break at the last opportunity in the line
+		k := 0
+		for j := len(ln); j > 0; j-- {
+			if j == len(ln) && pc.breakBefore || j < len(ln) && ln[j].breakBefore || ln[j-1].space || ln[j-1].tab {
+				k = j
+				break
+			}
+		}
+		if k > 0 {
+			rest := ln[k:]
+			ln = ln[:k]
+			flush()
+			q = append(append(make([]*renderPiece, 0, len(rest)+len(q)), rest...), q...)
+			continue
+		}
+		// no opportunity: split the word by glyphs
+		a, r := splitPiece(pc, lim-x)
+		if a == nil && len(ln) == 0 {
+			a, r = splitPiece(pc, 0)
+		}
+		if a != nil {
+			ln = append(ln, a)
+			q[0] = r
+			if r == nil {
+				q = q[1:]
+			}
+		}
+		flush()
+	}
+	if len(ln) > 0 || len(lines) == 0 || len(lines[len(lines)-1]) > 0 && lines[len(lines)-1][len(lines[len(lines)-1])-1].br {
+		lines = append(lines, ln)
+	}
+	return
+}
+
+// nextTabStop returns the default tab stop after x, the left indent being a stop
+func nextTabStop(x, left float64) float64 {
+	if left > x+0.01 {
+		return left
+	}
+	return (math.Floor(x/36+0.01) + 1) * 36
+}
+
+// splitPiece splits a text piece into the head fitting in w and the rest.
+// At least one glyph is kept in the head if w is 0, and a is nil if none fits.
+func splitPiece(pc *renderPiece, w float64) (a, b *renderPiece) {
+	if pc.text == nil || len(pc.text.glyphs) < 2 {
+		if w <= 0 {
+			return pc, nil
+		}
+		return nil, pc
+	}
+	t := pc.text
+	n, acc := 0, 0.0
+	for n < len(t.glyphs) {
+		adv := t.font.advance(t.glyphs[n]) * t.size
+		if acc+adv > w && (n > 0 || w > 0) {
+			break
+		}
+		acc += adv
+		n++
+	}
+	if n == 0 {
+		return nil, pc
+	}
+	if n == len(t.glyphs) {
+		return pc, nil
+	}
+	ha, ta := *pc, *t
+	hb, tb := *pc, *t
+	ta.glyphs, ta.width = t.glyphs[:n:n], acc
+	tb.glyphs, tb.width = t.glyphs[n:], t.width-acc
+	ha.text, ha.width = &ta, acc
+	hb.text, hb.width, hb.breakBefore = &tb, t.width-acc, true
+	return &ha, &hb
+}
+
+// layoutLine places the pieces of a line starting at x of start in width
+func layoutLine(ln []*renderPiece, start, width float64, jc string, last bool, mark *runFormat, lineRule string, line int) *renderBlock {
+	ascent, descent, height := 0.0, 0.0, 0.0
+	if len(ln) == 0 || len(ln) == 1 && ln[0].br {
+		font := mark.latin
+		if font == nil {
+			font = mark.def
+		}
+		ascent, descent, height = font.ascent*mark.size, font.descent*mark.size, font.height*mark.size
+	}
+	for _, pc := range ln {
+		if pc.text == nil && pc.image == nil {
+			continue
+		}
+		ascent = math.Max(ascent, pc.ascent-pc.rise)
+		descent = math.Max(descent, pc.descent+pc.rise)
+		height = math.Max(height, pc.height)
+	}
+	if height < ascent+descent {
+		height = ascent + descent
+	}
+	lh := height
+	switch lineRule {
+	case "exact":
+		lh = twipsPt(line)
+	case "atLeast":
+		lh = math.Max(height, twipsPt(line))
+	default:
+		lh = height * float64(line) / 240
+	}
+	baseline := (lh-height)/2 + ascent + (height-ascent-descent)/2
+	if lineRule == "exact" {
+		baseline = lh - descent - (lh-ascent-descent)/2
+	}
+
+	// the trailing spaces hang out of the line
+	end := len(ln)
+	for end > 0 && (ln[end-1].space || ln[end-1].br) {
+		end--
+	}
+	used, spaces := 0.0, 0
+	for i, pc := range ln[:end] {
+		used += pc.width
+		if pc.space {
+			spaces++
+		}
+		if pc.tab {
+			spaces, used = 0, 0
+			for _, x := range ln[:i+1] {
+				used += x.width
+			}
+		}
+	}
+	avail := width - start
+	off, extra, gaps := 0.0, 0.0, 0.0
+	switch jc {
+	case "center":
+		off = (avail - used) / 2
+	case "right", "end":
+		off = avail - used
+	case "both", "distribute":
+		if last && jc == "both" || used >= avail {
+			break
+		}
+		if spaces > 0 {
+			extra = (avail - used) / float64(spaces)
+		} else if end > 1 {
+			gaps = (avail - used) / float64(end-1)
+		}
+	}
+	if off < 0 {
+		off = 0
+	}
+
+	blk := &renderBlock{height: lh}
+	var fore []interface{}
+	x := start + off
+	tabbed := false
+	for i, pc := range ln {
+		if pc.tab {
+			tabbed = true
+		}
+		if pc.pageBreak {
+			blk.pageBreak = true
+		}
+		w := pc.width
+		if pc.space && i < end && !tabbed {
+			w += extra
+		}
+		switch {
+		case pc.text != nil:
+			t := *pc.text
+			t.x, t.y = x, baseline+pc.rise
+			if pc.bg.A != 0 {
+				blk.items = append(blk.items, &renderRect{x: x, y: t.y - pc.ascent, w: w, h: pc.ascent + pc.descent, fill: pc.bg})
+			}
+			fore = append(fore, &t)
+			th := t.size / 18
+			if pc.underline && i < end {
+				fore = append(fore, &renderLine{x1: x, y1: t.y + t.size*0.12, x2: x + w, y2: t.y + t.size*0.12, width: th, color: t.color})
+			}
+			if pc.strike {
+				fore = append(fore, &renderLine{x1: x, y1: t.y - t.size*0.28, x2: x + w, y2: t.y - t.size*0.28, width: th, color: t.color})
+			}
+		case pc.image != nil:
+			img := *pc.image
+			img.x, img.y = x, baseline-img.h
+			fore = append(fore, &img)
+		}
+		if pc.link != "" && w > 0 {
+			fore = append(fore, &renderLink{x: x, y: baseline - pc.ascent, w: w, h: pc.ascent + pc.descent, uri: pc.link})
+		}
+		x += w
+		if !pc.space && !pc.tab && i < end-1 {
+			x += gaps
+		}
+	}
+	blk.items = append(blk.items, fore...)
+	return blk
+}
+
+// tableCellPadding is the default left and right margin of cells in points
+const tableCellPadding = 5.4
+
+// renderCell is a laid out cell of a table row
+type renderCell struct {
+	c       *WTableCell
+	col     int
+	span    int
+	x, w    float64
+	cont    bool         // cont is a continuation of a vertically merged cell
+	rows    int          // rows is the number of rows merged from this cell
+	content *renderBlock // content is nil on cont
+}
+
+// borderLine converts a border into a line of the width from x1, y1 to x2, y2, or nil if it is none
+func borderLine(b *WTableBorder, x1, y1, x2, y2 float64) *renderLine {
+	if b == nil || b.Val == "" || b.Val == "nil" || b.Val == "none" {
+		return nil
+	}
+	w := float64(b.Size) / 8
+	if w < 0.25 {
+		w = 0.5
+	}
+	c := color.RGBA{A: 255}
+	if b.Color != "" && b.Color != "auto" {
+		c = hexColor(b.Color)
+	}
+	return &renderLine{
+		x1: x1, y1: y1, x2: x2, y2: y2, width: w, color: c,
+		dash: strings.Contains(b.Val, "dash") || strings.Contains(b.Val, "dot"),
+	}
+}
+
+// table lays out t in width into blocks of its rows
+func (l *layouter) table(t *Table, width float64) []*renderBlock {
+	cols := 0
+	for _, r := range t.TableRows {
+		n := 0
+		for _, c := range r.TableCells {
+			n += gridSpan(c)
+		}
+		if n > cols {
+			cols = n
+		}
+	}
+	if cols == 0 {
+		return nil
+	}
+	tw := width
+	var borders *WTableBorders
+	jc := ""
+	if tp := t.TableProperties; tp != nil {
+		borders = tp.TableBorders
+		if tp.Width != nil && tp.Width.W > 0 {
+			switch tp.Width.Type {
+			case "pct":
+				tw = width * float64(tp.Width.W) / 5000
+			case "", "dxa":
+				tw = twipsPt(int(tp.Width.W))
+			}
+		}
+		if tp.Justification != nil {
+			jc = tp.Justification.Val
+		}
+	}
+	ws := make([]float64, cols)
+	if t.TableGrid != nil && len(t.TableGrid.GridCols) >= cols {
+		for i := range ws {
+			ws[i] = twipsPt(int(t.TableGrid.GridCols[i].W))
+		}
+	} else if len(t.TableRows) > 0 {
+		col := 0
+		for _, c := range t.TableRows[0].TableCells {
+			span := gridSpan(c)
+			if tcp := c.TableCellProperties; tcp != nil && tcp.TableCellWidth != nil &&
+				tcp.TableCellWidth.W > 0 && tcp.TableCellWidth.Type == "dxa" && col+span <= cols {
+				for i := col; i < col+span; i++ {
+					ws[i] = twipsPt(int(tcp.TableCellWidth.W)) / float64(span)
+				}
+			}
+			col += span
+		}
+	}
+	total, unset := 0.0, 0
+	for _, w := range ws {
+		total += w
+		if w <= 0 {
+			unset++
+		}
+	}
+	if unset > 0 {
+		rest := (tw - total) / float64(unset)
+		if rest < tw/float64(cols)/4 {
+			rest = tw / float64(cols) / 4
+		}
+		for i := range ws {
+			if ws[i] <= 0 {
+				ws[i] = rest
+				total += rest
+			}
+		}
+	}
+	if total > width {
+		for i := range ws {
+			ws[i] *= width / total
+		}
+		total = width
+	}
+	xs := make([]float64, cols+1)
+	switch jc {
+	case "center":
+		xs[0] = (width - total) / 2
+	case "right", "end":
+		xs[0] = width - total
+	}
+	for i, w := range ws {
+		xs[i+1] = xs[i] + w
+	}
+
+	// lay out the cells and find the heights of rows
+	grid := make([][]*renderCell, len(t.TableRows))
+	heights := make([]float64, len(t.TableRows))
+	exact := make([]bool, len(t.TableRows))
+	for i, r := range t.TableRows {
+		if rp := r.TableRowProperties; rp != nil && rp.TableRowHeight != nil {
+			heights[i] = twipsPt(int(rp.TableRowHeight.Val))
+			exact[i] = rp.TableRowHeight.Rule == "exact"
+		}
+		col := 0
+		for _, c := range r.TableCells {
+			span := gridSpan(c)
+			if col+span > cols {
+				span = cols - col
+			}
+			if span <= 0 {
+				break
+			}
+			rc := &renderCell{c: c, col: col, span: span, x: xs[col], w: xs[col+span] - xs[col], rows: 1}
+			merged, restart := vMerge(c)
+			if merged && !restart && i > 0 {
+				rc.cont = true
+			} else {
+				if restart {
+					for _, nr := range t.TableRows[i+1:] {
+						nc := cellAt(nr, col)
+						if nc == nil {
+							break
+						}
+						if m, rs := vMerge(nc); !m || rs {
+							break
+						}
+						rc.rows++
+					}
+				}
+				items := make([]interface{}, 0, len(c.Paragraphs)+len(c.Tables))
+				for _, p := range c.Paragraphs {
+					items = append(items, p)
+				}
+				for _, nt := range c.Tables {
+					items = append(items, nt)
+				}
+				if len(items) == 0 {
+					items = append(items, &Paragraph{})
+				}
+				rc.content = stack(l.blocks(items, rc.w-2*tableCellPadding))
+				if rc.rows == 1 && !exact[i] && rc.content.height > heights[i] {
+					heights[i] = rc.content.height
+				}
+			}
+			grid[i] = append(grid[i], rc)
+			col += span
+		}
+	}
+	// grow the last row of the merged cells to hold their contents
+	for i, row := range grid {
+		for _, rc := range row {
+			if rc.cont || rc.rows < 2 {
+				continue
+			}
+			end := i + rc.rows - 1
+			h := 0.0
+			for k := i; k <= end; k++ {
+				h += heights[k]
+			}
+			if d := rc.content.height - h; d > 0 && !exact[end] {
+				heights[end] += d
+			}
+		}
+	}
+
+	blocks := make([]*renderBlock, len(grid))
+	for i, row := range grid {
+		blk := &renderBlock{height: heights[i]}
+		var lines []interface{}
+		for _, rc := range row {
+			h := 0.0
+			for k := i; k < i+rc.rows && k < len(heights); k++ {
+				h += heights[k]
+			}
+			var cb *WTableBorders
+			tcp := rc.c.TableCellProperties
+			if tcp != nil {
+				cb = tcp.TableBorders
+			}
+			if !rc.cont {
+				if tcp != nil && tcp.Shade != nil && tcp.Shade.Fill != "" && tcp.Shade.Fill != "auto" {
+					blk.items = append(blk.items, &renderRect{x: rc.x, y: 0, w: rc.w, h: h, fill: hexColor(tcp.Shade.Fill)})
+				}
+				dy := 0.0
+				if tcp != nil && tcp.VAlign != nil {
+					switch tcp.VAlign.Val {
+					case "center":
+						dy = (h - rc.content.height) / 2
+					case "bottom":
+						dy = h - rc.content.height
+					}
+				}
+				if dy < 0 {
+					dy = 0
+				}
+				for _, it := range rc.content.items {
+					translate(it, rc.x+tableCellPadding, dy)
+					blk.items = append(blk.items, it)
+				}
+			}
+			merging := false // merging is set if the next row continues the cell
+			if i+1 < len(grid) {
+				if nc := cellAt(t.TableRows[i+1], rc.col); nc != nil {
+					m, rs := vMerge(nc)
+					merging = m && !rs
+				}
+			}
+			sides := [...]struct {
+				name, inside string
+				outer        bool
+				x1, y1       float64
+				x2, y2       float64
+			}{
+				{"top", "insideH", i == 0, rc.x, 0, rc.x + rc.w, 0},
+				{"bottom", "insideH", i == len(grid)-1, rc.x, heights[i], rc.x + rc.w, heights[i]},
+				{"left", "insideV", rc.col == 0, rc.x, 0, rc.x, heights[i]},
+				{"right", "insideV", rc.col+rc.span >= cols, rc.x + rc.w, 0, rc.x + rc.w, heights[i]},
+			}
+			for k, s := range sides {
+				if k == 0 && rc.cont || k == 1 && merging {
+					continue
+				}
+				var b *WTableBorder
+				if cb != nil {
+					b = cb.side(s.name)
+				}
+				if b == nil && borders != nil {
+					if s.outer {
+						b = borders.side(s.name)
+					} else {
+						b = borders.side(s.inside)
+					}
+				}
+				if ln := borderLine(b, s.x1, s.y1, s.x2, s.y2); ln != nil {
+					lines = append(lines, ln)
+				}
+			}
+		}
+		blk.items = append(blk.items, lines...)
+		blocks[i] = blk
+	}
+	return blocks
+}
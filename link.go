@@ -64,6 +64,21 @@ func (f *Docx) addImageRelation(m Media) string {
 	return rel.ID
 }
 
+// addRelation adds an internal relationship of typ to target
+//
+//	this func is not thread-safe
+func (f *Docx) addRelation(typ, target string) string {
+	rel := Relationship{
+		ID:     "rId" + strconv.Itoa(int(atomic.AddUintptr(&f.rID, 1))),
+		Type:   typ,
+		Target: target,
+	}
+
+	f.docRelation.Relationship = append(f.docRelation.Relationship, rel)
+
+	return rel.ID
+}
+
 // ReferTarget gets the target for a reference
 func (f *Docx) ReferTarget(id string) (string, error) {
 	for _, a := range f.docRelation.Relationship {
@@ -0,0 +1,392 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownOptions controls WriteMarkdownTo
+type MarkdownOptions struct {
+	// MediaDir is the folder of images in the links, media by default
+	MediaDir string
+	// SaveMedia is called once on each image referred by the document,
+	// name is the file name in MediaDir. Images are not saved if it is nil.
+	SaveMedia func(name string, data []byte) error
+}
+
+// SaveMediaTo returns a SaveMedia of MarkdownOptions or HTMLOptions which writes files into dir
+func SaveMediaTo(dir string) func(name string, data []byte) error {
+	return func(name string, data []byte) error {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+}
+
+// WriteMarkdownTo writes the body of f to w as GitHub flavored markdown.
+//
+// Headings, bold, italic and strike runs, lists, hyperlinks, tables
+// and images are converted, and the paragraphs in text boxes are
+// written after the paragraph holding them.
+func (f *Docx) WriteMarkdownTo(w io.Writer, opt *MarkdownOptions) error {
+	if opt == nil {
+		opt = &MarkdownOptions{}
+	}
+	m := mdWriter{
+		f:     f,
+		opt:   opt,
+		w:     bufio.NewWriter(w),
+		nums:  make(map[string][]int, 8),
+		saved: make(map[string]struct{}, 8),
+	}
+	for _, it := range f.Document.Body.Items {
+		switch o := it.(type) {
+		case *Paragraph:
+			m.paragraph(o)
+		case *Table:
+			m.table(o)
+		}
+		if m.err != nil {
+			return m.err
+		}
+	}
+	return m.w.Flush()
+}
+
+var mdBlockStartRe = regexp.MustCompile(`^(#{1,6}|[-+]|\d{1,9}[.)])([ \t]|$)`)
+
+// escapeBlockStart escapes the start of text that would begin a heading or a list,
+// which is the delimiter after the digits of an ordered list as digits cannot be escaped
+func escapeBlockStart(text string) string {
+	loc := mdBlockStartRe.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return text
+	}
+	i := 0
+	if text[0] >= '0' && text[0] <= '9' {
+		i = loc[3] - 1
+	}
+	return text[:i] + "\\" + text[i:]
+}
+
+type mdWriter struct {
+	f   *Docx
+	opt *MarkdownOptions
+	w   *bufio.Writer
+	err error
+
+	started  bool
+	prevList bool
+	nums     map[string][]int    // nums is the counters of each level of numId
+	saved    map[string]struct{} // saved media names
+}
+
+// mdPiece is a piece of inline text with its emphasis
+type mdPiece struct {
+	text    string
+	raw     bool // raw is already markdown, like a link
+	b, i, s bool
+}
+
+// block starts a new block, separated by a blank line unless
+// it is an item following another item in a list
+func (m *mdWriter) block(list bool) {
+	if m.started {
+		if list && m.prevList {
+			m.w.WriteByte('\n')
+		} else {
+			m.w.WriteString("\n\n")
+		}
+	}
+	m.started = true
+	m.prevList = list
+}
+
+func (m *mdWriter) paragraph(p *Paragraph) {
+	text, boxes := m.inline(p)
+	if strings.TrimSpace(text) != "" {
+		if lv := m.f.HeadingLevel(p); lv > 0 {
+			m.block(false)
+			m.w.WriteString(strings.Repeat("#", lv))
+			m.w.WriteByte(' ')
+			m.w.WriteString(strings.ReplaceAll(text, "  \n", " "))
+		} else if numID, ilvl, numFmt := m.f.ListLevel(p); numID != "" {
+			m.block(true)
+			indent := strings.Repeat("    ", ilvl)
+			m.w.WriteString(indent)
+			m.w.WriteString(m.marker(numID, ilvl, numFmt))
+			m.w.WriteString(strings.ReplaceAll(text, "  \n", "  \n"+indent+"    "))
+		} else {
+			m.block(false)
+			m.w.WriteString(escapeBlockStart(text))
+		}
+	}
+	for _, b := range boxes {
+		m.paragraph(b)
+	}
+}
+
+// marker returns the list marker of the item and counts it
+func (m *mdWriter) marker(numID string, ilvl int, numFmt string) string {
+	if ilvl < 0 || ilvl > 8 {
+		ilvl = 0
+	}
+	c, ok := m.nums[numID]
+	if !ok {
+		c = make([]int, 9)
+		m.nums[numID] = c
+	}
+	if c[ilvl] == 0 {
+		c[ilvl] = m.f.listStart(numID, ilvl)
+	} else {
+		c[ilvl]++
+	}
+	for i := ilvl + 1; i < len(c); i++ {
+		c[i] = 0
+	}
+	switch numFmt {
+	case "", "bullet", "none":
+		return "- "
+	}
+	return strconv.Itoa(c[ilvl]) + ". "
+}
+
+// inline renders the children of p, and returns the paragraphs of its text boxes
+func (m *mdWriter) inline(p *Paragraph) (string, []*Paragraph) {
+	var pieces []mdPiece
+	var boxes []*Paragraph
+	for _, c := range p.Children {
+		switch o := c.(type) {
+		case *Hyperlink:
+			target, err := m.f.ReferTarget(o.ID)
+			if err != nil {
+				target = "#" + o.ID
+			}
+			pieces = append(pieces, mdPiece{
+				text: "[" + escapeMarkdown(PlainText(o)) + "](" + escapeLink(target) + ")",
+				raw:  true,
+			})
+		case *Run:
+			var b, i, s bool
+			if rp := o.RunProperties; rp != nil {
+				b = rp.Bold != nil && toggleOn(rp.Bold.Val)
+				i = rp.Italic != nil && toggleOn(rp.Italic.Val)
+				s = rp.Strike != nil && toggleOn(rp.Strike.Val)
+			}
+			for _, x := range o.Children {
+				switch y := x.(type) {
+				case *Text:
+					pieces = append(pieces, mdPiece{text: escapeMarkdown(y.Text), b: b, i: i, s: s})
+				case *Tab:
+					pieces = append(pieces, mdPiece{text: " ", b: b, i: i, s: s})
+				case *BarterRabbet:
+					if y.Type == "" || y.Type == "textWrapping" {
+						pieces = append(pieces, mdPiece{text: "  \n", raw: true})
+					}
+				case *Drawing:
+					for _, pic := range FindIn[*Picture](y) {
+						if img := m.image(y, pic); img != "" {
+							pieces = append(pieces, mdPiece{text: img, raw: true})
+						}
+					}
+					for _, sp := range FindIn[*WordprocessingShape](y) {
+						if sp.TextBox != nil && sp.TextBox.Content != nil {
+							for k := range sp.TextBox.Content.Paragraphs {
+								boxes = append(boxes, &sp.TextBox.Content.Paragraphs[k])
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return joinPieces(pieces), boxes
+}
+
+// image returns the markdown of pic and saves its media
+func (m *mdWriter) image(d *Drawing, pic *Picture) string {
+	if pic.BlipFill == nil || pic.BlipFill.Blip.Embed == "" {
+		return ""
+	}
+	target, err := m.f.ReferTarget(pic.BlipFill.Blip.Embed)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(target)
+	alt := ""
+	switch {
+	case d.Inline != nil && d.Inline.DocPr != nil:
+		alt = d.Inline.DocPr.Name
+	case d.Anchor != nil && d.Anchor.DocPr != nil:
+		alt = d.Anchor.DocPr.Name
+	}
+	dir := m.opt.MediaDir
+	if dir == "" {
+		dir = "media"
+	}
+	if _, ok := m.saved[name]; !ok && m.opt.SaveMedia != nil {
+		m.saved[name] = struct{}{}
+		if media := m.f.Media(name); media != nil {
+			data, err := media.Load()
+			if err == nil {
+				err = m.opt.SaveMedia(name, data)
+			}
+			if err != nil && m.err == nil {
+				m.err = err
+			}
+		}
+	}
+	return "![" + escapeMarkdown(alt) + "](" + escapeLink(path.Join(dir, name)) + ")"
+}
+
+// table writes t in grid columns, leaving the cells covered by
+// merged cells empty as markdown has no merging
+func (m *mdWriter) table(t *Table) {
+	cols := 0
+	for _, r := range t.TableRows {
+		n := 0
+		for _, c := range r.TableCells {
+			n += gridSpan(c)
+		}
+		if n > cols {
+			cols = n
+		}
+	}
+	if cols == 0 {
+		return
+	}
+	m.block(false)
+	for i, r := range t.TableRows {
+		if i > 0 {
+			m.w.WriteByte('\n')
+		}
+		m.w.WriteByte('|')
+		j := 0
+		for _, c := range r.TableCells {
+			m.w.WriteByte(' ')
+			if merged, restart := vMerge(c); !merged || restart {
+				m.w.WriteString(m.cell(c))
+			}
+			m.w.WriteString(" |")
+			j++
+			for k := 1; k < gridSpan(c); k++ {
+				m.w.WriteString("  |")
+				j++
+			}
+		}
+		for ; j < cols; j++ {
+			m.w.WriteString("  |")
+		}
+		if i == 0 {
+			m.w.WriteString("\n|")
+			m.w.WriteString(strings.Repeat(" --- |", cols))
+		}
+	}
+}
+
+// cell renders the paragraphs of c in one line
+func (m *mdWriter) cell(c *WTableCell) string {
+	lines := make([]string, 0, len(c.Paragraphs)+len(c.Tables))
+	for _, p := range c.Paragraphs {
+		text, _ := m.inline(p)
+		if strings.TrimSpace(text) != "" {
+			lines = append(lines, text)
+		}
+	}
+	for _, t := range c.Tables {
+		lines = append(lines, escapeMarkdown(strings.NewReplacer("\t", " ", "\n", " ").Replace(PlainText(t))))
+	}
+	s := strings.Join(lines, "<br>")
+	return strings.NewReplacer("  \n", "<br>", "|", `\|`).Replace(s)
+}
+
+// joinPieces merges the pieces with the same emphasis and writes them
+func joinPieces(pieces []mdPiece) string {
+	sb := strings.Builder{}
+	for i := 0; i < len(pieces); {
+		p := pieces[i]
+		if p.raw || !(p.b || p.i || p.s) {
+			sb.WriteString(p.text)
+			i++
+			continue
+		}
+		j := i + 1
+		text := p.text
+		for ; j < len(pieces); j++ {
+			q := pieces[j]
+			if q.raw || q.b != p.b || q.i != p.i || q.s != p.s {
+				break
+			}
+			text += q.text
+		}
+		i = j
+		core := strings.TrimSpace(text)
+		if core == "" {
+			sb.WriteString(text)
+			continue
+		}
+		k := strings.Index(text, core)
+		open := ""
+		if p.b {
+			open += "**"
+		}
+		if p.i {
+			open += "*"
+		}
+		if p.s {
+			open += "~~"
+		}
+		sb.WriteString(text[:k])
+		sb.WriteString(open)
+		sb.WriteString(core)
+		for n := len(open) - 1; n >= 0; n-- {
+			sb.WriteByte(open[n])
+		}
+		sb.WriteString(text[k+len(core):])
+	}
+	return sb.String()
+}
+
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `~`, `\~`,
+)
+
+// escapeMarkdown escapes the chars having meanings in inline markdown
+func escapeMarkdown(s string) string {
+	return mdEscaper.Replace(s)
+}
+
+var linkEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// escapeLink escapes the chars breaking a markdown link target
+func escapeLink(s string) string {
+	return linkEscaper.Replace(s)
+}
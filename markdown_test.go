@@ -0,0 +1,115 @@
+package docx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteMarkdownTo(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.numbering = &Numbering{}
+	err := xml.Unmarshal([]byte(numbering_xml_1), f.numbering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddParagraph().Style("Heading1").AddText("Title")
+	p := f.AddParagraph()
+	p.AddText("plain ")
+	p.AddText("bold ").Bold()
+	p.AddText("both").Bold().Italic()
+	p.AddText(" and ")
+	p.AddText("gone").Strike(true)
+	p.AddText(" a*b ")
+	p.AddLink("link", "https://example.com")
+	f.AddParagraph().NumPr("1", "0").AddText("one")
+	f.AddParagraph().NumPr("1", "0").AddText("two")
+	f.AddParagraph().NumPr("2", "1").AddText("dot")
+	tbl := f.AddTable(2, 2, 0, nil)
+	tbl.TableRows[0].TableCells[0].AddParagraph().AddText("a|b")
+	tbl.TableRows[0].TableCells[1].AddParagraph().AddText("c")
+	tbl.TableRows[1].TableCells[1].AddParagraph().AddText("d")
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := map[string]int{}
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteMarkdownTo(buf, &MarkdownOptions{SaveMedia: func(name string, data []byte) error {
+		saved[name] = len(data)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "# Title\n\n" +
+		"plain **bold** ***both*** and ~~gone~~ a\\*b [link](https://example.com)\n\n" +
+		"3. one\n4. two\n    - dot\n\n" +
+		"| a\\|b | c |\n| --- | --- |\n|  | d |\n\n" +
+		"![图片 1](media/image1.jpeg)"
+	if buf.String() != exp {
+		t.Fatalf("unexpected markdown:\n%s", buf.String())
+	}
+	if saved["image1.jpeg"] == 0 {
+		t.Fatal("media not saved", saved)
+	}
+}
+
+func TestWriteMarkdownToggleAndMerge(t *testing.T) {
+	f := New().WithDefaultTheme()
+	var p Paragraph
+	err := xml.Unmarshal([]byte(`<w:p xmlns:w="`+XMLNS_W+`"><w:r><w:rPr><w:b w:val="0"/><w:i w:val="false"/></w:rPr><w:t>plain</w:t></w:r><w:r><w:rPr><w:b w:val="1"/></w:rPr><w:t>bold</w:t></w:r></w:p>`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.file = f
+	f.Document.Body.Items = append(f.Document.Body.Items, &p)
+	tbl := f.AddTable(3, 3, 0, nil)
+	for i, r := range tbl.TableRows {
+		for j, c := range r.TableCells {
+			if c.TableCellProperties == nil {
+				c.TableCellProperties = &WTableCellProperties{}
+			}
+			c.AddParagraph().AddText(string(rune('a' + i*3 + j)))
+		}
+	}
+	row := tbl.TableRows[0]
+	row.TableCells[0].TableCellProperties.GridSpan = &WGridSpan{Val: 2}
+	row.TableCells = append(row.TableCells[:1], row.TableCells[2])
+	tbl.TableRows[1].TableCells[0].TableCellProperties.VMerge = &WvMerge{Val: "restart"}
+	tbl.TableRows[2].TableCells[0].TableCellProperties.VMerge = &WvMerge{}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteMarkdownTo(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "plain**bold**\n\n| a |  | c |\n| --- | --- | --- |\n| d | e | f |\n|  | h | i |"
+	if buf.String() != exp {
+		t.Fatalf("unexpected markdown %q", buf.String())
+	}
+}
+
+func TestWriteMarkdownStartAndEscape(t *testing.T) {
+	f := New().WithDefaultTheme()
+	numID, err := f.AddListNumbering(true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"three", "four"} {
+		f.AddParagraph().NumPr(numID, "0").AddText(s)
+	}
+	for _, s := range []string{"1. foo", "12) bar", "# baz", "#tag", "- qux", "-dash"} {
+		f.AddParagraph().AddText(s)
+	}
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteMarkdownTo(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "3. three\n4. four\n\n1\\. foo\n\n12\\) bar\n\n\\# baz\n\n#tag\n\n\\- qux\n\n-dash"
+	if buf.String() != exp {
+		t.Fatalf("unexpected markdown %q", buf.String())
+	}
+}
@@ -0,0 +1,1036 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/base64"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownImportOptions controls AddMarkdown
+type MarkdownImportOptions struct {
+	// BaseDir is where the relative paths of the images start.
+	// Local images are read only if BaseDir or ReadFile is set,
+	// and the absolute paths or the ones leaving BaseDir are never read.
+	BaseDir string
+	// ReadFile reads the local images, os.ReadFile by default
+	ReadFile func(name string) ([]byte, error)
+}
+
+// FromMarkdown creates a docx in the default theme from markdown src
+func FromMarkdown(src []byte, opt *MarkdownImportOptions) (*Docx, error) {
+	f := New().WithDefaultTheme()
+	err := f.AddMarkdown(src, opt)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddMarkdown appends the CommonMark blocks in src to the body of f.
+//
+// Headings, paragraphs, emphasis, strikethrough, code spans and blocks,
+// nested lists, GFM tables, links, images, block quotes and horizontal
+// rules are supported. The styles and list numbering needed are added
+// into f. Remote images and the ones failed to be read are turned into links.
+// Nothing is added into f if an error is returned.
+func (f *Docx) AddMarkdown(src []byte, opt *MarkdownImportOptions) error {
+	if opt == nil {
+		opt = &MarkdownImportOptions{}
+	}
+	st := f.saveImport()
+	b := mdBuilder{f: f, opt: opt, styles: make(map[string]string, 8)}
+	err := b.blocks(parseMarkdownBlocks(markdownLines(string(src))), &mdContext{ilvl: -1})
+	if err != nil {
+		f.rollbackImport(st)
+	}
+	return err
+}
+
+// importState is the sizes of what an import appends to in f
+type importState struct {
+	items, rels, media int
+	rID                uintptr
+	styles             int // styles is -1 if the styles are not loaded
+	abstractNums, nums int // abstractNums is -1 if the numbering is not loaded
+}
+
+// saveImport saves the state of f before an import
+func (f *Docx) saveImport() importState {
+	st := importState{
+		items: len(f.Document.Body.Items), rels: len(f.docRelation.Relationship), media: len(f.media),
+		rID: f.rID, styles: -1, abstractNums: -1,
+	}
+	if f.styles != nil {
+		st.styles = len(f.styles.Styles)
+	}
+	if f.numbering != nil {
+		st.abstractNums, st.nums = len(f.numbering.AbstractNums), len(f.numbering.Nums)
+	}
+	return st
+}
+
+// rollbackImport removes what has been added into f since st was saved.
+// The styles and numbering loaded by the import are dropped to be parsed again.
+func (f *Docx) rollbackImport(st importState) {
+	f.Document.Body.Items = f.Document.Body.Items[:st.items]
+	f.docRelation.Relationship = f.docRelation.Relationship[:st.rels]
+	f.rID = st.rID
+	for _, m := range f.media[st.media:] {
+		delete(f.mediaNameIdx, m.Name)
+	}
+	f.media = f.media[:st.media]
+	if st.styles < 0 {
+		f.styles, f.cachedStyles = nil, nil
+	} else {
+		f.styles.Styles = f.styles.Styles[:st.styles]
+	}
+	if st.abstractNums < 0 {
+		f.numbering, f.cachedNumbering = nil, nil
+	} else {
+		f.numbering.AbstractNums = f.numbering.AbstractNums[:st.abstractNums]
+		f.numbering.Nums = f.numbering.Nums[:st.nums]
+	}
+}
+
+type mdBlockKind uint8
+
+const (
+	mdParagraph mdBlockKind = iota
+	mdHeading
+	mdCode
+	mdQuote
+	mdList
+	mdRule
+	mdTable
+)
+
+// mdBlock is a block of markdown
+type mdBlock struct {
+	kind     mdBlockKind
+	level    int        // level is of heading
+	text     string     // text is the inline source of paragraph and heading, or the content of code
+	children []*mdBlock // children are of quote
+
+	ordered bool
+	start   int
+	items   [][]*mdBlock // items are of list
+
+	rows   [][]string // rows are of table, including the header
+	aligns []string
+}
+
+var (
+	mdFenceRe      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdATXRe        = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdRuleRe       = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdQuoteRe      = regexp.MustCompile(`^ {0,3}> ?`)
+	mdItemRe       = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)`)
+	mdSetextRe     = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdTableDelimRe = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdAutolinkRe   = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*|[\w.+-]+@[\w-]+(?:\.[\w-]+)+)>`)
+)
+
+// markdownLines splits src into lines with tabs in indentation expanded
+func markdownLines(src string) []string {
+	src = strings.ReplaceAll(strings.ReplaceAll(src, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, l := range lines {
+		if !strings.Contains(l, "\t") {
+			continue
+		}
+		sb := strings.Builder{}
+		col := 0
+		for j := 0; j < len(l); j++ {
+			switch l[j] {
+			case ' ':
+				sb.WriteByte(' ')
+				col++
+				continue
+			case '\t':
+				n := 4 - col%4
+				sb.WriteString(strings.Repeat(" ", n))
+				col += n
+				continue
+			}
+			sb.WriteString(l[j:])
+			break
+		}
+		lines[i] = sb.String()
+	}
+	return lines
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func isBlankLine(l string) bool {
+	return strings.TrimSpace(l) == ""
+}
+
+// indentOf counts the leading spaces of l
+func indentOf(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+// startsMarkdownBlock checks whether l interrupts a paragraph
+func startsMarkdownBlock(l string) bool {
+	if mdFenceRe.MatchString(l) || mdATXRe.MatchString(l) || mdRuleRe.MatchString(l) || mdQuoteRe.MatchString(l) {
+		return true
+	}
+	m := mdItemRe.FindStringSubmatch(l)
+	if m == nil || isBlankLine(l[len(m[0]):]) {
+		return false
+	}
+	c := m[2][len(m[2])-1]
+	return c == '-' || c == '*' || c == '+' || m[2][:len(m[2])-1] == "1"
+}
+
+// parseMarkdownBlocks parses the lines into blocks
+func parseMarkdownBlocks(lines []string) []*mdBlock {
+	var blocks []*mdBlock
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlankLine(line):
+			i++
+		case mdFenceRe.MatchString(line):
+			fence := mdFenceRe.FindStringSubmatch(line)[1]
+			indent := indentOf(line)
+			var code []string
+			for i++; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if indentOf(lines[i]) <= 3 && len(t) >= len(fence) && strings.Trim(t, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, lines[i][minInt(indent, indentOf(lines[i])):])
+			}
+			blocks = append(blocks, &mdBlock{kind: mdCode, text: strings.Join(code, "\n")})
+		case mdATXRe.MatchString(line):
+			m := mdATXRe.FindStringSubmatch(line)
+			blocks = append(blocks, &mdBlock{kind: mdHeading, level: len(m[1]), text: m[2]})
+			i++
+		case mdRuleRe.MatchString(line):
+			blocks = append(blocks, &mdBlock{kind: mdRule})
+			i++
+		case mdQuoteRe.MatchString(line):
+			var inner []string
+			lazy := false
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if loc := mdQuoteRe.FindStringIndex(l); loc != nil {
+					inner = append(inner, l[loc[1]:])
+					lazy = !isBlankLine(l[loc[1]:])
+					continue
+				}
+				if !lazy || isBlankLine(l) || startsMarkdownBlock(l) {
+					break
+				}
+				inner = append(inner, l)
+			}
+			blocks = append(blocks, &mdBlock{kind: mdQuote, children: parseMarkdownBlocks(inner)})
+		case mdItemRe.MatchString(line):
+			var list *mdBlock
+			list, i = parseMarkdownList(lines, i)
+			blocks = append(blocks, list)
+		case isMarkdownTable(lines, i):
+			var table *mdBlock
+			table, i = parseMarkdownTable(lines, i)
+			blocks = append(blocks, table)
+		case indentOf(line) >= 4:
+			var code []string
+			for ; i < len(lines) && (isBlankLine(lines[i]) || indentOf(lines[i]) >= 4); i++ {
+				code = append(code, lines[i][minInt(4, len(lines[i])):])
+			}
+			for len(code) > 0 && isBlankLine(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, &mdBlock{kind: mdCode, text: strings.Join(code, "\n")})
+		default:
+			para := []string{strings.TrimLeft(line, " ")}
+			kind, level := mdParagraph, 0
+			for i++; i < len(lines); i++ {
+				l := lines[i]
+				if isBlankLine(l) {
+					break
+				}
+				if m := mdSetextRe.FindStringSubmatch(l); m != nil {
+					kind, level = mdHeading, 1
+					if m[1][0] == '-' {
+						level = 2
+					}
+					i++
+					break
+				}
+				if startsMarkdownBlock(l) {
+					break
+				}
+				para = append(para, strings.TrimLeft(l, " "))
+			}
+			text := strings.Join(para, "\n")
+			if kind == mdHeading {
+				text = strings.TrimSpace(text)
+			}
+			blocks = append(blocks, &mdBlock{kind: kind, level: level, text: text})
+		}
+	}
+	return blocks
+}
+
+// parseMarkdownList parses the list starting at lines[i] and returns the line after it
+func parseMarkdownList(lines []string, i int) (*mdBlock, int) {
+	m := mdItemRe.FindStringSubmatch(lines[i])
+	delim := m[2][len(m[2])-1]
+	list := &mdBlock{kind: mdList, start: 1}
+	if delim == '.' || delim == ')' {
+		list.ordered = true
+		list.start, _ = strconv.Atoi(m[2][:len(m[2])-1])
+	}
+	for i < len(lines) {
+		m = mdItemRe.FindStringSubmatch(lines[i])
+		if m == nil || m[2][len(m[2])-1] != delim {
+			break
+		}
+		content := len(m[0])
+		if m[3] == "" || len(m[3]) > 4 {
+			content = len(m[1]) + len(m[2]) + 1
+		}
+		item := []string{lines[i][minInt(content, len(lines[i])):]}
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			if isBlankLine(l) {
+				j := i + 1
+				for j < len(lines) && isBlankLine(lines[j]) {
+					j++
+				}
+				if j < len(lines) && indentOf(lines[j]) >= content {
+					for ; i < j-1; i++ {
+						item = append(item, "")
+					}
+					item = append(item, "")
+					continue
+				}
+				break
+			}
+			if indentOf(l) >= content {
+				item = append(item, l[content:])
+				continue
+			}
+			if mdItemRe.MatchString(l) || startsMarkdownBlock(l) {
+				break
+			}
+			item = append(item, l) // lazy continuation
+		}
+		list.items = append(list.items, parseMarkdownBlocks(item))
+		j := i
+		for j < len(lines) && isBlankLine(lines[j]) {
+			j++
+		}
+		if j >= len(lines) {
+			return list, j
+		}
+		if m = mdItemRe.FindStringSubmatch(lines[j]); m == nil || m[2][len(m[2])-1] != delim || mdRuleRe.MatchString(lines[j]) {
+			return list, i
+		}
+		i = j
+	}
+	return list, i
+}
+
+// isMarkdownTable checks whether lines[i] starts a table, whose
+// delimiter row must have the same number of cells as the header
+func isMarkdownTable(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") && mdTableDelimRe.MatchString(lines[i+1]) &&
+		len(splitMarkdownRow(lines[i])) == len(splitMarkdownRow(lines[i+1]))
+}
+
+// parseMarkdownTable parses the table starting at lines[i] and returns the line after it
+func parseMarkdownTable(lines []string, i int) (*mdBlock, int) {
+	header := splitMarkdownRow(lines[i])
+	table := &mdBlock{kind: mdTable, rows: [][]string{header}}
+	for _, d := range splitMarkdownRow(lines[i+1]) {
+		d = strings.TrimSpace(d)
+		switch {
+		case strings.HasPrefix(d, ":") && strings.HasSuffix(d, ":"):
+			table.aligns = append(table.aligns, "center")
+		case strings.HasSuffix(d, ":"):
+			table.aligns = append(table.aligns, "right")
+		case strings.HasPrefix(d, ":"):
+			table.aligns = append(table.aligns, "left")
+		default:
+			table.aligns = append(table.aligns, "")
+		}
+	}
+	for i += 2; i < len(lines); i++ {
+		l := lines[i]
+		if isBlankLine(l) || startsMarkdownBlock(l) {
+			break
+		}
+		row := splitMarkdownRow(l)
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		table.rows = append(table.rows, row[:len(header)])
+	}
+	return table, i
+}
+
+// splitMarkdownRow splits a table row into cells by unescaped pipes
+func splitMarkdownRow(l string) []string {
+	l = strings.TrimSpace(l)
+	l = strings.TrimPrefix(l, "|")
+	if strings.HasSuffix(l, "|") && !strings.HasSuffix(l, `\|`) {
+		l = l[:len(l)-1]
+	}
+	var cells []string
+	begin := 0
+	for i := 0; i < len(l); i++ {
+		switch l[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(l[begin:i]))
+			begin = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(l[begin:]))
+}
+
+// mdSpan is a piece of inline markdown
+type mdSpan struct {
+	text          string
+	b, i, s, code bool
+	br            bool
+	link          string // link is the target of a link
+	image         string // image is the source of an image, whose alt is text
+}
+
+type mdInline struct {
+	spans  []mdSpan
+	buf    strings.Builder
+	closed int // closed is the end of the last emphasis parsed
+}
+
+// parseMarkdownInline parses the inline markdown s into spans
+func parseMarkdownInline(s string) []mdSpan {
+	var p mdInline
+	p.parse(s, mdSpan{})
+	return p.spans
+}
+
+// plainMarkdown is the text of inline markdown s without formatting
+func plainMarkdown(s string) string {
+	sb := strings.Builder{}
+	for _, sp := range parseMarkdownInline(s) {
+		if sp.br {
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteString(sp.text)
+	}
+	return sb.String()
+}
+
+// flush adds the text in buf as a span in style st
+func (p *mdInline) flush(st mdSpan) {
+	if p.buf.Len() == 0 {
+		return
+	}
+	st.text = p.buf.String()
+	if strings.Contains(st.text, "&") {
+		st.text = html.UnescapeString(st.text)
+	}
+	p.spans = append(p.spans, st)
+	p.buf.Reset()
+}
+
+func (p *mdInline) parse(s string, st mdSpan) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				p.flush(st)
+				p.spans = append(p.spans, mdSpan{br: true})
+				i += 2
+				for i < len(s) && s[i] == ' ' {
+					i++
+				}
+				continue
+			}
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				p.buf.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '\n':
+			text := p.buf.String()
+			hard := strings.HasSuffix(text, "  ")
+			p.buf.Reset()
+			p.buf.WriteString(strings.TrimRight(text, " "))
+			if hard {
+				p.flush(st)
+				p.spans = append(p.spans, mdSpan{br: true})
+			} else {
+				p.buf.WriteByte(' ')
+			}
+			for i++; i < len(s) && s[i] == ' '; i++ {
+			}
+			continue
+		case '`':
+			n := runLength(s, i, '`')
+			if j := findBackticks(s, i+n, n); j >= 0 {
+				p.flush(st)
+				code := strings.ReplaceAll(s[i+n:j], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+					code = code[1 : len(code)-1]
+				}
+				sp := st
+				sp.text, sp.code = code, true
+				p.spans = append(p.spans, sp)
+				i = j + n
+				continue
+			}
+			p.buf.WriteString(s[i : i+n])
+			i += n
+			continue
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' {
+				if text, dest, n, ok := parseMarkdownLink(s[i+1:]); ok {
+					p.flush(st)
+					p.spans = append(p.spans, mdSpan{text: plainMarkdown(text), image: dest})
+					i += 1 + n
+					continue
+				}
+			}
+		case '[':
+			if text, dest, n, ok := parseMarkdownLink(s[i:]); ok {
+				p.flush(st)
+				sp := st
+				sp.text, sp.link = plainMarkdown(text), dest
+				p.spans = append(p.spans, sp)
+				i += n
+				continue
+			}
+		case '<':
+			if m := mdAutolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				p.flush(st)
+				sp := st
+				sp.text, sp.link = m[1], m[1]
+				if !strings.Contains(m[1], ":") {
+					sp.link = "mailto:" + m[1]
+				}
+				p.spans = append(p.spans, sp)
+				i += len(m[0])
+				continue
+			}
+		case '*', '_', '~':
+			n := runLength(s, i, c)
+			if p.emphasis(s, i, n, st) {
+				i = p.closed
+				continue
+			}
+			p.buf.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		p.buf.WriteByte(c)
+		i++
+	}
+	p.flush(st)
+}
+
+// emphasis parses the emphasis opened by the n s[i] and sets closed on success
+func (p *mdInline) emphasis(s string, i, n int, st mdSpan) bool {
+	c := s[i]
+	if i+n >= len(s) || s[i+n] == ' ' || s[i+n] == '\n' {
+		return false
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return false
+	}
+	if c == '~' && n != 2 || n > 3 {
+		return false
+	}
+	j := findEmphasisCloser(s, i+n, c, n)
+	if j < 0 {
+		return false
+	}
+	p.flush(st)
+	inner := st
+	switch {
+	case c == '~':
+		inner.s = true
+	case n == 1:
+		inner.i = true
+	case n == 2:
+		inner.b = true
+	default:
+		inner.b, inner.i = true, true
+	}
+	p.parse(s[i+n:j], inner)
+	p.closed = j + n
+	return true
+}
+
+// findEmphasisCloser finds the run of exactly n c that closes an emphasis from s[from:]
+func findEmphasisCloser(s string, from int, c byte, n int) int {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			m := runLength(s, j, '`')
+			if k := findBackticks(s, j+m, m); k >= 0 {
+				j = k + m
+				continue
+			}
+			j += m
+			continue
+		case c:
+			m := runLength(s, j, c)
+			if m == n && j > from && s[j-1] != ' ' && s[j-1] != '\n' &&
+				(c != '_' || j+m >= len(s) || !isWordByte(s[j+m])) {
+				return j
+			}
+			j += m
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// runLength counts the c repeated from s[i]
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// findBackticks finds the run of exactly n backticks from s[from:]
+func findBackticks(s string, from, n int) int {
+	for j := from; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j, '`')
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+func isASCIIPunct(c byte) bool {
+	return c >= '!' && c <= '/' || c >= ':' && c <= '@' || c >= '[' && c <= '`' || c >= '{' && c <= '~'
+}
+
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// parseMarkdownLink parses an inline link [text](dest "title") at the start of s
+// and returns its text, destination and length
+func parseMarkdownLink(s string) (text, dest string, n int, ok bool) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			m := runLength(s, i, '`')
+			if k := findBackticks(s, i+m, m); k >= 0 {
+				i = k + m - 1
+			} else {
+				i += m - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return
+	}
+	text = s[1:end]
+	i := end + 2
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	if i < len(s) && s[i] == '<' {
+		k := strings.IndexAny(s[i+1:], ">\n")
+		if k < 0 || s[i+1+k] != '>' {
+			return
+		}
+		dest = s[i+1 : i+1+k]
+		i += k + 2
+	} else {
+		begin, parens := i, 0
+	dest:
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '(':
+				parens++
+			case ')':
+				if parens == 0 {
+					break dest
+				}
+				parens--
+			case ' ', '\n':
+				break dest
+			}
+		}
+		if i > len(s) {
+			return
+		}
+		dest = s[begin:i]
+	}
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := strings.IndexByte(s[i+1:], closer)
+		if k < 0 {
+			return
+		}
+		i += k + 2
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+	if i >= len(s) || s[i] != ')' {
+		return
+	}
+	dest = html.UnescapeString(markdownUnescape(dest))
+	return text, dest, i + 1, true
+}
+
+// markdownUnescape removes the backslashes before punctuations
+func markdownUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	sb := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// markdownStyles are the styles generated by AddMarkdown as type, id, name and props
+var markdownStyles = map[string][4]string{
+	"h1":    {"paragraph", "Heading1", "heading 1", markdownHeadingProps(1, 40)},
+	"h2":    {"paragraph", "Heading2", "heading 2", markdownHeadingProps(2, 32)},
+	"h3":    {"paragraph", "Heading3", "heading 3", markdownHeadingProps(3, 28)},
+	"h4":    {"paragraph", "Heading4", "heading 4", markdownHeadingProps(4, 26)},
+	"h5":    {"paragraph", "Heading5", "heading 5", markdownHeadingProps(5, 24)},
+	"h6":    {"paragraph", "Heading6", "heading 6", markdownHeadingProps(6, 22)},
+	"quote": {"paragraph", "Quote", "Quote", `<w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="CCCCCC"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:i/><w:color w:val="595959"/></w:rPr>`},
+	"code":  {"paragraph", "SourceCode", "Source Code", `<w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/><w:spacing w:after="0"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/></w:rPr>`},
+	"verb":  {"character", "VerbatimChar", "Verbatim Char", `<w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:rPr>`},
+	"rule":  {"paragraph", "HorizontalRule", "Horizontal Rule", `<w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr>`},
+}
+
+func markdownHeadingProps(level, sz int) string {
+	return `<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="` +
+		strconv.Itoa(level-1) + `"/></w:pPr><w:rPr><w:b/><w:sz w:val="` + strconv.Itoa(sz) + `"/></w:rPr>`
+}
+
+// mdContext is where the blocks are added
+type mdContext struct {
+	ilvl  int    // ilvl is the list level, -1 if not in a list
+	numID string // numID is of the list item
+	first bool   // first is set until the first paragraph of the list item is added
+	quote bool
+}
+
+// mdBuilder adds the markdown blocks into a docx
+type mdBuilder struct {
+	f      *Docx
+	opt    *MarkdownImportOptions
+	styles map[string]string // styles is key in markdownStyles -> styleId
+	cell   *WTableCell       // cell receives the paragraphs instead of the body if not nil
+}
+
+// style gets the id of the style by its key in markdownStyles, adding it on need
+func (b *mdBuilder) style(key string) (string, error) {
+	if id, ok := b.styles[key]; ok {
+		return id, nil
+	}
+	def := markdownStyles[key]
+	id, err := b.f.addStyle(def[0], def[1], def[2], key[0] != 'h', def[3])
+	if err != nil {
+		return "", err
+	}
+	b.styles[key] = id
+	return id, nil
+}
+
+// para adds a paragraph in ctx
+func (b *mdBuilder) para(ctx *mdContext) *Paragraph {
+	var p *Paragraph
+	if b.cell != nil {
+		p = b.cell.AddParagraph()
+	} else {
+		p = b.f.AddParagraph()
+	}
+	if ctx.ilvl < 0 {
+		return p
+	}
+	if ctx.first {
+		ctx.first = false
+		p.NumPr(ctx.numID, strconv.Itoa(ctx.ilvl))
+		return p
+	}
+	p.Properties = &ParagraphProperties{Ind: &Ind{Left: 720 * (ctx.ilvl + 1)}}
+	return p
+}
+
+// styledPara adds a paragraph of the style by its key in markdownStyles
+func (b *mdBuilder) styledPara(ctx *mdContext, key string) (*Paragraph, error) {
+	id, err := b.style(key)
+	if err != nil {
+		return nil, err
+	}
+	return b.para(ctx).Style(id), nil
+}
+
+func (b *mdBuilder) blocks(blocks []*mdBlock, ctx *mdContext) error {
+	for _, blk := range blocks {
+		err := b.block(blk, ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *mdBuilder) block(blk *mdBlock, ctx *mdContext) error {
+	switch blk.kind {
+	case mdParagraph:
+		p := b.para(ctx)
+		if ctx.quote {
+			id, err := b.style("quote")
+			if err != nil {
+				return err
+			}
+			p.Style(id)
+		}
+		return b.inline(p, parseMarkdownInline(blk.text))
+	case mdHeading:
+		p, err := b.styledPara(ctx, "h"+strconv.Itoa(blk.level))
+		if err != nil {
+			return err
+		}
+		return b.inline(p, parseMarkdownInline(blk.text))
+	case mdCode:
+		p, err := b.styledPara(ctx, "code")
+		if err != nil {
+			return err
+		}
+		preserveSpace(p.AddText(blk.text))
+	case mdQuote:
+		qctx := *ctx
+		qctx.quote = true
+		err := b.blocks(blk.children, &qctx)
+		ctx.first = qctx.first
+		return err
+	case mdList:
+		ilvl := ctx.ilvl + 1
+		if ilvl > 8 {
+			ilvl = 8
+		}
+		starts := make([]int, ilvl+1)
+		starts[ilvl] = blk.start
+		numID, err := b.f.AddListNumbering(blk.ordered, starts...)
+		if err != nil {
+			return err
+		}
+		for _, item := range blk.items {
+			ictx := mdContext{ilvl: ilvl, numID: numID, first: true, quote: ctx.quote}
+			if len(item) == 0 {
+				b.para(&ictx)
+				continue
+			}
+			err = b.blocks(item, &ictx)
+			if err != nil {
+				return err
+			}
+		}
+	case mdRule:
+		_, err := b.styledPara(ctx, "rule")
+		return err
+	case mdTable:
+		return b.table(blk)
+	}
+	return nil
+}
+
+func (b *mdBuilder) table(blk *mdBlock) error {
+	cols := 0
+	for _, row := range blk.rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	t := b.f.AddTable(len(blk.rows), cols, 0, nil)
+	for i, row := range blk.rows {
+		for j, cell := range row {
+			p := t.TableRows[i].TableCells[j].AddParagraph()
+			if j < len(blk.aligns) && blk.aligns[j] != "" {
+				p.Justification(blk.aligns[j])
+			}
+			spans := parseMarkdownInline(cell)
+			if i == 0 {
+				for k := range spans {
+					spans[k].b = true
+				}
+			}
+			err := b.inline(p, spans)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inline adds the spans into p
+func (b *mdBuilder) inline(p *Paragraph, spans []mdSpan) error {
+	for _, sp := range spans {
+		var r *Run
+		switch {
+		case sp.br:
+			p.Children = append(p.Children, &Run{
+				RunProperties: &RunProperties{},
+				Children:      []interface{}{&BarterRabbet{}},
+			})
+			continue
+		case sp.image != "":
+			if data, err := b.image(sp.image); err == nil && data != nil {
+				_, err = p.AddInlineDrawing(data)
+				if err == nil {
+					continue
+				}
+			}
+			text := sp.text
+			if text == "" {
+				text = sp.image
+			}
+			r = &p.AddLink(text, sp.image).Run
+		case sp.link != "":
+			r = &p.AddLink(sp.text, sp.link).Run
+		default:
+			r = p.AddText(sp.text)
+			preserveSpace(r)
+		}
+		if sp.b {
+			r.Bold()
+		}
+		if sp.i {
+			r.Italic()
+		}
+		if sp.s {
+			r.Strike(true)
+		}
+		if sp.code {
+			id, err := b.style("verb")
+			if err != nil {
+				return err
+			}
+			r.RunProperties.RunStyle = &RunStyle{Val: id}
+		}
+	}
+	return nil
+}
+
+// image reads the image at src, or returns nil if it is remote
+// or a local file not allowed by the options
+func (b *mdBuilder) image(src string) ([]byte, error) {
+	if strings.HasPrefix(src, "data:") {
+		meta, data, ok := strings.Cut(src[5:], ",")
+		if !ok || !strings.HasSuffix(meta, ";base64") {
+			return nil, nil
+		}
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if u, err := url.Parse(src); err == nil && u.Scheme != "" && len(u.Scheme) > 1 && u.Scheme != "file" {
+		return nil, nil
+	}
+	name := strings.TrimPrefix(src, "file://")
+	if n, err := url.PathUnescape(name); err == nil {
+		name = n
+	}
+	if (b.opt.BaseDir == "" && b.opt.ReadFile == nil) || !filepath.IsLocal(name) {
+		return nil, nil
+	}
+	if b.opt.BaseDir != "" {
+		name = filepath.Join(b.opt.BaseDir, name)
+	}
+	if b.opt.ReadFile != nil {
+		return b.opt.ReadFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+// preserveSpace keeps the leading, trailing and repeated spaces in the texts of r
+func preserveSpace(r *Run) {
+	for _, c := range r.Children {
+		t, ok := c.(*Text)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(t.Text, " ") || strings.HasSuffix(t.Text, " ") || strings.Contains(t.Text, "  ") {
+			t.XMLSpace = "preserve"
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package docx
+
+import "testing"
+
+func TestFromMarkdown(t *testing.T) {
+	src := "Title\n=====\n\n" +
+		"plain **bold** *it* ***both*** ~~gone~~ `code` a\\*b [link](https://example.com \"t\")  \nnext\n" +
+		"line\n\n" +
+		"## Sub\n\n" +
+		"1. one\n2. two\n   - dot\n\n     more\n3. three\n\n" +
+		"> quoted\nlazy\n\n" +
+		"---\n\n" +
+		"| a | b |\n|:--|--:|\n| c \\| d | e |\n\n" +
+		"```go\nfunc  main() {}\n```\n\n" +
+		"![pic](fumiama.JPG)\n"
+	f, err := FromMarkdown([]byte(src), &MarkdownImportOptions{BaseDir: "testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := f.Document.Body.Items
+	if len(items) != 13 {
+		t.Fatal("unexpected items", len(items))
+	}
+	para := func(i int) *Paragraph {
+		p, ok := items[i].(*Paragraph)
+		if !ok {
+			t.Fatalf("item %d is %T", i, items[i])
+		}
+		return p
+	}
+	style := func(i int) string {
+		p := para(i)
+		if p.Properties == nil || p.Properties.Style == nil {
+			return ""
+		}
+		return p.Properties.Style.Val
+	}
+	if f.HeadingLevel(para(0)) != 1 || f.HeadingLevel(para(2)) != 2 || para(0).String() != "Title" {
+		t.Fatal("unexpected heading", style(0), style(2))
+	}
+	if para(1).String() != "plain bold it both gone code a*b [link](https://example.com)\nnext line" {
+		t.Fatal("unexpected text", para(1).String())
+	}
+	runs := para(1).Children
+	if r := runs[1].(*Run); r.RunProperties.Bold == nil || r.RunProperties.Italic != nil {
+		t.Fatal("bold expected")
+	}
+	if r := runs[5].(*Run); r.RunProperties.Bold == nil || r.RunProperties.Italic == nil {
+		t.Fatal("bold italic expected")
+	}
+	if r := runs[7].(*Run); r.RunProperties.Strike == nil {
+		t.Fatal("strike expected")
+	}
+	if r := runs[9].(*Run); r.RunProperties.RunStyle == nil || r.RunProperties.RunStyle.Val != "VerbatimChar" {
+		t.Fatal("code expected")
+	}
+	for i, exp := range []struct {
+		ilvl   int
+		numFmt string
+	}{{0, "decimal"}, {0, "decimal"}, {1, "bullet"}, {-1, ""}, {0, "decimal"}} {
+		numID, ilvl, numFmt := f.ListLevel(para(3 + i))
+		if exp.ilvl < 0 {
+			if numID != "" || para(3+i).Properties.Ind.Left != 1440 {
+				t.Fatal("unexpected continuation", numID)
+			}
+			continue
+		}
+		if ilvl != exp.ilvl || numFmt != exp.numFmt {
+			t.Fatal("unexpected list level", i, ilvl, numFmt)
+		}
+	}
+	if style(8) != "Quote" || para(8).String() != "quoted lazy" {
+		t.Fatal("unexpected quote", style(8), para(8).String())
+	}
+	if style(9) != "HorizontalRule" {
+		t.Fatal("unexpected rule", style(9))
+	}
+	tbl, ok := items[10].(*Table)
+	if !ok || len(tbl.TableRows) != 2 {
+		t.Fatal("unexpected table")
+	}
+	if c := tbl.TableRows[1].TableCells[0].Paragraphs[0]; c.String() != "c | d" || c.Properties.Justification.Val != "left" {
+		t.Fatal("unexpected cell", c.String())
+	}
+	if style(11) != "SourceCode" || para(11).String() != "func  main() {}" {
+		t.Fatal("unexpected code", style(11), para(11).String())
+	}
+	r := para(12).Children[0].(*Run)
+	if _, ok := r.Children[0].(*Drawing); !ok {
+		t.Fatal("image expected")
+	}
+	s, err := f.Styles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Get("Heading1"); st == nil || st.Name != "heading 1" || st.CustomStyle {
+		t.Fatal("unexpected heading style")
+	}
+}
+
+func TestFromMarkdownMismatchedTable(t *testing.T) {
+	f, err := FromMarkdown([]byte("| a | b |\n| --- |\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Document.Body.Items) != 1 {
+		t.Fatal("unexpected items", len(f.Document.Body.Items))
+	}
+	if _, ok := f.Document.Body.Items[0].(*Paragraph); !ok {
+		t.Fatal("mismatched table is not a paragraph")
+	}
+	f, err = FromMarkdown([]byte("| a | b |\n| --- | :-: |\n| 1 | 2 | 3 |\n| 4 |\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl, ok := f.Document.Body.Items[0].(*Table)
+	if !ok || len(tbl.TableRows) != 3 || len(tbl.TableRows[1].TableCells) != 2 {
+		t.Fatal("unexpected table", f.Document.Body.Items[0])
+	}
+}
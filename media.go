@@ -20,13 +20,105 @@
 
 package docx
 
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/fs"
+)
+
 //nolint:revive,stylecheck
 const MEDIA_FOLDER = `word/media/`
 
 // Media is in word/media
 type Media struct {
 	Name string // Name is for word/media/Name
-	Data []byte // Data is data of this media
+	// Data is data of this media. It is nil for the parsed media
+	// before being loaded by Load, and replaces the parsed one if set.
+	Data []byte
+
+	src    fs.FS         // src is the package parsed, which has the media not loaded
+	limits *ParseOptions // limits is the limits of src on reading
+}
+
+// Load reads the data of the media from the parsed package
+// if not loaded yet, and keeps it in Data
+func (m *Media) Load() ([]byte, error) {
+	if m.Data != nil || m.src == nil {
+		return m.Data, nil
+	}
+	file, err := m.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	m.Data, err = io.ReadAll(file)
+	if err != nil {
+		m.Data = nil
+		return nil, err
+	}
+	return m.Data, nil
+}
+
+// Open opens the data of the media for reading without loading it into Data
+func (m *Media) Open() (io.ReadCloser, error) {
+	if m.Data != nil || m.src == nil {
+		return io.NopCloser(bytes.NewReader(m.Data)), nil
+	}
+	file, err := m.src.Open(m.String())
+	if err != nil || m.limits == nil {
+		return file, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{m.limits.limitReader(m.String(), file), file}, nil
+}
+
+// mediaReader opens the media on the first read and closes it at the end,
+// so that the media not loaded are not held in memory together on packing
+type mediaReader struct {
+	m *Media
+	r io.ReadCloser
+}
+
+// Read implements io.Reader
+func (mr *mediaReader) Read(p []byte) (n int, err error) {
+	if mr.r == nil {
+		mr.r, err = mr.m.Open()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err = mr.r.Read(p)
+	if err == io.EOF {
+		_ = mr.r.Close()
+	}
+	return
+}
+
+// mediaData returns the data of the media name, or nil if missing
+func (f *Docx) mediaData(name string) []byte {
+	m := f.Media(name)
+	if m == nil {
+		return nil
+	}
+	data, _ := m.Load()
+	return data
+}
+
+// mediaMD5 returns the md5 hex of the media name without loading it into Data,
+// or that of empty data if missing
+func (f *Docx) mediaMD5(name string) string {
+	h := md5.New()
+	if m := f.Media(name); m != nil {
+		if rc, err := m.Open(); err == nil {
+			_, _ = io.Copy(h, rc)
+			_ = rc.Close()
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // String is the full path of the media
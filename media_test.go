@@ -0,0 +1,98 @@
+package docx
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLazyMedia(t *testing.T) {
+	f := New().WithDefaultTheme()
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png, jpg := nf.Media(f.media[0].Name), nf.Media(f.media[1].Name)
+	if png.Data != nil || jpg.Data != nil {
+		t.Fatal("unexpected loaded media")
+	}
+	r, err := png.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Close()
+	if !bytes.Equal(data, f.media[0].Data) || png.Data != nil {
+		t.Fatal("unexpected opened media")
+	}
+	data, err = jpg.Load()
+	if err != nil || !bytes.Equal(data, f.media[1].Data) || jpg.Data == nil {
+		t.Fatal("unexpected loaded media", err)
+	}
+	jpg.Data = []byte("replaced")
+
+	// the media not loaded are copied through and the replaced are written
+	buf.Reset()
+	_, err = nf.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if png.Data != nil {
+		t.Fatal("unexpected loaded media on packing")
+	}
+	nf, err = Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(nf.mediaData(png.Name), f.media[0].Data) || string(nf.mediaData(jpg.Name)) != "replaced" {
+		t.Fatal("unexpected media after packing")
+	}
+}
+
+func TestLazyMediaString(t *testing.T) {
+	f := New().WithDefaultTheme()
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := md5.Sum(f.media[0].Data)
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := nf.Document.Body.Items[0].(*Paragraph).String(); !strings.HasSuffix(s, "("+hex.EncodeToString(h[:])+")") {
+		t.Fatal("unexpected string", s)
+	}
+	_, err = nf.Search(regexp.MustCompile("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nf.media[0].Data != nil {
+		t.Fatal("media loaded by String")
+	}
+}
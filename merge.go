@@ -0,0 +1,366 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+)
+
+// AppendStyleMode decides what to do with a style of the appended
+// file that has a same-named style in the destination
+type AppendStyleMode uint8
+
+const (
+	// UseDestinationStyles maps the style to the same-named one in the destination
+	UseDestinationStyles AppendStyleMode = iota
+	// KeepSourceFormatting imports the style under a new id so
+	// that the appended content looks the same as in its source
+	KeepSourceFormatting
+)
+
+// AppendOptions controls how AppendFileWithOptions merges two files
+type AppendOptions struct {
+	// StyleMode decides what to do with the conflicting styles
+	StyleMode AppendStyleMode
+	// ContinueNumbering continues the lists that already exist in the
+	// destination with the same definition (nsid) instead of restarting them
+	ContinueNumbering bool
+}
+
+var (
+	numIDInRawRe = regexp.MustCompile(`(<w:numId\s+w:val=")(\d+)(")`)
+	lvlStartRe   = regexp.MustCompile(`<w:start\s+w:val="(\d+)"`)
+)
+
+// AppendFileWithOptions appends all contents in af to f,
+// importing the styles and numbering definitions they use.
+//
+// Styles missing in f are copied, and numbering definitions are
+// always copied under new ids so that lists of af never collide
+// with the ones in f.
+//
+// All the parts needed are parsed before changing f,
+// so f is left unchanged if an error is returned.
+func (f *Docx) AppendFileWithOptions(af *Docx, opt AppendOptions) error {
+	m := appendMapper{
+		opt:    opt,
+		styles: make(map[string]string, 64),
+		nums:   make(map[string]string, 16),
+	}
+	err := m.load(f, af)
+	if err != nil {
+		return err
+	}
+	if m.dstNum != nil {
+		m.importNumbering()
+		f.numbering, f.cachedNumbering = m.dstNum, nil
+	}
+	m.importStyles()
+	f.styles, f.cachedStyles = m.dst, nil
+	f.appendItems(af, &m)
+	return nil
+}
+
+// appendItems appends the copied items of af to f, renaming
+// their style and numbering references by m if it is not nil
+func (f *Docx) appendItems(af *Docx, m *appendMapper) {
+	for _, item := range af.Document.Body.Items {
+		switch o := item.(type) {
+		case *Paragraph:
+			np := o.copymedia(f)
+			if m != nil {
+				m.paragraph(&np)
+			}
+			f.Document.Body.Items = append(f.Document.Body.Items, &np)
+		case *Table:
+			nt := o.copymedia(f)
+			if m != nil {
+				m.table(&nt)
+			}
+			f.Document.Body.Items = append(f.Document.Body.Items, &nt)
+		default:
+			f.Document.Body.Items = append(f.Document.Body.Items, o)
+		}
+	}
+}
+
+// hasNumbering checks whether the docx has word/numbering.xml
+func (f *Docx) hasNumbering() bool {
+	return f.numbering != nil || f.hasTemplateFile("word/numbering.xml")
+}
+
+// appendMapper renames the style and numbering ids from the appended file
+type appendMapper struct {
+	opt AppendOptions
+
+	src, dst       *Styles
+	srcNum, dstNum *Numbering        // srcNum and dstNum are nil if af has no list
+	styles         map[string]string // styles is src styleId -> dst styleId
+	nums           map[string]string // nums is src numId -> dst numId
+
+	defaultPara string // defaultPara is set in KeepSourceFormatting for paragraphs without style
+}
+
+// load parses the styles and numbering of f and af for reading
+func (m *appendMapper) load(f, af *Docx) (err error) {
+	if af.hasNumbering() {
+		src, err := af.readNumbering()
+		if err != nil {
+			return err
+		}
+		if len(src.Nums) > 0 {
+			m.dstNum, err = f.readNumbering()
+			if err != nil {
+				return err
+			}
+			m.srcNum = src
+		}
+	}
+	m.src, err = af.readStyles()
+	if err != nil {
+		return
+	}
+	m.dst, err = f.readStyles()
+	return
+}
+
+func (m *appendMapper) importNumbering() {
+	src, dst := m.srcNum, m.dstNum
+	dst.Attrs = mergeNamespaces(dst.Attrs, src.Attrs)
+	abstracts := make(map[string]*AbstractNum, len(src.AbstractNums))
+	var added []*AbstractNum
+	for _, a := range src.AbstractNums {
+		if a.Nsid != "" {
+			reused := false
+			for _, da := range dst.AbstractNums {
+				if da.Nsid == a.Nsid {
+					abstracts[a.AbstractNumID] = da
+					reused = true
+					break
+				}
+			}
+			if reused {
+				continue
+			}
+		}
+		na := a.Copy()
+		na.AbstractNumID = strconv.Itoa(dst.nextAbstractNumID())
+		dst.AbstractNums = append(dst.AbstractNums, na)
+		abstracts[a.AbstractNumID] = na
+		added = append(added, na)
+	}
+	nextid := dst.nextNumID()
+	for _, num := range src.Nums {
+		a, ok := abstracts[num.AbstractNumID]
+		if !ok {
+			continue
+		}
+		nn := num.Copy()
+		nn.NumID = strconv.Itoa(nextid)
+		nextid++
+		nn.AbstractNumID = a.AbstractNumID
+		if !m.opt.ContinueNumbering {
+			for ilvl := 0; ilvl < 9; ilvl++ {
+				nn.StartOverride(ilvl, a.start(ilvl))
+			}
+		}
+		dst.Nums = append(dst.Nums, nn)
+		m.nums[num.NumID] = nn.NumID
+	}
+	// the numbering styles linked are imported after all the numIds are mapped
+	for _, a := range added {
+		for i, e := range a.elems {
+			if e.Name != "styleLink" && e.Name != "numStyleLink" {
+				continue
+			}
+			id := m.style(getAtt(e.Attr, "val"))
+			a.elems[i] = rawElement{
+				Name: e.Name,
+				Attr: []xml.Attr{{Name: xml.Name{Space: "w", Local: "val"}, Value: id}},
+				XML:  `<w:` + e.Name + ` w:val="` + escapeAttr(id) + `"/>`,
+			}
+		}
+	}
+}
+
+// start is the start value of level ilvl
+func (a *AbstractNum) start(ilvl int) int {
+	lvl := strconv.Itoa(ilvl)
+	for _, e := range a.elems {
+		if e.Name != "lvl" || getAtt(e.Attr, "ilvl") != lvl {
+			continue
+		}
+		sub := lvlStartRe.FindStringSubmatch(e.XML)
+		if len(sub) < 2 {
+			break
+		}
+		n, err := strconv.Atoi(sub[1])
+		if err != nil {
+			break
+		}
+		return n
+	}
+	return 1
+}
+
+func (m *appendMapper) importStyles() {
+	m.dst.Attrs = mergeNamespaces(m.dst.Attrs, m.src.Attrs)
+	if m.opt.StyleMode == KeepSourceFormatting {
+		if def := m.src.Default("paragraph"); def != nil {
+			m.defaultPara = m.style(def.StyleID)
+		}
+	}
+}
+
+// style maps a style id of src to dst, importing it on need
+func (m *appendMapper) style(id string) string {
+	if nid, ok := m.styles[id]; ok {
+		return nid
+	}
+	st := m.src.Get(id)
+	if st == nil {
+		m.styles[id] = id
+		return id
+	}
+	var match *StyleDefinition
+	if st.Name != "" {
+		match = m.dst.GetByName(st.Type, st.Name)
+	} else if dst := m.dst.Get(id); dst != nil && dst.Type == st.Type {
+		match = dst
+	}
+	if match != nil && m.opt.StyleMode == UseDestinationStyles {
+		m.styles[id] = match.StyleID
+		return match.StyleID
+	}
+	nst := st.Copy()
+	nst.Default = false
+	if m.dst.Get(nst.StyleID) != nil {
+		for i := 1; ; i++ {
+			nid := id + "_" + strconv.Itoa(i)
+			if m.dst.Get(nid) == nil {
+				nst.StyleID = nid
+				break
+			}
+		}
+	}
+	if match != nil && nst.Name != "" {
+		for i := 1; ; i++ {
+			nname := st.Name + "_" + strconv.Itoa(i)
+			if m.dst.GetByName(st.Type, nname) == nil {
+				nst.Name = nname
+				break
+			}
+		}
+	}
+	m.styles[id] = nst.StyleID
+	m.dst.Styles = append(m.dst.Styles, nst)
+	if nst.BasedOn != "" {
+		nst.BasedOn = m.style(nst.BasedOn)
+	}
+	if nst.Next != "" {
+		nst.Next = m.style(nst.Next)
+	}
+	if nst.Link != "" {
+		nst.Link = m.style(nst.Link)
+	}
+	for i, e := range nst.elems {
+		if e.Name != "pPr" {
+			continue
+		}
+		nst.elems[i].XML = numIDInRawRe.ReplaceAllStringFunc(e.XML, func(s string) string {
+			sub := numIDInRawRe.FindStringSubmatch(s)
+			return sub[1] + m.num(sub[2]) + sub[3]
+		})
+	}
+	return nst.StyleID
+}
+
+// num maps a numId of src to dst
+func (m *appendMapper) num(id string) string {
+	if nid, ok := m.nums[id]; ok {
+		return nid
+	}
+	return id
+}
+
+func (m *appendMapper) runProperties(rp *RunProperties) *RunProperties {
+	if rp == nil || (rp.RunStyle == nil && rp.Style == nil) {
+		return rp
+	}
+	nrp := *rp
+	if nrp.RunStyle != nil {
+		nrp.RunStyle = &RunStyle{Val: m.style(nrp.RunStyle.Val)}
+	}
+	if nrp.Style != nil {
+		nrp.Style = &Style{Val: m.style(nrp.Style.Val)}
+	}
+	return &nrp
+}
+
+func (m *appendMapper) paragraph(p *Paragraph) {
+	var pp ParagraphProperties
+	if p.Properties != nil {
+		pp = *p.Properties
+	}
+	switch {
+	case pp.Style != nil:
+		pp.Style = &Style{Val: m.style(pp.Style.Val)}
+	case m.defaultPara != "":
+		pp.Style = &Style{Val: m.defaultPara}
+	}
+	if pp.NumProperties != nil && pp.NumProperties.NumID != nil {
+		np := *pp.NumProperties
+		np.NumID = &NumID{Val: m.num(np.NumID.Val)}
+		pp.NumProperties = &np
+	}
+	pp.RunProperties = m.runProperties(pp.RunProperties)
+	if p.Properties != nil || pp.Style != nil {
+		p.Properties = &pp
+	}
+	for _, c := range p.Children {
+		switch o := c.(type) {
+		case *Run:
+			o.RunProperties = m.runProperties(o.RunProperties)
+		case *Hyperlink:
+			o.Run.RunProperties = m.runProperties(o.Run.RunProperties)
+		}
+	}
+}
+
+func (m *appendMapper) table(t *Table) {
+	if t.TableProperties != nil && t.TableProperties.Style != nil {
+		ntp := *t.TableProperties
+		ntp.Style = &WTableStyle{Val: m.style(ntp.Style.Val)}
+		t.TableProperties = &ntp
+	}
+	for _, tr := range t.TableRows {
+		for _, tc := range tr.TableCells {
+			for _, p := range tc.Paragraphs {
+				m.paragraph(p)
+			}
+			for _, nt := range tc.Tables {
+				m.table(nt)
+			}
+		}
+	}
+}
@@ -0,0 +1,801 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// odtMimeType is the media type of OpenDocument Text
+	odtMimeType = "application/vnd.oasis.opendocument.text"
+
+	odtNamespaces = ` xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+		` xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+		` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"` +
+		` xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+		` xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"` +
+		` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"` +
+		` xmlns:xlink="http://www.w3.org/1999/xlink"` +
+		` xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0"` +
+		` office:version="1.3"`
+)
+
+// WriteODTTo writes f to w as an OpenDocument Text package.
+//
+// Paragraphs, headings, runs with their formatting, lists, tables
+// with merged cells, images and hyperlinks are converted, and the
+// page size and margins are taken from the body section.
+func (f *Docx) WriteODTTo(w io.Writer) error {
+	o := odtWriter{
+		f:          f,
+		autoNames:  make(map[string]string, 16),
+		autoCounts: make(map[string]int, 8),
+		listStyles: make(map[string]string, 4),
+		pictures:   make(map[string]*Media, 4),
+	}
+	o.blocks(f.Document.Body.Items)
+	if o.err != nil {
+		return o.err
+	}
+	zw := zip.NewWriter(w)
+	// mimetype must be the first and stored
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(fw, odtMimeType)
+	if err != nil {
+		return err
+	}
+	manifest := strings.Builder{}
+	manifest.WriteString(xml.Header + `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.3">` +
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.3" manifest:media-type="` + odtMimeType + `"/>` +
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+		`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>`)
+	files := []struct {
+		name string
+		data string
+	}{
+		{"content.xml", xml.Header + `<office:document-content` + odtNamespaces + `><office:automatic-styles>` +
+			o.auto.String() + o.lists.String() + `</office:automatic-styles><office:body><office:text>` +
+			o.body.String() + `</office:text></office:body></office:document-content>`},
+		{"styles.xml", f.odtStyles()},
+	}
+	for _, name := range o.pictureNames {
+		typ := mime.TypeByExtension(path.Ext(name))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		manifest.WriteString(`<manifest:file-entry manifest:full-path="Pictures/` + escapeAttr(name) +
+			`" manifest:media-type="` + typ + `"/>`)
+	}
+	manifest.WriteString(`</manifest:manifest>`)
+	files = append(files, struct {
+		name string
+		data string
+	}{"META-INF/manifest.xml", manifest.String()})
+	for _, file := range files {
+		fw, err = zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, file.data)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range o.pictureNames {
+		fw, err = zw.Create("Pictures/" + name)
+		if err != nil {
+			return err
+		}
+		data, err := o.pictures[name].Load()
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// odtStyles returns styles.xml with the common styles and the page layout of the body section
+func (f *Docx) odtStyles() string {
+	var sp *SectPr
+	for _, it := range f.Document.Body.Items {
+		if s, ok := it.(*SectPr); ok {
+			sp = s
+		}
+	}
+	page := ` fo:page-width="595.3pt" fo:page-height="841.9pt" fo:margin-top="72pt" fo:margin-bottom="72pt" fo:margin-left="90pt" fo:margin-right="90pt"`
+	if sp != nil && sp.PgSz != nil && sp.PgSz.W > 0 && sp.PgSz.H > 0 {
+		page = ` fo:page-width="` + twipsToPt(int64(sp.PgSz.W)) + `" fo:page-height="` + twipsToPt(int64(sp.PgSz.H)) + `"`
+		if m := sp.PgMar; m != nil {
+			page += ` fo:margin-top="` + twipsToPt(int64(m.Top)) + `" fo:margin-bottom="` + twipsToPt(int64(m.Bottom)) +
+				`" fo:margin-left="` + twipsToPt(int64(m.Left)) + `" fo:margin-right="` + twipsToPt(int64(m.Right)) + `"`
+		}
+	}
+	sb := strings.Builder{}
+	sb.WriteString(xml.Header + `<office:document-styles` + odtNamespaces + `><office:styles>` +
+		`<style:default-style style:family="paragraph"><style:text-properties fo:font-size="10.5pt"/></style:default-style>` +
+		`<style:style style:name="Standard" style:family="paragraph" style:class="text"/>` +
+		`<style:style style:name="Heading" style:family="paragraph" style:parent-style-name="Standard" style:class="text">` +
+		`<style:paragraph-properties fo:margin-top="12pt" fo:margin-bottom="6pt" fo:keep-with-next="always"/>` +
+		`<style:text-properties fo:font-weight="bold"/></style:style>`)
+	for i := 1; i <= 9; i++ {
+		lv := strconv.Itoa(i)
+		size := [...]string{"20pt", "16pt", "14pt", "13pt", "12pt", "11pt", "10.5pt", "10.5pt", "10.5pt"}[i-1]
+		sb.WriteString(`<style:style style:name="Heading_20_` + lv + `" style:display-name="Heading ` + lv +
+			`" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="` + lv +
+			`" style:class="text"><style:text-properties fo:font-size="` + size + `"/></style:style>`)
+	}
+	sb.WriteString(`</office:styles><office:automatic-styles><style:page-layout style:name="pm1">` +
+		`<style:page-layout-properties` + page + `/></style:page-layout></office:automatic-styles>` +
+		`<office:master-styles><style:master-page style:name="Standard" style:page-layout-name="pm1"/>` +
+		`</office:master-styles></office:document-styles>`)
+	return sb.String()
+}
+
+type odtWriter struct {
+	f   *Docx
+	err error
+
+	body  strings.Builder
+	auto  strings.Builder // auto is the automatic styles
+	lists strings.Builder // lists is the list styles
+
+	autoNames    map[string]string // autoNames is family, parent and properties -> style name
+	autoCounts   map[string]int    // autoCounts is the number of styles by name prefix
+	listStyles   map[string]string // listStyles is numId -> list style name
+	opened       []odtList         // opened is the stack of the opened lists
+	pictures     map[string]*Media
+	pictureNames []string
+	tables       int
+	images       int
+	space        bool // space is set at the start of paragraphs or after a white space
+}
+
+// odtList is an opened <text:list>
+type odtList struct {
+	numID  string
+	itemed bool // itemed is set if a <text:list-item> is opened in it
+}
+
+// autoStyle returns the name of the automatic style of family with props, adding it on need
+func (o *odtWriter) autoStyle(family, parent, props, prefix string) string {
+	key := family + "\x00" + parent + "\x00" + props
+	if name, ok := o.autoNames[key]; ok {
+		return name
+	}
+	o.autoCounts[prefix]++
+	name := prefix + strconv.Itoa(o.autoCounts[prefix])
+	o.autoNames[key] = name
+	o.auto.WriteString(`<style:style style:name="` + name + `" style:family="` + family + `"`)
+	if parent != "" {
+		o.auto.WriteString(` style:parent-style-name="` + parent + `"`)
+	}
+	o.auto.WriteString(">" + props + "</style:style>")
+	return name
+}
+
+// blocks writes the paragraphs and tables in items
+func (o *odtWriter) blocks(items []interface{}) {
+	for _, it := range items {
+		switch x := it.(type) {
+		case *Paragraph:
+			o.paragraph(x)
+		case *Table:
+			o.closeLists(0)
+			o.table(x)
+		}
+		if o.err != nil {
+			return
+		}
+	}
+	o.closeLists(0)
+}
+
+// closeLists closes the opened lists until n left
+func (o *odtWriter) closeLists(n int) {
+	for len(o.opened) > n {
+		if o.opened[len(o.opened)-1].itemed {
+			o.body.WriteString("</text:list-item>")
+		}
+		o.body.WriteString("</text:list>")
+		o.opened = o.opened[:len(o.opened)-1]
+	}
+}
+
+// item opens a <text:list-item> at ilvl of numID, opening and closing the lists on need
+func (o *odtWriter) item(numID string, ilvl int) {
+	if ilvl < 0 || ilvl > 8 {
+		ilvl = 0
+	}
+	o.closeLists(ilvl + 1)
+	if len(o.opened) == ilvl+1 && o.opened[ilvl].numID != numID {
+		o.closeLists(ilvl)
+	}
+	for len(o.opened) < ilvl+1 {
+		if n := len(o.opened); n > 0 && !o.opened[n-1].itemed {
+			o.body.WriteString("<text:list-item>")
+			o.opened[n-1].itemed = true
+		}
+		if len(o.opened) == 0 || o.opened[0].numID != numID {
+			name, ok := o.listStyles[numID]
+			if !ok {
+				name = o.listStyle(numID)
+			}
+			o.body.WriteString(`<text:list text:style-name="` + name + `"`)
+			if ok {
+				o.body.WriteString(` text:continue-numbering="true"`)
+			}
+			o.body.WriteString(">")
+		} else {
+			o.body.WriteString("<text:list>")
+		}
+		o.opened = append(o.opened, odtList{numID: numID})
+	}
+	l := &o.opened[ilvl]
+	if l.itemed {
+		o.body.WriteString("</text:list-item>")
+	}
+	l.itemed = true
+	o.body.WriteString("<text:list-item>")
+}
+
+// odtNumFormats maps numFmt into style:num-format
+var odtNumFormats = map[string]string{
+	"decimal": "1", "lowerLetter": "a", "upperLetter": "A", "lowerRoman": "i", "upperRoman": "I",
+}
+
+// listStyle adds the list style of the levels of numID
+func (o *odtWriter) listStyle(numID string) string {
+	name := "L" + strconv.Itoa(len(o.listStyles)+1)
+	o.listStyles[numID] = name
+	var num *Num
+	var a *AbstractNum
+	if o.f.hasNumbering() {
+		n, err := o.f.readNumbering()
+		if err == nil {
+			num = n.Num(numID)
+			if num != nil {
+				a = n.AbstractNum(num.AbstractNumID)
+			}
+		}
+	}
+	o.lists.WriteString(`<text:list-style style:name="` + name + `">`)
+	for i := 0; i < 9; i++ {
+		lv := strconv.Itoa(i + 1)
+		numFmt, text, start, left, hanging := "bullet", "•", 1, 720*(i+1), 360
+		if a != nil {
+			if f := a.NumFmt(i); f != "" {
+				numFmt = f
+			}
+			if t, ok := a.lvlText(i); ok {
+				text = t
+			}
+			start = a.start(i)
+			if s := num.startOverride(i); s > 0 {
+				start = s
+			}
+			if l, h := a.lvlInd(i); l > 0 {
+				left, hanging = l, h
+			}
+		}
+		props := `<style:list-level-properties text:list-level-position-and-space-mode="label-alignment">` +
+			`<style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="` +
+			twipsToPt(-int64(hanging)) + `" fo:margin-left="` + twipsToPt(int64(left)) + `"/></style:list-level-properties>`
+		if numFmt == "bullet" || numFmt == "none" {
+			char := []rune(bulletText(text))
+			if len(char) == 0 {
+				char = []rune{' '}
+			}
+			o.lists.WriteString(`<text:list-level-style-bullet text:level="` + lv + `" text:bullet-char="` +
+				escapeAttr(string(char[0])) + `">` + props + `</text:list-level-style-bullet>`)
+			continue
+		}
+		format, ok := odtNumFormats[numFmt]
+		if !ok {
+			format = "1"
+		}
+		prefix, suffix, levels := text, "", 0
+		if k := strings.IndexByte(text, '%'); k >= 0 {
+			prefix = text[:k]
+			last := strings.LastIndexByte(text, '%')
+			suffix = text[minInt(last+2, len(text)):]
+			levels = strings.Count(text, "%")
+		}
+		o.lists.WriteString(`<text:list-level-style-number text:level="` + lv + `" style:num-prefix="` +
+			escapeAttr(prefix) + `" style:num-suffix="` + escapeAttr(suffix) + `" style:num-format="` + format + `"`)
+		if levels > 1 {
+			o.lists.WriteString(` text:display-levels="` + strconv.Itoa(levels) + `"`)
+		}
+		if start != 1 {
+			o.lists.WriteString(` text:start-value="` + strconv.Itoa(start) + `"`)
+		}
+		o.lists.WriteString(">" + props + "</text:list-level-style-number>")
+	}
+	o.lists.WriteString(`</text:list-style>`)
+	return name
+}
+
+func (o *odtWriter) paragraph(p *Paragraph) {
+	lv := o.f.HeadingLevel(p)
+	numID, ilvl, _ := o.f.ListLevel(p)
+	if numID != "" && lv == 0 {
+		o.item(numID, ilvl)
+	} else {
+		o.closeLists(0)
+	}
+	content, boxes, brk := o.inline(p)
+	tag, parent := "text:p", "Standard"
+	if lv > 0 {
+		tag, parent = "text:h", "Heading_20_"+strconv.Itoa(lv)
+	}
+	name := parent
+	if props := paragraphODT(p.Properties, numID != "", brk); props != "" {
+		name = o.autoStyle("paragraph", parent, props, "P")
+	}
+	o.body.WriteString("<" + tag + ` text:style-name="` + name + `"`)
+	if lv > 0 {
+		o.body.WriteString(` text:outline-level="` + strconv.Itoa(lv) + `"`)
+	}
+	o.body.WriteString(">" + content + "</" + tag + ">")
+	for _, b := range boxes {
+		o.paragraph(b)
+	}
+}
+
+// paragraphODT converts the paragraph properties and the page break
+// before or after it into <style:paragraph-properties>
+func paragraphODT(pp *ParagraphProperties, list bool, brk string) string {
+	sb := strings.Builder{}
+	if brk != "" {
+		sb.WriteString(` fo:break-` + brk + `="page"`)
+	}
+	if pp != nil {
+		if pp.Justification != nil {
+			switch pp.Justification.Val {
+			case "left", "start":
+				sb.WriteString(` fo:text-align="start"`)
+			case "center":
+				sb.WriteString(` fo:text-align="center"`)
+			case "end", "right":
+				sb.WriteString(` fo:text-align="end"`)
+			case "both", "distribute":
+				sb.WriteString(` fo:text-align="justify"`)
+			}
+		}
+		if pp.Ind != nil && !list {
+			if pp.Ind.Left != 0 {
+				sb.WriteString(` fo:margin-left="` + twipsToPt(int64(pp.Ind.Left)) + `"`)
+			}
+			switch {
+			case pp.Ind.FirstLine != 0:
+				sb.WriteString(` fo:text-indent="` + twipsToPt(int64(pp.Ind.FirstLine)) + `"`)
+			case pp.Ind.Hanging != 0:
+				sb.WriteString(` fo:text-indent="` + twipsToPt(-int64(pp.Ind.Hanging)) + `"`)
+			}
+		}
+		if sp := pp.Spacing; sp != nil {
+			if sp.Before != 0 {
+				sb.WriteString(` fo:margin-top="` + twipsToPt(int64(sp.Before)) + `"`)
+			}
+			if sp.After != 0 {
+				sb.WriteString(` fo:margin-bottom="` + twipsToPt(int64(sp.After)) + `"`)
+			}
+			if sp.Line != 0 {
+				switch sp.LineRule {
+				case "", "auto":
+					sb.WriteString(` fo:line-height="` + strconv.Itoa(sp.Line*100/240) + `%"`)
+				case "atLeast":
+					sb.WriteString(` style:line-height-at-least="` + twipsToPt(int64(sp.Line)) + `"`)
+				default:
+					sb.WriteString(` fo:line-height="` + twipsToPt(int64(sp.Line)) + `"`)
+				}
+			}
+		}
+		if c := shadeColor(pp.Shade); c != "" {
+			sb.WriteString(` fo:background-color="` + c + `"`)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "<style:paragraph-properties" + sb.String() + "/>"
+}
+
+// runODT converts the run properties into <style:text-properties>
+func runODT(rp *RunProperties) string {
+	if rp == nil {
+		return ""
+	}
+	sb := strings.Builder{}
+	if rp.Fonts != nil {
+		if rp.Fonts.ASCII != "" {
+			sb.WriteString(` fo:font-family="` + escapeAttr("'"+rp.Fonts.ASCII+"'") + `"`)
+		}
+		if rp.Fonts.EastAsia != "" {
+			sb.WriteString(` style:font-family-asian="` + escapeAttr("'"+rp.Fonts.EastAsia+"'") + `"`)
+		}
+	}
+	if rp.Bold != nil && toggleOn(rp.Bold.Val) {
+		sb.WriteString(` fo:font-weight="bold" style:font-weight-asian="bold"`)
+	}
+	if rp.Italic != nil && toggleOn(rp.Italic.Val) {
+		sb.WriteString(` fo:font-style="italic" style:font-style-asian="italic"`)
+	}
+	if rp.Underline != nil && rp.Underline.Val != "none" {
+		sb.WriteString(` style:text-underline-style="solid" style:text-underline-width="auto" style:text-underline-color="font-color"`)
+	}
+	if rp.Strike != nil && toggleOn(rp.Strike.Val) {
+		sb.WriteString(` style:text-line-through-style="solid"`)
+	}
+	if rp.Color != nil {
+		if c := htmlColor(rp.Color.Val); c != "" {
+			sb.WriteString(` fo:color="` + c + `"`)
+		}
+	}
+	if rp.Size != nil {
+		if sz, err := strconv.Atoi(rp.Size.Val); err == nil {
+			pt := strconv.FormatFloat(float64(sz)/2, 'f', -1, 64) + "pt"
+			sb.WriteString(` fo:font-size="` + pt + `" style:font-size-asian="` + pt + `"`)
+		}
+	}
+	if c := highlightColor(rp.Highlight); c != "" {
+		if c[0] != '#' {
+			c = "#" + highlightColors[rp.Highlight.Val]
+		}
+		sb.WriteString(` fo:background-color="` + c + `"`)
+	} else if c := shadeColor(rp.Shade); c != "" {
+		sb.WriteString(` fo:background-color="` + c + `"`)
+	}
+	if rp.VertAlign != nil {
+		switch rp.VertAlign.Val {
+		case "superscript":
+			sb.WriteString(` style:text-position="super 58%"`)
+		case "subscript":
+			sb.WriteString(` style:text-position="sub 58%"`)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "<style:text-properties" + sb.String() + "/>"
+}
+
+// text writes s, keeping the repeated spaces, tabs and line breaks as elements
+func (o *odtWriter) text(sb *strings.Builder, s string) {
+	n := 0
+	flush := func() {
+		switch {
+		case n == 1:
+			sb.WriteString("<text:s/>")
+		case n > 1:
+			sb.WriteString(`<text:s text:c="` + strconv.Itoa(n) + `"/>`)
+		}
+		n = 0
+	}
+	for _, r := range s {
+		if r == ' ' {
+			if o.space {
+				n++
+			} else {
+				sb.WriteByte(' ')
+				o.space = true
+			}
+			continue
+		}
+		flush()
+		switch r {
+		case '\t':
+			sb.WriteString("<text:tab/>")
+			o.space = true
+		case '\n':
+			sb.WriteString("<text:line-break/>")
+			o.space = true
+		default:
+			sb.WriteString(escapeAttr(string(r)))
+			o.space = false
+		}
+	}
+	flush()
+}
+
+// span wraps s in a <text:span> of rp
+func (o *odtWriter) span(sb *strings.Builder, rp *RunProperties, s string) {
+	if props := runODT(rp); props != "" {
+		sb.WriteString(`<text:span text:style-name="` + o.autoStyle("text", "", props, "T") + `">` + s + "</text:span>")
+		return
+	}
+	sb.WriteString(s)
+}
+
+// inline renders the children of p, and returns the paragraphs of its text boxes
+// and where a page break is, before or after p
+func (o *odtWriter) inline(p *Paragraph) (content string, boxes []*Paragraph, brk string) {
+	sb := strings.Builder{}
+	o.space = true
+	for _, c := range p.Children {
+		switch x := c.(type) {
+		case *Hyperlink:
+			target, err := o.f.ReferTarget(x.ID)
+			if err != nil {
+				target = "#" + x.ID
+			}
+			rs := strings.Builder{}
+			o.text(&rs, PlainText(x))
+			sb.WriteString(`<text:a xlink:type="simple" xlink:href="` + escapeAttr(target) + `">`)
+			o.span(&sb, x.Run.RunProperties, rs.String())
+			sb.WriteString("</text:a>")
+		case *Run:
+			rs := strings.Builder{}
+			for _, y := range x.Children {
+				switch z := y.(type) {
+				case *Text:
+					o.text(&rs, z.Text)
+				case *Tab:
+					o.text(&rs, "\t")
+				case *BarterRabbet:
+					switch z.Type {
+					case "", "textWrapping":
+						o.text(&rs, "\n")
+					case "page":
+						if sb.Len() == 0 && rs.Len() == 0 {
+							brk = "before"
+						} else {
+							brk = "after"
+						}
+					}
+				case *Drawing:
+					for _, pic := range FindIn[*Picture](z) {
+						rs.WriteString(o.image(z, pic))
+					}
+					for _, sp := range FindIn[*WordprocessingShape](z) {
+						if sp.TextBox != nil && sp.TextBox.Content != nil {
+							for k := range sp.TextBox.Content.Paragraphs {
+								boxes = append(boxes, &sp.TextBox.Content.Paragraphs[k])
+							}
+						}
+					}
+				}
+			}
+			if rs.Len() > 0 {
+				o.span(&sb, x.RunProperties, rs.String())
+			}
+		}
+	}
+	return sb.String(), boxes, brk
+}
+
+// emuToPt formats EMU as points
+func emuToPt(emu int64) string {
+	return strconv.FormatFloat(float64(emu)/12700, 'f', 2, 64) + "pt"
+}
+
+// image returns the <draw:frame> of pic and adds its media into Pictures
+func (o *odtWriter) image(d *Drawing, pic *Picture) string {
+	if pic.BlipFill == nil || pic.BlipFill.Blip.Embed == "" {
+		return ""
+	}
+	target, err := o.f.ReferTarget(pic.BlipFill.Blip.Embed)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(target)
+	if _, ok := o.pictures[name]; !ok {
+		media := o.f.Media(name)
+		if media == nil {
+			return ""
+		}
+		o.pictures[name] = media
+		o.pictureNames = append(o.pictureNames, name)
+	}
+	o.images++
+	alt := "Image " + strconv.Itoa(o.images)
+	anchor := ` text:anchor-type="as-char"`
+	var ext *WPExtent
+	switch {
+	case d.Inline != nil:
+		ext = d.Inline.Extent
+		if d.Inline.DocPr != nil && d.Inline.DocPr.Name != "" {
+			alt = d.Inline.DocPr.Name
+		}
+	case d.Anchor != nil:
+		ext = d.Anchor.Extent
+		if d.Anchor.DocPr != nil && d.Anchor.DocPr.Name != "" {
+			alt = d.Anchor.DocPr.Name
+		}
+		anchor = ` text:anchor-type="paragraph"`
+		if d.Anchor.PositionH != nil {
+			anchor += ` svg:x="` + emuToPt(d.Anchor.PositionH.PosOffset) + `"`
+		}
+		if d.Anchor.PositionV != nil {
+			anchor += ` svg:y="` + emuToPt(d.Anchor.PositionV.PosOffset) + `"`
+		}
+	}
+	cx, cy := int64(0), int64(0)
+	if pic.SpPr != nil {
+		cx, cy = pic.SpPr.Xfrm.Ext.CX, pic.SpPr.Xfrm.Ext.CY
+	}
+	if (cx == 0 || cy == 0) && ext != nil {
+		cx, cy = ext.CX, ext.CY
+	}
+	size := ""
+	if cx > 0 && cy > 0 {
+		size = ` svg:width="` + emuToPt(cx) + `" svg:height="` + emuToPt(cy) + `"`
+	}
+	return `<draw:frame draw:name="` + escapeAttr(alt) + `"` + anchor + size + ` draw:z-index="0">` +
+		`<draw:image xlink:href="Pictures/` + escapeAttr(name) + `" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad"/>` +
+		`</draw:frame>`
+}
+
+func (o *odtWriter) table(t *Table) {
+	cols := 0
+	for _, r := range t.TableRows {
+		n := 0
+		for _, c := range r.TableCells {
+			n += gridSpan(c)
+		}
+		if n > cols {
+			cols = n
+		}
+	}
+	if cols == 0 {
+		return
+	}
+	o.tables++
+	name := "Table" + strconv.Itoa(o.tables)
+	var borders *WTableBorders
+	props := ""
+	if tp := t.TableProperties; tp != nil {
+		borders = tp.TableBorders
+		if tp.Width != nil && tp.Width.W > 0 {
+			switch tp.Width.Type {
+			case "pct":
+				props += ` style:rel-width="` + strconv.FormatInt(tp.Width.W/50, 10) + `%"`
+			case "", "dxa":
+				props += ` style:width="` + twipsToPt(tp.Width.W) + `"`
+			}
+		}
+		if tp.Justification != nil {
+			switch tp.Justification.Val {
+			case "center":
+				props += ` table:align="center"`
+			case "end", "right":
+				props += ` table:align="right"`
+			default:
+				props += ` table:align="left"`
+			}
+		}
+	}
+	o.body.WriteString(`<table:table table:name="` + name + `"`)
+	if props != "" {
+		o.body.WriteString(` table:style-name="` + o.autoStyle("table", "", "<style:table-properties"+props+"/>", "Ta") + `"`)
+	}
+	o.body.WriteString(">")
+	for i := 0; i < cols; i++ {
+		o.body.WriteString("<table:table-column")
+		if t.TableGrid != nil && i < len(t.TableGrid.GridCols) && t.TableGrid.GridCols[i].W > 0 {
+			o.body.WriteString(` table:style-name="` + o.autoStyle("table-column", "",
+				`<style:table-column-properties style:column-width="`+twipsToPt(t.TableGrid.GridCols[i].W)+`"/>`, "Co") + `"`)
+		}
+		o.body.WriteString("/>")
+	}
+	for i, r := range t.TableRows {
+		o.body.WriteString("<table:table-row>")
+		col := 0
+		for _, c := range r.TableCells {
+			span := gridSpan(c)
+			merged, restart := vMerge(c)
+			if merged && !restart {
+				o.body.WriteString(strings.Repeat("<table:covered-table-cell/>", span))
+				col += span
+				continue
+			}
+			rows := 1
+			if restart {
+				for _, nr := range t.TableRows[i+1:] {
+					nc := cellAt(nr, col)
+					if nc == nil {
+						break
+					}
+					if m, rs := vMerge(nc); !m || rs {
+						break
+					}
+					rows++
+				}
+			}
+			o.body.WriteString(`<table:table-cell table:style-name="` +
+				o.autoStyle("table-cell", "", cellODT(c, borders, i == 0, i+rows >= len(t.TableRows), col == 0, col+span >= cols), "Ce") +
+				`" office:value-type="string"`)
+			if span > 1 {
+				o.body.WriteString(` table:number-columns-spanned="` + strconv.Itoa(span) + `"`)
+			}
+			if rows > 1 {
+				o.body.WriteString(` table:number-rows-spanned="` + strconv.Itoa(rows) + `"`)
+			}
+			o.body.WriteString(">")
+			o.cell(c)
+			o.body.WriteString("</table:table-cell>")
+			o.body.WriteString(strings.Repeat("<table:covered-table-cell/>", span-1))
+			col += span
+		}
+		o.body.WriteString("</table:table-row>")
+	}
+	o.body.WriteString("</table:table>")
+}
+
+// cellODT converts the cell properties and the table borders into <style:table-cell-properties>
+func cellODT(c *WTableCell, tb *WTableBorders, top, bottom, left, right bool) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<style:table-cell-properties fo:padding-left="5.4pt" fo:padding-right="5.4pt"`)
+	var cb *WTableBorders
+	if tcp := c.TableCellProperties; tcp != nil {
+		cb = tcp.TableBorders
+		if col := shadeColor(tcp.Shade); col != "" {
+			sb.WriteString(` fo:background-color="` + col + `"`)
+		}
+		if tcp.VAlign != nil {
+			switch tcp.VAlign.Val {
+			case "center":
+				sb.WriteString(` style:vertical-align="middle"`)
+			case "bottom":
+				sb.WriteString(` style:vertical-align="bottom"`)
+			}
+		}
+	}
+	for i, b := range cellBorders(cb, tb, top, bottom, left, right) {
+		if v := borderCSS(b); v != "" {
+			sb.WriteString(` fo:border-` + cellSides[i] + `="` + v + `"`)
+		}
+	}
+	sb.WriteString("/>")
+	return sb.String()
+}
+
+// cell writes the paragraphs and tables in c
+func (o *odtWriter) cell(c *WTableCell) {
+	if len(c.Paragraphs) == 0 && len(c.Tables) == 0 {
+		o.body.WriteString(`<text:p text:style-name="Standard"/>`)
+		return
+	}
+	items := make([]interface{}, 0, len(c.Paragraphs)+len(c.Tables))
+	for _, p := range c.Paragraphs {
+		items = append(items, p)
+	}
+	for _, t := range c.Tables {
+		items = append(items, t)
+	}
+	opened := o.opened
+	o.opened = nil
+	o.blocks(items)
+	o.opened = opened
+}
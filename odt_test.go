@@ -0,0 +1,101 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestODT(t *testing.T) {
+	f, err := FromMarkdown([]byte("# Title\n\n- one\n- two\n  1. sub\n\nend  with **bold** and [link](https://example.com)"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := f.AddParagraph().Justification("center")
+	p.AddText("red").Color("FF0000").Size("28")
+	p.AddText(" under").Underline("single")
+	tbl := f.AddTable(2, 3, 0, nil)
+	c := tbl.TableRows[0].TableCells[0]
+	c.TableCellProperties.GridSpan = &WGridSpan{Val: 2}
+	tbl.TableRows[0].TableCells = tbl.TableRows[0].TableCells[:2]
+	c.AddParagraph().AddText("wide")
+	tbl.TableRows[0].TableCells[1].TableCellProperties.VMerge = &WvMerge{Val: "restart"}
+	tbl.TableRows[1].TableCells[2].TableCellProperties.VMerge = &WvMerge{}
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteODTTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Fatal("invalid mimetype entry")
+	}
+	rc, err := zr.Open("content.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	content := string(data)
+	for _, exp := range []string{
+		`<text:h text:style-name="Heading_20_1" text:outline-level="1">Title</text:h>`,
+		`<text:list text:style-name="L1"><text:list-item><text:p`,
+		`end <text:s/>with `,
+		`<text:a xlink:type="simple" xlink:href="https://example.com">link</text:a>`,
+		`table:number-columns-spanned="2"`, `table:number-rows-spanned="2"`, `<table:covered-table-cell/>`,
+		`<draw:image xlink:href="Pictures/image1.jpeg"`,
+	} {
+		if !strings.Contains(content, exp) {
+			t.Fatal("missing", exp, "in", content)
+		}
+	}
+
+	nf, err := FromODT(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := bytes.NewBuffer(nil)
+	err = nf.WriteMarkdownTo(md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"# Title\n\n", "- one\n- two\n    1. sub\n\n", "end  with **bold** and [link](https://example.com)",
+		"red under", "| wide |", "![", "](media/image1.jpeg)",
+	} {
+		if !strings.Contains(md.String(), exp) {
+			t.Fatal("missing", exp, "in", md.String())
+		}
+	}
+	var ps []*Paragraph
+	for _, it := range nf.Document.Body.Items {
+		if p, ok := it.(*Paragraph); ok && strings.Contains(PlainText(p), "red") {
+			ps = append(ps, p)
+		}
+	}
+	if len(ps) != 1 || ps[0].Properties.Justification.Val != "center" {
+		t.Fatal("unexpected paragraph", ps)
+	}
+	r := ps[0].Children[0].(*Run)
+	if r.RunProperties.Color.Val != "FF0000" || r.RunProperties.Size.Val != "28" {
+		t.Fatal("unexpected run", r.RunProperties)
+	}
+	for _, it := range nf.Document.Body.Items {
+		if tb, ok := it.(*Table); ok {
+			row := tb.TableRows[0]
+			if len(row.TableCells) != 2 || row.TableCells[0].TableCellProperties.GridSpan.Val != 2 ||
+				row.TableCells[1].TableCellProperties.VMerge.Val != "restart" {
+				t.Fatal("unexpected table")
+			}
+		}
+	}
+}
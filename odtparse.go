@@ -0,0 +1,414 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromODT creates a docx in the default theme from the OpenDocument Text in r
+func FromODT(r io.ReaderAt, size int64) (*Docx, error) {
+	f := New().WithDefaultTheme()
+	err := f.AddODT(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddODT appends the body of the OpenDocument Text in r to the body of f.
+//
+// Paragraphs, headings, spans with their formatting, lists, tables
+// with spanned cells, images in Pictures and hyperlinks are converted
+// in the same way as AddHTML, and the other elements are treated as
+// their contents.
+func (f *Docx) AddODT(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	read := func(name string) ([]byte, error) {
+		file, err := zr.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	o := odtReader{styles: make(map[string]*odtStyle, 32), lists: make(map[string][]odtLevel, 4)}
+	if data, err := read("styles.xml"); err == nil {
+		root, err := parseODTXML(data)
+		if err != nil {
+			return err
+		}
+		o.collect(root)
+	}
+	data, err := read("content.xml")
+	if err != nil {
+		return err
+	}
+	root, err := parseODTXML(data)
+	if err != nil {
+		return err
+	}
+	o.collect(root)
+	body := findODT(root, "office:text")
+	if body == nil {
+		return nil
+	}
+	b := htmlBuilder{
+		mdBuilder: mdBuilder{f: f, opt: &MarkdownImportOptions{ReadFile: read}, styles: make(map[string]string, 8)},
+		ctx:       &mdContext{ilvl: -1},
+	}
+	err = b.children(&htmlNode{tag: "div", children: o.convertAll(body.children, "", 0)}, htmlFormat{})
+	b.end()
+	return err
+}
+
+// odtPrefixes maps the namespaces of ODF into their usual prefixes
+var odtPrefixes = map[string]string{
+	"urn:oasis:names:tc:opendocument:xmlns:office:1.0":            "office",
+	"urn:oasis:names:tc:opendocument:xmlns:style:1.0":             "style",
+	"urn:oasis:names:tc:opendocument:xmlns:text:1.0":              "text",
+	"urn:oasis:names:tc:opendocument:xmlns:table:1.0":             "table",
+	"urn:oasis:names:tc:opendocument:xmlns:drawing:1.0":           "draw",
+	"urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0": "fo",
+	"urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0":    "svg",
+	"http://www.w3.org/1999/xlink":                                "xlink",
+}
+
+// odtName is the prefixed name of n
+func odtName(n xml.Name) string {
+	if p, ok := odtPrefixes[n.Space]; ok {
+		return p + ":" + n.Local
+	}
+	return n.Local
+}
+
+// parseODTXML parses an xml of ODF into a tree of htmlNode with prefixed tags
+func parseODTXML(data []byte) (*htmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	root := &htmlNode{tag: "#root"}
+	stack := []*htmlNode{root}
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch tt := t.(type) {
+		case xml.StartElement:
+			n := &htmlNode{tag: odtName(tt.Name), attr: make(map[string]string, len(tt.Attr))}
+			for _, a := range tt.Attr {
+				n.attr[odtName(a.Name)] = a.Value
+			}
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.children = append(top.children, &htmlNode{text: string(tt)})
+		}
+	}
+	return root, nil
+}
+
+// findODT finds the first element of tag in n
+func findODT(n *htmlNode, tag string) *htmlNode {
+	if n.tag == tag {
+		return n
+	}
+	for _, c := range n.children {
+		if x := findODT(c, tag); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+// odtStyle is a paragraph or text style of ODF
+type odtStyle struct {
+	parent  string
+	heading int               // heading is the default outline level
+	css     map[string]string // css is the converted properties
+}
+
+// odtLevel is a level of a list style
+type odtLevel struct {
+	ordered bool
+	start   int
+}
+
+type odtReader struct {
+	styles map[string]*odtStyle // styles is family:name -> style
+	lists  map[string][]odtLevel
+}
+
+// collect collects the styles and list styles in n
+func (o *odtReader) collect(n *htmlNode) {
+	switch n.tag {
+	case "style:style":
+		st := &odtStyle{parent: n.attr["style:parent-style-name"], css: make(map[string]string, 4)}
+		st.heading, _ = strconv.Atoi(n.attr["style:default-outline-level"])
+		if st.heading == 0 && strings.HasPrefix(n.attr["style:name"], "Heading_20_") {
+			st.heading, _ = strconv.Atoi(strings.TrimPrefix(n.attr["style:name"], "Heading_20_"))
+		}
+		for _, c := range n.children {
+			odtCSS(c, st.css)
+		}
+		o.styles[n.attr["style:family"]+":"+n.attr["style:name"]] = st
+		return
+	case "text:list-style":
+		levels := make([]odtLevel, 10)
+		for _, c := range n.children {
+			lv, err := strconv.Atoi(c.attr["text:level"])
+			if err != nil || lv < 1 || lv > len(levels) {
+				continue
+			}
+			if c.tag == "text:list-level-style-number" {
+				start, err := strconv.Atoi(c.attr["text:start-value"])
+				if err != nil {
+					start = 1
+				}
+				levels[lv-1] = odtLevel{ordered: c.attr["style:num-format"] != "", start: start}
+			}
+		}
+		o.lists[n.attr["style:name"]] = levels
+		return
+	}
+	for _, c := range n.children {
+		o.collect(c)
+	}
+}
+
+// odtCSS converts the properties in n into css
+func odtCSS(n *htmlNode, css map[string]string) {
+	for k, v := range n.attr {
+		switch k {
+		case "fo:font-weight", "fo:font-style", "fo:color", "fo:text-align":
+			if k == "fo:text-align" {
+				switch v {
+				case "start":
+					v = "left"
+				case "end":
+					v = "right"
+				}
+			}
+			css[k[3:]] = v
+		case "fo:font-size":
+			if !strings.HasSuffix(v, "%") {
+				css["font-size"] = v
+			}
+		case "fo:background-color":
+			if v != "transparent" {
+				css["background-color"] = v
+			}
+		case "style:font-name", "fo:font-family":
+			css["font-family"] = v
+		case "style:text-underline-style", "style:text-line-through-style":
+			key := "underline"
+			if k == "style:text-line-through-style" {
+				key = "line-through"
+			}
+			deco := strings.Fields(css["text-decoration"])
+			kept := deco[:0]
+			for _, d := range deco {
+				if d != key && d != "none" {
+					kept = append(kept, d)
+				}
+			}
+			if v != "none" {
+				kept = append(kept, key)
+			}
+			if len(kept) == 0 {
+				kept = append(kept, "none")
+			}
+			css["text-decoration"] = strings.Join(kept, " ")
+		case "style:text-position":
+			pos := strings.Fields(v)
+			if len(pos) == 0 {
+				break
+			}
+			switch {
+			case pos[0] == "super" || !strings.HasPrefix(pos[0], "-") && strings.Trim(pos[0], "0%.") != "":
+				css["vertical-align"] = "super"
+			case pos[0] == "sub" || strings.HasPrefix(pos[0], "-"):
+				css["vertical-align"] = "sub"
+			default:
+				css["vertical-align"] = "baseline"
+			}
+		}
+	}
+}
+
+// style resolves the css and the heading level of style name in family through its parents
+func (o *odtReader) style(family, name string) (string, int) {
+	var chain []*odtStyle
+	for i := 0; i < 16 && name != ""; i++ {
+		st, ok := o.styles[family+":"+name]
+		if !ok {
+			break
+		}
+		chain = append(chain, st)
+		name = st.parent
+	}
+	css := make(map[string]string, 8)
+	heading := 0
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].heading > 0 {
+			// the look of headings comes from the heading styles on import
+			heading = chain[i].heading
+			css = make(map[string]string, 8)
+			continue
+		}
+		for k, v := range chain[i].css {
+			css[k] = v
+		}
+	}
+	keys := make([]string, 0, len(css))
+	for k := range css {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb := strings.Builder{}
+	for _, k := range keys {
+		if k == "text-decoration" && css[k] == "none" {
+			continue
+		}
+		sb.WriteString(k + ":" + css[k] + ";")
+	}
+	return sb.String(), heading
+}
+
+// convertAll converts the nodes of ODF into the equivalent HTML nodes
+func (o *odtReader) convertAll(nodes []*htmlNode, list string, depth int) []*htmlNode {
+	var out []*htmlNode
+	for _, n := range nodes {
+		out = append(out, o.convert(n, list, depth)...)
+	}
+	return out
+}
+
+// convert converts n in the list style list at depth into HTML nodes
+func (o *odtReader) convert(n *htmlNode, list string, depth int) []*htmlNode {
+	el := func(tag string, attr map[string]string) []*htmlNode {
+		if attr == nil {
+			attr = make(map[string]string, 1)
+		}
+		return []*htmlNode{{tag: tag, attr: attr, children: o.convertAll(n.children, list, depth)}}
+	}
+	switch n.tag {
+	case "":
+		return []*htmlNode{n}
+	case "text:p", "text:h":
+		css, heading := o.style("paragraph", n.attr["text:style-name"])
+		if n.tag == "text:h" {
+			heading, _ = strconv.Atoi(n.attr["text:outline-level"])
+			if heading < 1 {
+				heading = 1
+			}
+		}
+		tag := "p"
+		if heading > 0 {
+			tag = "h" + strconv.Itoa(minInt(heading, 6))
+		}
+		return el(tag, map[string]string{"style": css})
+	case "text:span":
+		css, _ := o.style("text", n.attr["text:style-name"])
+		return el("span", map[string]string{"style": css})
+	case "text:a":
+		return el("a", map[string]string{"href": n.attr["xlink:href"]})
+	case "text:s":
+		c, err := strconv.Atoi(n.attr["text:c"])
+		if err != nil || c < 1 {
+			c = 1
+		}
+		return []*htmlNode{{text: strings.Repeat(" ", c), raw: true}}
+	case "text:tab":
+		return []*htmlNode{{text: "\t", raw: true}}
+	case "text:line-break":
+		return []*htmlNode{{tag: "br"}}
+	case "text:list":
+		if name := n.attr["text:style-name"]; name != "" {
+			list = name
+		}
+		lv := odtLevel{}
+		if levels := o.lists[list]; depth < len(levels) {
+			lv = levels[depth]
+		}
+		tag, attr := "ul", map[string]string{}
+		if lv.ordered {
+			tag = "ol"
+			attr["start"] = strconv.Itoa(lv.start)
+		}
+		return []*htmlNode{{tag: tag, attr: attr, children: o.convertAll(n.children, list, depth+1)}}
+	case "text:list-item", "text:list-header":
+		return el("li", nil)
+	case "table:table":
+		return el("table", nil)
+	case "table:table-header-rows", "table:table-rows", "table:table-row-group":
+		return el("tbody", nil)
+	case "table:table-row":
+		return el("tr", nil)
+	case "table:table-cell":
+		return el("td", map[string]string{
+			"colspan": n.attr["table:number-columns-spanned"],
+			"rowspan": n.attr["table:number-rows-spanned"],
+		})
+	case "draw:frame":
+		for _, c := range n.children {
+			switch c.tag {
+			case "draw:image":
+				style := ""
+				if w := n.attr["svg:width"]; w != "" {
+					style += "width:" + w + ";"
+				}
+				if h := n.attr["svg:height"]; h != "" {
+					style += "height:" + h + ";"
+				}
+				return []*htmlNode{{tag: "img", attr: map[string]string{
+					"src": c.attr["xlink:href"], "alt": n.attr["draw:name"], "style": style,
+				}}}
+			case "draw:text-box":
+				return []*htmlNode{{tag: "div", children: o.convertAll(c.children, list, depth)}}
+			}
+		}
+		return nil
+	case "table:covered-table-cell", "table:table-column", "table:table-columns", "text:note",
+		"office:annotation", "text:sequence-decls", "text:tracked-changes", "office:forms", "text:soft-page-break":
+		return nil
+	case "text:section", "text:index-body", "text:table-of-content", "text:alphabetical-index",
+		"text:illustration-index", "text:index-title":
+		return el("div", nil)
+	}
+	return el("span", nil)
+}
@@ -23,16 +23,259 @@ package docx
 import (
 	"archive/zip"
 	"bytes"
+	"compress/flate"
+	"encoding/binary"
 	"encoding/xml"
+	"hash/crc32"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
+// WriteOptions is the options of WriteToWithOptions
+type WriteOptions struct {
+	// Level is the deflate level from flate.BestSpeed to flate.BestCompression,
+	// or flate.DefaultCompression if 0
+	Level int
+	// Store writes all parts without compression
+	Store bool
+	// StoreMedia writes the media already compressed, like JPEG and PNG, without compression
+	StoreMedia bool
+	// Parallel is the number of media compressed at the same time, 1 if 0.
+	// At most Parallel compressed media are held in memory.
+	Parallel int
+}
+
+// storedMedia is the extensions of the media already compressed
+var storedMedia = map[string]bool{
+	".jpeg": true, ".jpg": true, ".png": true, ".gif": true, ".webp": true,
+}
+
+// method returns the zip method of the part name
+func (opt *WriteOptions) method(name string) uint16 {
+	if opt.Store || (opt.StoreMedia && strings.HasPrefix(name, MEDIA_FOLDER) &&
+		storedMedia[strings.ToLower(path.Ext(name))]) {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// packJob is a part to be written in order, with its media compressed ahead if data is not nil
+type packJob struct {
+	name string
+	r    io.Reader
+	hdr  *zip.FileHeader
+	data *bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
 // pack receives a zip file writer (word documents are a zip with multiple xml inside)
 // and writes the relevant files. Some of them come from the empty_constants file,
 // others from the actual in-memory structure
-func (f *Docx) pack(zipWriter *zip.Writer) (err error) {
-	files := make(map[string]io.Reader, 64)
+func (f *Docx) pack(zipWriter *zip.Writer, opt *WriteOptions) (err error) {
+	if opt == nil {
+		opt = &WriteOptions{}
+	}
+	files, err := f.packFiles(false)
+	if err != nil {
+		return
+	}
+	return f.packWrite(zipWriter, files, opt)
+}
+
+// level returns the deflate level of opt
+func (opt *WriteOptions) level() int {
+	if opt.Level == 0 {
+		return flate.DefaultCompression
+	}
+	return opt.Level
+}
+
+// packTime returns the modification time of the zip entries
+func (f *Docx) packTime() time.Time {
+	if f.fixedTime.IsZero() {
+		return zipEpoch
+	}
+	return f.fixedTime
+}
+
+// registerCompressor makes zipWriter deflate in the level of opt
+func registerCompressor(zipWriter *zip.Writer, opt *WriteOptions) {
+	level := opt.level()
+	zipWriter.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+// packWrite writes files into zipWriter in the order of packOrder
+func (f *Docx) packWrite(zipWriter *zip.Writer, files map[string]io.Reader, opt *WriteOptions) (err error) {
+	registerCompressor(zipWriter, opt)
+	level, modified := opt.level(), f.packTime()
+
+	// the media are compressed ahead by at most Parallel goroutines
+	parallel := opt.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	sem := make(chan struct{}, parallel)
+	jobs := make(chan *packJob, parallel)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		// stop sending jobs and wait for the media being compressed,
+		// so that none of the files is read after returning
+		close(quit)
+		for range jobs {
+		}
+		wg.Wait()
+	}()
+	go func() {
+		defer close(jobs)
+		for _, name := range packOrder(files) {
+			job := &packJob{name: name, r: files[name], hdr: &zip.FileHeader{
+				Name: name, Method: opt.method(name), Modified: modified,
+			}}
+			if parallel > 1 && job.hdr.Method == zip.Deflate && strings.HasPrefix(name, MEDIA_FOLDER) {
+				select {
+				case sem <- struct{}{}:
+				case <-quit:
+					return
+				}
+				job.data, job.done = bytes.NewBuffer(nil), make(chan struct{})
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					job.compress(level)
+				}()
+			}
+			select {
+			case jobs <- job:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	for job := range jobs {
+		if job.done == nil {
+			w, err := zipWriter.CreateHeader(job.hdr)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, job.r)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		<-job.done
+		if job.err != nil {
+			return job.err
+		}
+		rawHeader(job.hdr)
+		w, err := zipWriter.CreateRaw(job.hdr)
+		if err != nil {
+			return err
+		}
+		_, err = job.data.WriteTo(w)
+		if err != nil {
+			return err
+		}
+		job.data = nil
+		<-sem
+	}
+
+	return
+}
+
+// compress deflates the part of job into its data
+func (job *packJob) compress(level int) {
+	defer close(job.done)
+	fw, err := flate.NewWriter(job.data, level)
+	if err != nil {
+		job.err = err
+		return
+	}
+	h := crc32.NewIEEE()
+	n, err := io.Copy(io.MultiWriter(fw, h), job.r)
+	if err == nil {
+		err = fw.Close()
+	}
+	job.err = err
+	job.hdr.CRC32 = h.Sum32()
+	job.hdr.UncompressedSize64 = uint64(n)
+	job.hdr.CompressedSize64 = uint64(job.data.Len())
+}
+
+// rawHeader sets the fields of hdr that zip.Writer.CreateHeader would set
+// but CreateRaw leaves as they are, so that the parts compressed ahead
+// have the same headers as the others
+func rawHeader(hdr *zip.FileHeader) {
+	if utf8.ValidString(hdr.Name) && strings.IndexFunc(hdr.Name, func(r rune) bool {
+		return r < 0x20 || r > 0x7d || r == 0x5c
+	}) >= 0 {
+		hdr.Flags |= 0x800
+	}
+	hdr.Flags |= 0x8 // a data descriptor follows
+	hdr.CreatorVersion = hdr.CreatorVersion&0xff00 | 20
+	hdr.ReaderVersion = 20
+	if hdr.Modified.IsZero() {
+		return
+	}
+	// the same as zip.timeToMsDosTime, without converting to UTC as CreateHeader
+	t := hdr.Modified
+	hdr.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	hdr.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	// the extended timestamp of Info-ZIP
+	var ext [9]byte
+	binary.LittleEndian.PutUint16(ext[0:], 0x5455)
+	binary.LittleEndian.PutUint16(ext[2:], 5)
+	ext[4] = 1
+	binary.LittleEndian.PutUint32(ext[5:], uint32(t.Unix()))
+	hdr.Extra = append(hdr.Extra, ext[:]...)
+}
+
+// zipEpoch is the earliest time of zip entries, used if the time is not fixed
+var zipEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// packOrder returns the names of files in the order of packing,
+// [Content_Types].xml and _rels/.rels first as OPC recommends.
+// StreamWriter is the exception, which writes word/document.xml
+// before all of them.
+func packOrder(files map[string]io.Reader) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		switch name {
+		case "[Content_Types].xml":
+			return 0
+		case "_rels/.rels":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := rank(names[i]), rank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// packFiles collects the parts of the package by their names.
+// word/document.xml is left out if it is streamed.
+func (f *Docx) packFiles(streamed bool) (files map[string]io.Reader, err error) {
+	files = make(map[string]io.Reader, 64)
 
 	if f.template != "" {
 		for _, name := range f.tmpfslst {
@@ -50,25 +293,41 @@ func (f *Docx) pack(zipWriter *zip.Writer) (err error) {
 		}
 	}
 
-	files["word/_rels/document.xml.rels"] = marshaller{data: &f.docRelation}
-	files["word/document.xml"] = marshaller{data: &f.Document}
+	for name, data := range f.parts {
+		files[name] = bytes.NewReader(data)
+	}
 
-	for _, m := range f.media {
-		files[m.String()] = bytes.NewReader(m.Data)
+	if f.styles != nil {
+		files["word/styles.xml"] = marshaller{data: f.styles}
+	}
+	if f.numbering != nil {
+		files["word/numbering.xml"] = marshaller{data: f.numbering}
+		f.ensureNumberingRelation()
 	}
 
-	for path, r := range files {
-		w, err := zipWriter.Create(path)
-		if err != nil {
-			return err
-		}
+	err = f.packModelParts(files)
+	if err != nil {
+		return
+	}
 
-		_, err = io.Copy(w, r)
-		if err != nil {
-			return err
+	files["word/_rels/document.xml.rels"] = marshaller{data: &f.docRelation}
+	var written []string
+	if streamed {
+		written = append(written, "word/document.xml")
+	} else {
+		files["word/document.xml"] = marshaller{data: &f.Document}
+	}
+
+	for i := range f.media {
+		m := &f.media[i]
+		if m.Data == nil && m.src != nil {
+			files[m.String()] = &mediaReader{m: m}
+			continue
 		}
+		files[m.String()] = bytes.NewReader(m.Data)
 	}
 
+	err = f.packContentTypes(files, written...)
 	return
 }
 
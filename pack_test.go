@@ -0,0 +1,188 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestDeterministicPack(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 10, 0, time.UTC)
+	build := func() []byte {
+		f, err := FromMarkdown([]byte("# Title\n\n- one\n- two\n\n1. first\n\n| a | b |\n| - | - |\n| 1 | 2 |\n\n![x](testdata/fumiamayoko.png)"), &MarkdownImportOptions{BaseDir: "."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WithFixedTime(at)
+		p := f.AddParagraph()
+		p.AddText("commented")
+		r, err := p.Range(0, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := r.AddComment("someone", "check")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Date != "2024-05-06T07:08:10Z" {
+			t.Fatal("unexpected date", c.Date)
+		}
+		buf := bytes.NewBuffer(nil)
+		_, err = f.WriteTo(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	a, b := build(), build()
+	if !bytes.Equal(a, b) {
+		t.Fatal("output is not reproducible")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(a), int64(len(a)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Name != "[Content_Types].xml" || zr.File[1].Name != "_rels/.rels" {
+		t.Fatal("unexpected order", zr.File[0].Name, zr.File[1].Name)
+	}
+	for i, file := range zr.File {
+		if !file.Modified.Equal(at) {
+			t.Fatal("unexpected time", file.Modified)
+		}
+		if i > 1 && zr.File[i-1].Name > file.Name {
+			t.Fatal("unexpected order", zr.File[i-1].Name, file.Name)
+		}
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("options")
+	for _, name := range []string{"testdata/fumiamayoko.png", "testdata/fumiama.JPG", "testdata/fumiama2x.webp", "testdata/fumiamayoko.png"} {
+		_, err := f.AddParagraph().AddInlineDrawingFrom(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, opt := range []*WriteOptions{
+		{Parallel: 3, Level: flate.BestSpeed},
+		{StoreMedia: true},
+		{Store: true, Parallel: 2},
+	} {
+		buf := bytes.NewBuffer(nil)
+		err := f.WriteToWithOptions(buf, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range zr.File {
+			exp := uint16(zip.Deflate)
+			if opt.Store || (opt.StoreMedia && strings.HasPrefix(file.Name, MEDIA_FOLDER)) {
+				exp = zip.Store
+			}
+			if file.Method != exp {
+				t.Fatal("unexpected method of", file.Name, file.Method)
+			}
+		}
+		nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nf.media) != len(f.media) {
+			t.Fatal("unexpected media count", len(nf.media))
+		}
+		for _, m := range f.media {
+			if !bytes.Equal(nf.mediaData(m.Name), m.Data) {
+				t.Fatal("unexpected media", m.Name)
+			}
+		}
+	}
+	if f.WriteToWithOptions(bytes.NewBuffer(nil), &WriteOptions{Level: 42}) == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestParallelPackHeaders(t *testing.T) {
+	f := New().WithDefaultTheme().WithFixedTime(time.Date(2024, 5, 6, 7, 8, 10, 0, time.UTC))
+	for _, name := range []string{"testdata/fumiamayoko.png", "testdata/fumiama.JPG", "testdata/fumiama2x.webp"} {
+		_, err := f.AddParagraph().AddInlineDrawingFrom(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := *f.Media(f.media[0].Name)
+	m.Name = "图片.png"
+	f.addMedia(m)
+	write := func(opt *WriteOptions) []byte {
+		buf := bytes.NewBuffer(nil)
+		err := f.WriteToWithOptions(buf, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	serial, parallel := write(nil), write(&WriteOptions{Parallel: 4})
+	zs, err := zip.NewReader(bytes.NewReader(serial), int64(len(serial)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zp, err := zip.NewReader(bytes.NewReader(parallel), int64(len(parallel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zs.File) != len(zp.File) {
+		t.Fatal("unexpected entry count", len(zp.File))
+	}
+	for i, s := range zs.File {
+		p := zp.File[i]
+		if s.Name != p.Name || !s.Modified.Equal(p.Modified) || s.ModifiedDate != p.ModifiedDate || s.ModifiedTime != p.ModifiedTime ||
+			s.ReaderVersion != p.ReaderVersion || s.CreatorVersion != p.CreatorVersion || s.Flags != p.Flags || s.Method != p.Method {
+			t.Fatal("unexpected header of", p.Name, p.FileHeader)
+		}
+	}
+	if !bytes.Equal(serial, parallel) {
+		t.Fatal("parallel output differs from serial output")
+	}
+}
+
+// slowReader returns n bytes slowly and reports when all are read
+type slowReader struct {
+	n    int
+	done *int32
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		atomic.StoreInt32(r.done, 1)
+		return 0, io.EOF
+	}
+	time.Sleep(10 * time.Millisecond)
+	r.n--
+	p[0] = 'x'
+	return 1, nil
+}
+
+func TestPackWriteWaitsOnError(t *testing.T) {
+	var done int32
+	files := map[string]io.Reader{
+		"a.xml":                iotest.ErrReader(errors.New("broken")),
+		MEDIA_FOLDER + "x.png": &slowReader{n: 5, done: &done},
+	}
+	err := New().packWrite(zip.NewWriter(io.Discard), files, &WriteOptions{Parallel: 2})
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	if atomic.LoadInt32(&done) == 0 {
+		t.Fatal("media still read after returning")
+	}
+}
@@ -0,0 +1,184 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrLimitExceeded is matched by errors.Is for all LimitError
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// ParseOptions is the limits of ParseWithOptions on parsing
+// untrusted files. The zero value of a limit means unlimited.
+type ParseOptions struct {
+	// MaxEntries is the max number of entries in the zip
+	MaxEntries int
+	// MaxPartSize is the max uncompressed size of each part except media
+	MaxPartSize int64
+	// MaxMediaSize is the max uncompressed size of each media
+	MaxMediaSize int64
+	// MaxTotalSize is the max uncompressed size of all entries in the zip
+	MaxTotalSize int64
+	// MaxDepth is the max nesting depth of elements in each xml part,
+	// including the ones parsed later on demand like styles and headers
+	MaxDepth int
+}
+
+// LimitError is returned when a limit of ParseOptions is exceeded
+type LimitError struct {
+	Limit string // Limit is the field name of the limit in ParseOptions
+	Name  string // Name is the part exceeding the limit, empty for the whole package
+	Max   int64  // Max is the value of the limit
+}
+
+// Error implements error
+func (e *LimitError) Error() string {
+	s := "limit " + e.Limit + " of " + strconv.FormatInt(e.Max, 10) + " exceeded"
+	if e.Name != "" {
+		s += " by " + e.Name
+	}
+	return s
+}
+
+// Is makes errors.Is(err, ErrLimitExceeded) true
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ParseWithOptions parses the docx like Parse within the limits of opt,
+// returning *LimitError if any is exceeded. It should be used to parse
+// the files from untrusted sources, e.g. uploaded by users.
+//
+// The sizes declared in the zip are checked at once, and the parts are
+// also limited on reading, including the parts parsed (like styles and
+// headers) and the media loaded later on demand.
+func ParseWithOptions(reader io.ReaderAt, size int64, opt *ParseOptions) (*Docx, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+	return unpack(zipReader, opt)
+}
+
+// checkZip checks the entries declared in zipReader against opt
+func (opt *ParseOptions) checkZip(zipReader *zip.Reader) error {
+	if opt.MaxEntries > 0 && len(zipReader.File) > opt.MaxEntries {
+		return &LimitError{Limit: "MaxEntries", Max: int64(opt.MaxEntries)}
+	}
+	var total uint64
+	for _, f := range zipReader.File {
+		max, limit := opt.partLimit(f.Name)
+		if max > 0 && f.UncompressedSize64 > uint64(max) {
+			return &LimitError{Limit: limit, Name: f.Name, Max: max}
+		}
+		total += f.UncompressedSize64
+		if opt.MaxTotalSize > 0 && total > uint64(opt.MaxTotalSize) {
+			return &LimitError{Limit: "MaxTotalSize", Max: opt.MaxTotalSize}
+		}
+	}
+	return nil
+}
+
+// partLimit returns the size limit of the part name and its field name
+func (opt *ParseOptions) partLimit(name string) (int64, string) {
+	if strings.HasPrefix(name, MEDIA_FOLDER) {
+		return opt.MaxMediaSize, "MaxMediaSize"
+	}
+	return opt.MaxPartSize, "MaxPartSize"
+}
+
+// limitReader returns r limited to the size of the part name in opt
+func (opt *ParseOptions) limitReader(name string, r io.Reader) io.Reader {
+	if opt == nil {
+		return r
+	}
+	max, limit := opt.partLimit(name)
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: max, err: &LimitError{Limit: limit, Name: name, Max: max}}
+}
+
+// limitedReader reads at most n bytes from r and returns err on more
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+// Read implements io.Reader
+func (lr *limitedReader) Read(p []byte) (n int, err error) {
+	if lr.n < 0 {
+		return 0, lr.err
+	}
+	if int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+	n, err = lr.r.Read(p)
+	lr.n -= int64(n)
+	if lr.n < 0 {
+		return n + int(lr.n), lr.err
+	}
+	return
+}
+
+// newDecoder returns the decoder of r of the part name limited in the depth of opt
+func (opt *ParseOptions) newDecoder(name string, r io.Reader) *xml.Decoder {
+	if opt == nil || opt.MaxDepth <= 0 {
+		return xml.NewDecoder(r)
+	}
+	return xml.NewTokenDecoder(&depthReader{d: xml.NewDecoder(r), name: name, max: opt.MaxDepth})
+}
+
+// depthReader returns the raw tokens of d to be translated by
+// the outer decoder and fails on nesting deeper than max
+type depthReader struct {
+	d     *xml.Decoder
+	name  string
+	depth int
+	max   int
+	err   error
+}
+
+// Token implements xml.TokenReader
+func (dr *depthReader) Token() (xml.Token, error) {
+	if dr.err != nil {
+		return nil, dr.err
+	}
+	t, err := dr.d.RawToken()
+	switch t.(type) {
+	case xml.StartElement:
+		dr.depth++
+		if dr.depth > dr.max {
+			dr.err = &LimitError{Limit: "MaxDepth", Name: dr.name, Max: int64(dr.max)}
+			return nil, dr.err
+		}
+	case xml.EndElement:
+		dr.depth--
+	}
+	return t, err
+}
@@ -0,0 +1,85 @@
+package docx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseWithOptions(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("limited")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, size := bytes.NewReader(buf.Bytes()), int64(buf.Len())
+	nf, err := ParseWithOptions(r, size, &ParseOptions{
+		MaxEntries: 100, MaxPartSize: 1 << 20, MaxMediaSize: 1 << 20, MaxTotalSize: 1 << 22, MaxDepth: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nf.Document.Body.Items) != 2 || nf.Document.Body.Items[0].(*Paragraph).String() != "limited" {
+		t.Fatal("unexpected items", nf.Document.Body.Items)
+	}
+	if !bytes.Equal(nf.mediaData(f.media[0].Name), f.media[0].Data) {
+		t.Fatal("unexpected media")
+	}
+	for limit, opt := range map[string]*ParseOptions{
+		"MaxEntries":   {MaxEntries: 2},
+		"MaxPartSize":  {MaxPartSize: 16},
+		"MaxMediaSize": {MaxMediaSize: 16},
+		"MaxTotalSize": {MaxTotalSize: 1024},
+		"MaxDepth":     {MaxDepth: 3},
+	} {
+		_, err = ParseWithOptions(r, size, opt)
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Fatal("unexpected error of", limit, err)
+		}
+		var le *LimitError
+		if !errors.As(err, &le) || le.Limit != limit {
+			t.Fatal("unexpected limit", err)
+		}
+	}
+	opt := &ParseOptions{MaxPartSize: 4}
+	_, err = io.ReadAll(opt.limitReader("word/document.xml", strings.NewReader("12345")))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatal("unexpected error", err)
+	}
+	data, err := io.ReadAll(opt.limitReader("word/document.xml", strings.NewReader("1234")))
+	if err != nil || string(data) != "1234" {
+		t.Fatal("unexpected read", string(data), err)
+	}
+}
+
+func TestParseWithOptionsLazyParts(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("lazy")
+	deep := `<w:styles xmlns:w="` + XMLNS_W + `">` + strings.Repeat("<w:x>", 100) + strings.Repeat("</w:x>", 100) + `</w:styles>`
+	err := f.PutPart("word/styles.xml", []byte(deep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := ParseWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &ParseOptions{MaxDepth: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = nf.Styles()
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxDepth" || le.Name != "word/styles.xml" {
+		t.Fatal("unexpected error", err)
+	}
+}
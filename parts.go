@@ -0,0 +1,219 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"path"
+	"strings"
+)
+
+// modelPart is a part parsed into a model on demand. It is written
+// back on packing only if the model has been changed, so that the
+// elements not modelled are kept if nothing was edited.
+type modelPart struct {
+	name string
+	data interface{}
+	sum  [md5.Size]byte // sum is the md5 of the model marshalled right after parsing
+	ct   string         // ct is the content type to be registered if the part is new
+}
+
+// marshal the model with xml header
+func (m *modelPart) marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	_, err := marshaller{data: m.data}.WriteTo(buf)
+	return buf.Bytes(), err
+}
+
+// changed checks whether the model differs from the parsed one and
+// returns the marshalled data
+func (m *modelPart) changed() (bool, []byte, error) {
+	data, err := m.marshal()
+	if err != nil {
+		return false, nil, err
+	}
+	return md5.Sum(data) != m.sum, data, nil
+}
+
+// loadModelPart parses the part name into data
+func (f *Docx) loadModelPart(name string, data interface{}) (*modelPart, error) {
+	m := &modelPart{name: name}
+	err := f.parseModelPart(m, data)
+	if err != nil {
+		return nil, err
+	}
+	f.modelParts = append(f.modelParts, m)
+	return m, nil
+}
+
+// parseModelPart parses the part of m into data as the model of m
+func (f *Docx) parseModelPart(m *modelPart, data interface{}) error {
+	err := f.decodeTemplate(m.name, data)
+	if err != nil {
+		return err
+	}
+	m.data = data
+	d, err := m.marshal()
+	if err != nil {
+		return err
+	}
+	m.sum = md5.Sum(d)
+	return nil
+}
+
+// newModelPart adds a part not existing in the template,
+// which will always be written on packing.
+func (f *Docx) newModelPart(name string, data interface{}, contenttype string) *modelPart {
+	m := &modelPart{name: name, data: data, ct: contenttype}
+	f.modelParts = append(f.modelParts, m)
+	return m
+}
+
+// packModelParts puts the changed model parts into files
+func (f *Docx) packModelParts(files map[string]io.Reader) error {
+	for _, m := range f.modelParts {
+		changed, data, err := m.changed()
+		if err != nil {
+			return err
+		}
+		if emptyRelationships(m) && !f.hasTemplateFile(m.name) {
+			continue
+		}
+		if changed || !f.hasTemplateFile(m.name) {
+			files[m.name] = bytes.NewReader(data)
+		}
+	}
+	return nil
+}
+
+// partTarget resolves the target of a relationship of the part source
+// into a part name as OPC does: a target starting with / is from the
+// package root, and others are relative to the directory of source.
+func partTarget(source, target string) string {
+	if !strings.HasPrefix(target, "/") {
+		target = path.Join("/", path.Dir(source), target)
+	}
+	return path.Clean(target)[1:]
+}
+
+// Headers parses the headers referred by word/_rels/document.xml.rels on first call
+func (f *Docx) Headers() ([]*Header, error) {
+	if f.headers != nil {
+		return f.headers, nil
+	}
+	headers := make([]*Header, 0, 4)
+	for _, r := range f.docRelation.Relationship {
+		if r.Type != REL_HEADER {
+			continue
+		}
+		h := &Header{id: r.ID, name: partTarget("word/document.xml", r.Target), file: f}
+		h.setNamespaces()
+		_, err := f.loadModelPart(h.name, h)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+	f.headers = headers
+	return headers, nil
+}
+
+// Footers parses the footers referred by word/_rels/document.xml.rels on first call
+func (f *Docx) Footers() ([]*Footer, error) {
+	if f.footers != nil {
+		return f.footers, nil
+	}
+	footers := make([]*Footer, 0, 4)
+	for _, r := range f.docRelation.Relationship {
+		if r.Type != REL_FOOTER {
+			continue
+		}
+		h := &Footer{id: r.ID, name: partTarget("word/document.xml", r.Target), file: f}
+		h.setNamespaces()
+		_, err := f.loadModelPart(h.name, h)
+		if err != nil {
+			return nil, err
+		}
+		footers = append(footers, h)
+	}
+	f.footers = footers
+	return footers, nil
+}
+
+// Footnotes parses word/footnotes.xml on first call,
+// or returns nil if the document has no footnotes.
+func (f *Docx) Footnotes() (*Footnotes, error) {
+	if f.footnotes != nil {
+		return f.footnotes, nil
+	}
+	for _, r := range f.docRelation.Relationship {
+		if r.Type != REL_FOOTNOTES {
+			continue
+		}
+		fn := &Footnotes{name: partTarget("word/document.xml", r.Target), file: f}
+		fn.setNamespaces()
+		_, err := f.loadModelPart(fn.name, fn)
+		if err != nil {
+			return nil, err
+		}
+		f.footnotes = fn
+		return fn, nil
+	}
+	return nil, nil
+}
+
+// Comments parses word/comments.xml on first call,
+// or returns nil if the document has no comments.
+func (f *Docx) Comments() (*Comments, error) {
+	if f.comments != nil {
+		return f.comments, nil
+	}
+	for _, r := range f.docRelation.Relationship {
+		if r.Type != REL_COMMENTS {
+			continue
+		}
+		cs := &Comments{name: partTarget("word/document.xml", r.Target), file: f}
+		cs.setNamespaces()
+		_, err := f.loadModelPart(cs.name, cs)
+		if err != nil {
+			return nil, err
+		}
+		f.comments = cs
+		return cs, nil
+	}
+	return nil, nil
+}
+
+// ensureComments returns the comments, creating word/comments.xml if missing
+func (f *Docx) ensureComments() (*Comments, error) {
+	cs, err := f.Comments()
+	if err != nil || cs != nil {
+		return cs, err
+	}
+	cs = &Comments{name: "word/comments.xml", file: f}
+	cs.setNamespaces()
+	f.addRelation(REL_COMMENTS, "comments.xml")
+	f.newModelPart(cs.name, cs, "application/vnd.openxmlformats-officedocument.wordprocessingml.comments+xml")
+	f.comments = cs
+	return cs, nil
+}
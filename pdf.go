@@ -0,0 +1,418 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"  // decode gif media
+	_ "image/jpeg" // decode jpeg media
+	_ "image/png"  // decode png media
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+
+	_ "golang.org/x/image/bmp"  // decode bmp media
+	_ "golang.org/x/image/tiff" // decode tiff media
+	_ "golang.org/x/image/webp" // decode webp media
+)
+
+// WritePDFTo renders f into a PDF document and writes it to w.
+//
+// The body is paginated by the page sizes and margins of its sections,
+// with the default headers and footers. Texts are embedded in subsets
+// of the fonts in opt, falling back to the Go fonts, and the images that
+// cannot be decoded are skipped.
+func (f *Docx) WritePDFTo(w io.Writer, opt *RenderOptions) error {
+	pages, err := f.layoutDocument(opt)
+	if err != nil {
+		return err
+	}
+	pw := &pdfWriter{
+		fonts:  make(map[*renderFont]string, 4),
+		images: make(map[*Media]string, 4),
+	}
+	pw.alloc() // 1 is the catalog
+	pw.alloc() // 2 is the page tree
+	pw.alloc() // 3 is the resources
+	kids := make([]string, 0, len(pages))
+	for _, p := range pages {
+		kids = append(kids, strconv.Itoa(pw.page(p))+" 0 R")
+	}
+	pw.set(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pw.set(2, "<< /Type /Pages /Kids ["+strings.Join(kids, " ")+"] /Count "+strconv.Itoa(len(pages))+" >>")
+	res, err := pw.resources()
+	if err != nil {
+		return err
+	}
+	pw.set(3, res)
+	return pw.writeTo(w)
+}
+
+// pdfWriter collects the objects of a PDF document
+type pdfWriter struct {
+	objs   [][]byte // objs are the objects numbered from 1
+	fonts  map[*renderFont]string
+	images map[*Media]string
+	order  []*renderFont // order is the fonts by their resource names
+	media  []*Media      // media is the images by their resource names
+}
+
+// alloc reserves an object number
+func (pw *pdfWriter) alloc() int {
+	pw.objs = append(pw.objs, nil)
+	return len(pw.objs)
+}
+
+// set sets the object n
+func (pw *pdfWriter) set(n int, obj string) {
+	pw.objs[n-1] = []byte(obj)
+}
+
+// add adds an object and returns its number
+func (pw *pdfWriter) add(obj string) int {
+	n := pw.alloc()
+	pw.set(n, obj)
+	return n
+}
+
+// stream adds a Flate compressed stream with the other entries of dict
+func (pw *pdfWriter) stream(dict string, data []byte) int {
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)/2+64))
+	zw := zlib.NewWriter(buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	n := pw.alloc()
+	obj := bytes.NewBuffer(make([]byte, 0, buf.Len()+128))
+	obj.WriteString("<< ")
+	if dict != "" {
+		obj.WriteString(dict)
+		obj.WriteByte(' ')
+	}
+	obj.WriteString("/Filter /FlateDecode /Length " + strconv.Itoa(buf.Len()) + " >>\nstream\n")
+	obj.Write(buf.Bytes())
+	obj.WriteString("\nendstream")
+	pw.objs[n-1] = obj.Bytes()
+	return n
+}
+
+// pdfNum formats v with at most 3 decimals
+func pdfNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfColor formats c into the operands of rg and RG
+func pdfColor(c color.RGBA) string {
+	return pdfNum(float64(c.R)/255) + " " + pdfNum(float64(c.G)/255) + " " + pdfNum(float64(c.B)/255)
+}
+
+// pdfString escapes s into a literal string
+func pdfString(s string) string {
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`).Replace(s) + ")"
+}
+
+// page adds the objects of p and returns the number of its page object
+func (pw *pdfWriter) page(p *renderPage) int {
+	h := p.height
+	sb := strings.Builder{}
+	for _, it := range p.items {
+		switch o := it.(type) {
+		case *renderRect:
+			sb.WriteString(pdfColor(o.fill) + " rg " + pdfNum(o.x) + " " + pdfNum(h-o.y-o.h) + " " +
+				pdfNum(o.w) + " " + pdfNum(o.h) + " re f\n")
+		case *renderLine:
+			dash := "[] 0 d"
+			if o.dash {
+				dash = "[" + pdfNum(o.width*3) + " " + pdfNum(o.width*2) + "] 0 d"
+			}
+			sb.WriteString(pdfNum(o.width) + " w " + dash + " " + pdfColor(o.color) + " RG " +
+				pdfNum(o.x1) + " " + pdfNum(h-o.y1) + " m " + pdfNum(o.x2) + " " + pdfNum(h-o.y2) + " l S\n")
+		case *renderText:
+			if len(o.glyphs) == 0 {
+				continue
+			}
+			sb.WriteString("BT /" + pw.font(o.font) + " " + pdfNum(o.size) + " Tf " + pdfColor(o.color) + " rg ")
+			if o.fakeBold {
+				sb.WriteString("2 Tr " + pdfNum(o.size/30) + " w " + pdfColor(o.color) + " RG ")
+			} else {
+				sb.WriteString("0 Tr ")
+			}
+			sb.WriteString("1 0 0 1 " + pdfNum(o.x) + " " + pdfNum(h-o.y) + " Tm <")
+			for _, g := range o.glyphs {
+				fmt.Fprintf(&sb, "%04X", uint16(g))
+			}
+			sb.WriteString("> Tj ET\n")
+		case *renderImage:
+			name := pw.image(o.media)
+			if name == "" {
+				continue
+			}
+			sb.WriteString("q " + pdfNum(o.w) + " 0 0 " + pdfNum(o.h) + " " + pdfNum(o.x) + " " +
+				pdfNum(h-o.y-o.h) + " cm /" + name + " Do Q\n")
+		}
+	}
+	content := pw.stream("", []byte(sb.String()))
+	var annots []string
+	for _, lk := range p.links {
+		if lk.uri == "" || strings.HasPrefix(lk.uri, "#") {
+			continue
+		}
+		n := pw.add("<< /Type /Annot /Subtype /Link /Rect [" + pdfNum(lk.x) + " " + pdfNum(h-lk.y-lk.h) + " " +
+			pdfNum(lk.x+lk.w) + " " + pdfNum(h-lk.y) + "] /Border [0 0 0] /A << /S /URI /URI " + pdfString(lk.uri) + " >> >>")
+		annots = append(annots, strconv.Itoa(n)+" 0 R")
+	}
+	obj := "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 " + pdfNum(p.width) + " " + pdfNum(h) +
+		"] /Resources 3 0 R /Contents " + strconv.Itoa(content) + " 0 R"
+	if len(annots) > 0 {
+		obj += " /Annots [" + strings.Join(annots, " ") + "]"
+	}
+	return pw.add(obj + " >>")
+}
+
+// font returns the resource name of rf
+func (pw *pdfWriter) font(rf *renderFont) string {
+	if name, ok := pw.fonts[rf]; ok {
+		return name
+	}
+	pw.order = append(pw.order, rf)
+	name := "F" + strconv.Itoa(len(pw.order))
+	pw.fonts[rf] = name
+	return name
+}
+
+// image returns the resource name of m, or "" if it cannot be decoded
+func (pw *pdfWriter) image(m *Media) string {
+	if name, ok := pw.images[m]; ok {
+		return name
+	}
+	name := ""
+	data, err := m.Load()
+	if err == nil {
+		_, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err == nil {
+		pw.media = append(pw.media, m)
+		name = "Im" + strconv.Itoa(len(pw.media))
+	}
+	pw.images[m] = name
+	return name
+}
+
+// resources adds the fonts and images and returns the resource dictionary
+func (pw *pdfWriter) resources() (string, error) {
+	sb := strings.Builder{}
+	sb.WriteString("<< /ProcSet [/PDF /Text /ImageB /ImageC]")
+	if len(pw.order) > 0 {
+		sb.WriteString(" /Font <<")
+		for i, rf := range pw.order {
+			n, err := pw.embedFont(rf)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(" /F" + strconv.Itoa(i+1) + " " + strconv.Itoa(n) + " 0 R")
+		}
+		sb.WriteString(" >>")
+	}
+	if len(pw.media) > 0 {
+		sb.WriteString(" /XObject <<")
+		for i, m := range pw.media {
+			sb.WriteString(" /Im" + strconv.Itoa(i+1) + " " + strconv.Itoa(pw.embedImage(m)) + " 0 R")
+		}
+		sb.WriteString(" >>")
+	}
+	sb.WriteString(" >>")
+	return sb.String(), nil
+}
+
+// subsetTag is the 6 letters prefixing the name of a subset font
+func subsetTag(rf *renderFont, gids []sfnt.GlyphIndex) string {
+	h := fnv.New32a()
+	h.Write([]byte(rf.name))
+	for _, g := range gids {
+		h.Write([]byte{byte(g >> 8), byte(g)})
+	}
+	v := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = byte('A' + v%26)
+		v /= 26
+	}
+	return string(tag)
+}
+
+// embedFont adds rf as a Type0 font of its used glyphs
+func (pw *pdfWriter) embedFont(rf *renderFont) (int, error) {
+	gids := make([]sfnt.GlyphIndex, 0, len(rf.used))
+	for g := range rf.used {
+		gids = append(gids, g)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	name := subsetTag(rf, gids) + "+" + rf.name
+
+	data, err := rf.subset()
+	if err != nil {
+		return 0, err
+	}
+	file, sub := "/FontFile2", "/CIDFontType2"
+	fileDict := "/Length1 " + strconv.Itoa(len(data))
+	if rf.cff {
+		file, sub, fileDict = "/FontFile3", "/CIDFontType0", "/Subtype /OpenType"
+	}
+	fontFile := pw.stream(fileDict, data)
+	descriptor := pw.add("<< /Type /FontDescriptor /FontName /" + name + " /Flags 4 /FontBBox [0 " +
+		pdfNum(-rf.descent*1000) + " 1000 " + pdfNum(rf.ascent*1000) + "] /ItalicAngle 0 /Ascent " +
+		pdfNum(rf.ascent*1000) + " /Descent " + pdfNum(-rf.descent*1000) + " /CapHeight " +
+		pdfNum(rf.ascent*1000) + " /StemV 80 " + file + " " + strconv.Itoa(fontFile) + " 0 R >>")
+
+	w := strings.Builder{}
+	for _, g := range gids {
+		w.WriteString(strconv.Itoa(int(g)) + " [" + pdfNum(rf.advance(g)*1000) + "] ")
+	}
+	cid := "<< /Type /Font /Subtype " + sub + " /BaseFont /" + name +
+		" /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor " +
+		strconv.Itoa(descriptor) + " 0 R /DW 0 /W [" + strings.TrimSpace(w.String()) + "]"
+	if !rf.cff {
+		cid += " /CIDToGIDMap /Identity"
+	}
+	cidFont := pw.add(cid + " >>")
+	toUnicode := pw.stream("", toUnicodeCMap(rf, gids))
+	return pw.add("<< /Type /Font /Subtype /Type0 /BaseFont /" + name + " /Encoding /Identity-H /DescendantFonts [" +
+		strconv.Itoa(cidFont) + " 0 R] /ToUnicode " + strconv.Itoa(toUnicode) + " 0 R >>"), nil
+}
+
+// toUnicodeCMap maps the glyphs back to their runes
+func toUnicodeCMap(rf *renderFont, gids []sfnt.GlyphIndex) []byte {
+	sb := strings.Builder{}
+	sb.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		chunk := gids[i:minInt(i+100, len(gids))]
+		sb.WriteString(strconv.Itoa(len(chunk)) + " beginbfchar\n")
+		for _, g := range chunk {
+			fmt.Fprintf(&sb, "<%04X> <", uint16(g))
+			for _, u := range utf16Units(rf.used[g]) {
+				fmt.Fprintf(&sb, "%04X", u)
+			}
+			sb.WriteString(">\n")
+		}
+		sb.WriteString("endbfchar\n")
+	}
+	sb.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(sb.String())
+}
+
+// utf16Units encodes r in UTF-16
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + r>>10), uint16(0xDC00 + r&0x3FF)}
+}
+
+// embedImage adds m as an image XObject, the JPEGs are kept as they are
+func (pw *pdfWriter) embedImage(m *Media) int {
+	data, _ := m.Load()
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil && format == "jpeg" {
+		cs, decode := "/DeviceRGB", ""
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			cs = "/DeviceGray"
+		case color.CMYKModel:
+			cs, decode = "/DeviceCMYK", " /Decode [1 0 1 0 1 0 1 0]"
+		}
+		n := pw.alloc()
+		pw.set(n, "<< /Type /XObject /Subtype /Image /Width "+strconv.Itoa(cfg.Width)+" /Height "+
+			strconv.Itoa(cfg.Height)+" /ColorSpace "+cs+" /BitsPerComponent 8"+decode+
+			" /Filter /DCTDecode /Length "+strconv.Itoa(len(data))+" >>\nstream\n")
+		pw.objs[n-1] = append(append(pw.objs[n-1], data...), "\nendstream"...)
+		return n
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// keep the resource valid with a blank pixel
+		return pw.stream("/Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", []byte{255})
+	}
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	alpha := make([]byte, 0, b.Dx()*b.Dy())
+	opaque := true
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		rgb = append(rgb, rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2])
+		alpha = append(alpha, rgba.Pix[i+3])
+		if rgba.Pix[i+3] != 255 {
+			opaque = false
+		}
+	}
+	size := "/Width " + strconv.Itoa(b.Dx()) + " /Height " + strconv.Itoa(b.Dy()) + " /BitsPerComponent 8"
+	dict := "/Type /XObject /Subtype /Image " + size + " /ColorSpace /DeviceRGB"
+	if !opaque {
+		mask := pw.stream("/Type /XObject /Subtype /Image "+size+" /ColorSpace /DeviceGray", alpha)
+		dict += " /SMask " + strconv.Itoa(mask) + " 0 R"
+	}
+	return pw.stream(dict, rgb)
+}
+
+// writeTo writes the document with its cross-reference table
+func (pw *pdfWriter) writeTo(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	offset := 0
+	write := func(s string) {
+		n, _ := bw.WriteString(s)
+		offset += n
+	}
+	write("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(pw.objs))
+	for i, obj := range pw.objs {
+		offsets[i] = offset
+		write(strconv.Itoa(i+1) + " 0 obj\n")
+		n, _ := bw.Write(obj)
+		offset += n
+		write("\nendobj\n")
+	}
+	xref := offset
+	write("xref\n0 " + strconv.Itoa(len(pw.objs)+1) + "\n0000000000 65535 f \n")
+	for _, o := range offsets {
+		write(fmt.Sprintf("%010d 00000 n \n", o))
+	}
+	write("trailer\n<< /Size " + strconv.Itoa(len(pw.objs)+1) + " /Root 1 0 R >>\nstartxref\n" +
+		strconv.Itoa(xref) + "\n%%EOF\n")
+	return bw.Flush()
+}
@@ -0,0 +1,65 @@
+package docx
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDFTo(t *testing.T) {
+	f := New().WithDefaultTheme().WithA4Page()
+	f.AddParagraph().Style("Heading1").AddText("Title")
+	for i := 0; i < 80; i++ {
+		p := f.AddParagraph().Justification("both")
+		p.AddText("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor. ")
+		p.AddText("中文段落的换行。").Bold()
+	}
+	p := f.AddParagraph()
+	p.AddLink("link", "https://example.com")
+	tbl := f.AddTable(2, 2, 0, nil)
+	tbl.TableRows[0].TableCells[0].AddParagraph().AddText("cell")
+	tbl.TableRows[1].TableCells[1].Shade("clear", "auto", "EEEEEE")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := f.layoutDocument(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) < 3 {
+		t.Fatal("unexpected pages", len(pages))
+	}
+	for _, pg := range pages {
+		if pg.width < 595 || pg.width > 596 {
+			t.Fatal("unexpected page width", pg.width)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.WritePDFTo(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if !strings.HasPrefix(s, "%PDF-1.7\n") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatal("invalid pdf")
+	}
+	if strings.Count(s, "/Type /Page ") != len(pages) {
+		t.Fatal("unexpected page objects", strings.Count(s, "/Type /Page "))
+	}
+	for _, exp := range []string{
+		"/Subtype /Type0", "/FontFile2", "/ToUnicode", "/Filter /DCTDecode", "/ColorSpace /DeviceRGB /Filter /FlateDecode",
+		"/URI (https://example.com)", "/Count " + strconv.Itoa(len(pages)),
+	} {
+		if !strings.Contains(s, exp) {
+			t.Fatal("missing", exp)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Predicate matches a node in the document tree
+type Predicate func(node interface{}) bool
+
+// Find returns the nodes in body, headers, footers, footnotes and comments
+// matching all the preds, in document order. The results are live
+// pointers, so changing them changes the document.
+func (f *Docx) Find(preds ...Predicate) ([]interface{}, error) {
+	var nodes []interface{}
+	err := Walk(f, finder(preds, func(n interface{}) {
+		nodes = append(nodes, n)
+	}))
+	return nodes, err
+}
+
+// FindAll returns the nodes of type T in body, headers, footers,
+// footnotes and comments matching all the preds, in document order.
+//
+//	drawings, err := docx.FindAll[*docx.Drawing](doc)
+func FindAll[T any](f *Docx, preds ...Predicate) ([]T, error) {
+	var nodes []T
+	err := Walk(f, finder(preds, func(n interface{}) {
+		if v, ok := n.(T); ok {
+			nodes = append(nodes, v)
+		}
+	}))
+	return nodes, err
+}
+
+// FindIn returns the nodes of type T under node (inclusive)
+// matching all the preds, in document order.
+func FindIn[T any](node interface{}, preds ...Predicate) []T {
+	var nodes []T
+	WalkNode(node, finder(preds, func(n interface{}) {
+		if v, ok := n.(T); ok {
+			nodes = append(nodes, v)
+		}
+	}))
+	return nodes
+}
+
+// finder calls found on each node matching all the preds
+func finder(preds []Predicate, found func(interface{})) VisitorFuncs {
+	return VisitorFuncs{EnterFunc: func(c *Cursor) WalkAction {
+		n := c.Node()
+		for _, p := range preds {
+			if !p(n) {
+				return WalkContinue
+			}
+		}
+		found(n)
+		return WalkContinue
+	}}
+}
+
+// OfType matches nodes of type T, like OfType[*Table]()
+func OfType[T any]() Predicate {
+	return func(node interface{}) bool {
+		_, ok := node.(T)
+		return ok
+	}
+}
+
+// And matches nodes matching all the preds
+func And(preds ...Predicate) Predicate {
+	return func(node interface{}) bool {
+		for _, p := range preds {
+			if !p(node) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches nodes matching any of the preds
+func Or(preds ...Predicate) Predicate {
+	return func(node interface{}) bool {
+		for _, p := range preds {
+			if p(node) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches nodes not matching pred
+func Not(pred Predicate) Predicate {
+	return func(node interface{}) bool {
+		return !pred(node)
+	}
+}
+
+// ParagraphsWithStyle matches paragraphs whose style id is one of ids
+func ParagraphsWithStyle(ids ...string) Predicate {
+	return func(node interface{}) bool {
+		p, ok := node.(*Paragraph)
+		if !ok || p.Properties == nil || p.Properties.Style == nil {
+			return false
+		}
+		for _, id := range ids {
+			if p.Properties.Style.Val == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ParagraphsMatching matches paragraphs whose plain text matches re
+func ParagraphsMatching(re *regexp.Regexp) Predicate {
+	return And(OfType[*Paragraph](), TextMatching(re))
+}
+
+// TextMatching matches paragraphs, hyperlinks, runs, texts,
+// table cells and tables whose plain text matches re
+func TextMatching(re *regexp.Regexp) Predicate {
+	return func(node interface{}) bool {
+		switch node.(type) {
+		case *Paragraph, *Hyperlink, *Run, *Text, *WTableCell, *Table:
+			return re.MatchString(PlainText(node))
+		}
+		return false
+	}
+}
+
+// TablesWithHeader matches tables whose first row has a cell containing s
+func TablesWithHeader(s string) Predicate {
+	return func(node interface{}) bool {
+		t, ok := node.(*Table)
+		if !ok || len(t.TableRows) == 0 {
+			return false
+		}
+		for _, c := range t.TableRows[0].TableCells {
+			if strings.Contains(PlainText(c), s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PlainText returns the text of node without any formatting.
+// Paragraphs in cells are separated by '\n', and cells by '\t'.
+func PlainText(node interface{}) string {
+	sb := strings.Builder{}
+	writePlainText(&sb, node)
+	return sb.String()
+}
+
+func writePlainText(sb *strings.Builder, node interface{}) {
+	switch o := node.(type) {
+	case *Text:
+		sb.WriteString(o.Text)
+	case *Tab:
+		sb.WriteByte('\t')
+	case *BarterRabbet:
+		sb.WriteByte('\n')
+	case *Run:
+		for _, c := range o.Children {
+			writePlainText(sb, c)
+		}
+	case *Hyperlink:
+		if len(o.Run.Children) == 0 {
+			sb.WriteString(o.Run.InstrText)
+			return
+		}
+		writePlainText(sb, &o.Run)
+	case *Paragraph:
+		for _, c := range o.Children {
+			writePlainText(sb, c)
+		}
+	case *WTableCell:
+		for i, p := range o.Paragraphs {
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+			writePlainText(sb, p)
+		}
+	case *WTableRow:
+		for i, c := range o.TableCells {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			writePlainText(sb, c)
+		}
+	case *Table:
+		for i, r := range o.TableRows {
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+			writePlainText(sb, r)
+		}
+	}
+}
+
+// Keep keeps the items matching any of the preds and removes others
+func (b *Body) Keep(preds ...Predicate) {
+	b.Items = keepItems(b.Items, preds)
+}
+
+// Keep keeps the children matching any of the preds and removes others
+func (p *Paragraph) Keep(preds ...Predicate) {
+	p.Children = keepItems(p.Children, preds)
+}
+
+// Keep keeps the children matching any of the preds and removes others
+func (r *Run) Keep(preds ...Predicate) {
+	r.Children = keepItems(r.Children, preds)
+}
+
+func keepItems(items []interface{}, preds []Predicate) []interface{} {
+	kept := make([]interface{}, 0, len(items))
+	match := Or(preds...)
+	for _, item := range items {
+		if match(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
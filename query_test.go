@@ -0,0 +1,63 @@
+package docx
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().Style("Heading1").AddText("chapter 1")
+	f.AddParagraph().AddText("text 1")
+	tbl := f.AddTable(2, 2, 0, nil)
+	tbl.TableRows[0].TableCells[0].AddParagraph().AddText("Name")
+	tbl.TableRows[0].TableCells[1].AddParagraph().AddText("Value")
+	tbl.TableRows[1].TableCells[0].AddParagraph().AddText("a")
+	f.AddTable(1, 1, 0, nil).TableRows[0].TableCells[0].AddParagraph().AddText("other")
+	f.AddParagraph().Style("Heading1").AddText("chapter 2")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := f.Find(ParagraphsWithStyle("Heading1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || PlainText(nodes[1]) != "chapter 2" {
+		t.Fatal("unexpected headings", nodes)
+	}
+	nodes[1].(*Paragraph).Children = nil
+	if PlainText(f.Document.Body.Items[4]) != "" {
+		t.Fatal("result is not live")
+	}
+
+	drawings, err := FindAll[*Drawing](f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drawings) != 1 || drawings[0].Inline == nil {
+		t.Fatal("unexpected drawings", drawings)
+	}
+
+	tables, err := FindAll[*Table](f, TablesWithHeader("Value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0] != tbl {
+		t.Fatal("unexpected tables", tables)
+	}
+	if PlainText(tbl) != "Name\tValue\na\t" {
+		t.Fatalf("unexpected table text %q", PlainText(tbl))
+	}
+
+	ps := FindIn[*Paragraph](&f.Document.Body, ParagraphsMatching(regexp.MustCompile(`^(text|a)`)))
+	if len(ps) != 2 || PlainText(ps[1]) != "a" {
+		t.Fatal("unexpected paragraphs", ps)
+	}
+
+	f.Document.Body.Keep(OfType[*Table]())
+	if len(f.Document.Body.Items) != 2 {
+		t.Fatal("unexpected items after keep", len(f.Document.Body.Items))
+	}
+}
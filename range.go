@@ -0,0 +1,429 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrRangeOutOfBounds the offsets are not in the paragraph
+	ErrRangeOutOfBounds = errors.New("range out of bounds")
+	// ErrRangeSplitsElement a boundary falls inside an element that cannot be split,
+	// like a drawing or a hyperlink
+	ErrRangeSplitsElement = errors.New("range boundary inside an unsplittable element")
+	// ErrRangeNotText the range contains elements other than texts, tabs and breaks
+	ErrRangeNotText = errors.New("range contains non-text elements")
+	// ErrRangeNoDocument the paragraph does not belong to a docx
+	ErrRangeNoDocument = errors.New("paragraph does not belong to a docx")
+)
+
+// Range is the rune offsets [start, end) in a paragraph,
+// which are counted in the same way as Paragraph.String.
+//
+// The runs are split at the boundaries on need, and the
+// offsets keep valid as long as the text before the range
+// is not changed.
+type Range struct {
+	p          *Paragraph
+	start, end int
+}
+
+// Range returns the range [start, end) of p in runes of p.String()
+func (p *Paragraph) Range(start, end int) (*Range, error) {
+	if start < 0 || end < start || end > p.runeLen() {
+		return nil, ErrRangeOutOfBounds
+	}
+	return &Range{p: p, start: start, end: end}, nil
+}
+
+// Paragraph is where the range is
+func (r *Range) Paragraph() *Paragraph {
+	return r.p
+}
+
+// Start offset in runes
+func (r *Range) Start() int {
+	return r.start
+}
+
+// End offset in runes, exclusive
+func (r *Range) End() int {
+	return r.end
+}
+
+// Text is the runes [start, end) of p.String()
+func (r *Range) Text() string {
+	s := r.p.String()
+	i := runeIndex(s, r.start)
+	return s[i : i+runeIndex(s[i:], r.end-r.start)]
+}
+
+// Runs splits the runs at the boundaries and returns the runs
+// in the range, including the ones of hyperlinks.
+func (r *Range) Runs() ([]*Run, error) {
+	i, j, err := r.bounds()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]*Run, 0, j-i)
+	for _, c := range r.p.Children[i:j] {
+		switch o := c.(type) {
+		case *Run:
+			runs = append(runs, o)
+		case *Hyperlink:
+			runs = append(runs, &o.Run)
+		}
+	}
+	return runs, nil
+}
+
+// Format calls fn on each run in the range, like
+//
+//	r.Format(func(run *docx.Run) { run.Bold().Color("FF0000") })
+func (r *Range) Format(fn func(*Run)) error {
+	runs, err := r.Runs()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if run.RunProperties == nil {
+			run.RunProperties = &RunProperties{}
+		}
+		fn(run)
+	}
+	return nil
+}
+
+// Replace replaces the content in the range with text in the
+// format of the first run, and the range will cover the new text.
+// Bookmarks and comment marks in the range are kept.
+//
+// Replacing an empty range inserts text at the offset.
+func (r *Range) Replace(text string) error {
+	i, j, err := r.bounds()
+	if err != nil {
+		return err
+	}
+	var items []interface{}
+	if text != "" {
+		run := &Run{
+			RunProperties: r.props(i, j),
+			Children:      textChildren(text),
+			file:          r.p.file,
+		}
+		for _, c := range run.Children {
+			if t, ok := c.(*Text); ok && strings.TrimSpace(t.Text) != t.Text {
+				t.XMLSpace = "preserve"
+			}
+		}
+		items = []interface{}{run}
+	}
+	r.p.Children = r.splice(i, j, items...)
+	r.end = r.start + utf8.RuneCountInString(text)
+	return nil
+}
+
+// Delete removes the content in the range, making it empty
+func (r *Range) Delete() error {
+	return r.Replace("")
+}
+
+// WrapHyperlink turns the text in the range into a link to target,
+// in the same way as Paragraph.AddLink.
+func (r *Range) WrapHyperlink(target string) (*Hyperlink, error) {
+	if r.p.file == nil {
+		return nil, ErrRangeNoDocument
+	}
+	i, j, err := r.bounds()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range r.p.Children[i:j] {
+		if !isTextItem(c) {
+			return nil, ErrRangeNotText
+		}
+	}
+	props := r.props(i, j)
+	props.RunStyle = &RunStyle{Val: HYPERLINK_STYLE}
+	h := &Hyperlink{
+		ID: r.p.file.addLinkRelation(target),
+		Run: Run{
+			RunProperties: props,
+			InstrText:     r.Text(),
+		},
+	}
+	r.p.Children = r.splice(i, j, h)
+	r.end = r.start + utf8.RuneCountInString(r.p.linkString(h))
+	return h, nil
+}
+
+// AddBookmark wraps the range in a bookmark named name
+func (r *Range) AddBookmark(name string) (*BookmarkStart, error) {
+	i, j, err := r.bounds()
+	if err != nil {
+		return nil, err
+	}
+	var bookmarks []*BookmarkStart
+	if r.p.file != nil {
+		bookmarks = FindIn[*BookmarkStart](&r.p.file.Document.Body)
+	}
+	bookmarks = append(bookmarks, FindIn[*BookmarkStart](r.p)...)
+	id := 0
+	for _, b := range bookmarks {
+		if n, err := strconv.Atoi(b.ID); err == nil && n >= id {
+			id = n + 1
+		}
+	}
+	bs := &BookmarkStart{ID: strconv.Itoa(id), Name: name}
+	r.p.Children = r.insert(i, j, bs, &BookmarkEnd{ID: bs.ID})
+	return bs, nil
+}
+
+// AddComment attaches a comment of text by author to the range,
+// creating word/comments.xml if missing.
+func (r *Range) AddComment(author, text string) (*Comment, error) {
+	f := r.p.file
+	if f == nil {
+		return nil, ErrRangeNoDocument
+	}
+	i, j, err := r.bounds()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := f.ensureComments()
+	if err != nil {
+		return nil, err
+	}
+	id := 0
+	for _, c := range cs.Comments {
+		if n, err := strconv.Atoi(c.ID); err == nil && n >= id {
+			id = n + 1
+		}
+	}
+	p := &Paragraph{file: f}
+	p.AddText(text)
+	c := &Comment{
+		ID:     strconv.Itoa(id),
+		Author: author,
+		Date:   f.now().UTC().Format(time.RFC3339),
+		Items:  []interface{}{p},
+	}
+	cs.Comments = append(cs.Comments, c)
+	r.p.Children = r.insert(i, j, &CommentRangeStart{ID: c.ID}, &CommentRangeEnd{ID: c.ID}, &Run{
+		RunProperties: &RunProperties{},
+		Children:      []interface{}{&CommentReference{ID: c.ID}},
+		file:          f,
+	})
+	return c, nil
+}
+
+// bounds splits the runs at the boundaries and returns
+// the range [i, j) of the paragraph children in it
+func (r *Range) bounds() (i, j int, err error) {
+	i, err = r.p.boundary(r.start)
+	if err != nil {
+		return
+	}
+	j, err = r.p.boundary(r.end)
+	return
+}
+
+// props copies the run properties of the first run in children [i, j),
+// or of the last run before i.
+func (r *Range) props(i, j int) *RunProperties {
+	for _, c := range r.p.Children[i:j] {
+		if run, ok := c.(*Run); ok && run.RunProperties != nil && r.p.childRuneLen(run) > 0 {
+			return run.RunProperties.Copy()
+		}
+	}
+	for k := i - 1; k >= 0; k-- {
+		if run, ok := r.p.Children[k].(*Run); ok && run.RunProperties != nil {
+			return run.RunProperties.Copy()
+		}
+	}
+	return &RunProperties{}
+}
+
+// splice replaces children [i, j) with items, keeping the empty ones like bookmarks
+func (r *Range) splice(i, j int, items ...interface{}) []interface{} {
+	children := make([]interface{}, 0, len(r.p.Children)-(j-i)+len(items))
+	children = append(children, r.p.Children[:i]...)
+	for _, c := range r.p.Children[i:j] {
+		if r.p.childRuneLen(c) == 0 {
+			children = append(children, c)
+		}
+	}
+	children = append(children, items...)
+	return append(children, r.p.Children[j:]...)
+}
+
+// insert puts start before children i and ends after children j-1
+func (r *Range) insert(i, j int, start interface{}, ends ...interface{}) []interface{} {
+	children := make([]interface{}, 0, len(r.p.Children)+1+len(ends))
+	children = append(children, r.p.Children[:i]...)
+	children = append(children, start)
+	children = append(children, r.p.Children[i:j]...)
+	children = append(children, ends...)
+	return append(children, r.p.Children[j:]...)
+}
+
+// boundary splits the run at offset o if needed and returns the
+// index of the first child at or after o
+func (p *Paragraph) boundary(o int) (int, error) {
+	pos := utf8.RuneCountInString(p.indentString())
+	if o > 0 && o < pos {
+		return 0, ErrRangeSplitsElement
+	}
+	for i, c := range p.Children {
+		if pos >= o {
+			return i, nil
+		}
+		l := p.childRuneLen(c)
+		if pos+l <= o {
+			pos += l
+			continue
+		}
+		run, ok := c.(*Run)
+		if !ok {
+			return 0, ErrRangeSplitsElement
+		}
+		right, err := run.splitAt(o - pos)
+		if err != nil {
+			return 0, err
+		}
+		p.Children = append(p.Children[:i+1], p.Children[i:]...)
+		p.Children[i+1] = right
+		return i + 1, nil
+	}
+	if pos < o {
+		return 0, ErrRangeOutOfBounds
+	}
+	return len(p.Children), nil
+}
+
+// runeLen is the length of p.String() in runes
+func (p *Paragraph) runeLen() int {
+	n := utf8.RuneCountInString(p.indentString())
+	for _, c := range p.Children {
+		n += p.childRuneLen(c)
+	}
+	return n
+}
+
+// childRuneLen is the length of c in p.String() in runes
+func (p *Paragraph) childRuneLen(c interface{}) int {
+	switch o := c.(type) {
+	case *Hyperlink:
+		return utf8.RuneCountInString(p.linkString(o))
+	case *Run:
+		n := 0
+		for _, c := range o.Children {
+			n += utf8.RuneCountInString(runChildString(c))
+		}
+		return n
+	}
+	return 0
+}
+
+// splitAt cuts r at rune offset o, keeping [0, o) in r
+// and returning [o, end) as a new run.
+func (r *Run) splitAt(o int) (*Run, error) {
+	pos := 0
+	for i, c := range r.Children {
+		if pos >= o {
+			return r.cut(i, nil, nil), nil
+		}
+		l := utf8.RuneCountInString(runChildString(c))
+		if pos+l <= o {
+			pos += l
+			continue
+		}
+		t, ok := c.(*Text)
+		if !ok {
+			return nil, ErrRangeSplitsElement
+		}
+		k := runeIndex(t.Text, o-pos)
+		return r.cut(i+1, splitText(t, t.Text[:k]), splitText(t, t.Text[k:])), nil
+	}
+	return r.cut(len(r.Children), nil, nil), nil
+}
+
+// cut keeps children [0, i) in r, and returns the new run of children [i, end).
+// If left and right are not nil, the child i-1 is replaced by them.
+func (r *Run) cut(i int, left, right *Text) *Run {
+	nr := &Run{
+		Space:         r.Space,
+		RunProperties: r.RunProperties.Copy(),
+		file:          r.file,
+	}
+	nr.Children = make([]interface{}, 0, len(r.Children)-i+1)
+	if right != nil {
+		nr.Children = append(nr.Children, right)
+	}
+	nr.Children = append(nr.Children, r.Children[i:]...)
+	r.Children = r.Children[:i:i]
+	if left != nil {
+		r.Children[i-1] = left
+	}
+	return nr
+}
+
+// splitText makes a part of t with text s
+func splitText(t *Text, s string) *Text {
+	nt := &Text{XMLSpace: t.XMLSpace, Text: s}
+	if strings.TrimSpace(s) != s {
+		nt.XMLSpace = "preserve"
+	}
+	return nt
+}
+
+// isTextItem checks whether c is a run of texts, tabs and breaks only, or an empty item
+func isTextItem(c interface{}) bool {
+	switch o := c.(type) {
+	case *Run:
+		for _, x := range o.Children {
+			switch x.(type) {
+			case *Text, *Tab, *BarterRabbet:
+			default:
+				return false
+			}
+		}
+	case *Hyperlink:
+		return false
+	}
+	return true
+}
+
+// runeIndex is the byte index of the n-th rune in s
+func runeIndex(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
@@ -0,0 +1,131 @@
+package docx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	f := New().WithDefaultTheme()
+	p := f.AddParagraph()
+	p.AddText("Hello ").Bold()
+	p.AddText("wonderful world")
+	p.AddTab()
+	p.AddText("end")
+
+	r, err := p.Range(3, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Text() != "lo wonderful" {
+		t.Fatalf("unexpected text %q", r.Text())
+	}
+	err = r.Format(func(run *Run) { run.Color("FF0000") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "Hello wonderful world\tend" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+	runs, err := r.Runs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].RunProperties.Bold == nil || runs[1].RunProperties.Bold != nil {
+		t.Fatal("unexpected runs", runs)
+	}
+	for _, c := range p.Children {
+		run := c.(*Run)
+		red := run.RunProperties.Color != nil
+		if red != (run == runs[0] || run == runs[1]) {
+			t.Fatal("format out of range", PlainText(run))
+		}
+	}
+
+	r, _ = p.Range(6, 15)
+	err = r.Replace("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "Hello big world\tend" || r.End() != 9 {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+	r, _ = p.Range(9, 9)
+	_ = r.Replace(" wide")
+	r, _ = p.Range(0, 6)
+	_ = r.Delete()
+	if p.String() != "big wide world\tend" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+
+	r, _ = p.Range(9, 14)
+	h, err := r.WrapHyperlink("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Run.InstrText != "world" || p.String() != "big wide [world](https://example.com)\tend" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+	if r.End() != 37 {
+		t.Fatal("unexpected end", r.End())
+	}
+	r, _ = p.Range(10, 12)
+	_, err = r.Runs()
+	if err != ErrRangeSplitsElement {
+		t.Fatal("unexpected error", err)
+	}
+
+	r, _ = p.Range(0, 3)
+	bs, err := r.AddBookmark("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs.ID != "0" {
+		t.Fatal("unexpected bookmark id", bs.ID)
+	}
+	c, err := r.AddComment("someone", "check this")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Children[0].(*CommentRangeStart); !ok {
+		t.Fatal("comment not inserted")
+	}
+	if _, ok := p.Children[1].(*BookmarkStart); !ok {
+		t.Fatal("bookmark not inserted")
+	}
+	if p.String() != "big wide [world](https://example.com)\tend" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := nf.Comments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comments == nil || len(comments.Comments) != 1 || comments.Comments[0].Author != c.Author ||
+		PlainText(comments.Comments[0].Items[0]) != "check this" {
+		t.Fatal("unexpected comments", comments)
+	}
+	refs, err := FindAll[*CommentReference](nf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].ID != c.ID {
+		t.Fatal("unexpected comment references", refs)
+	}
+	bookmarks, err := FindAll[*BookmarkStart](nf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Name != "first" {
+		t.Fatal("unexpected bookmarks", bookmarks)
+	}
+}
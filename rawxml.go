@@ -0,0 +1,139 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// rawElement is a verbatim copy of an xml element that is not modelled
+type rawElement struct {
+	Name string     // Name is the local name of the element
+	Attr []xml.Attr // Attr is the raw attributes with prefix in Name.Space
+	XML  string     // XML is the whole element, including its own tags
+}
+
+// splitRawElements splits an inner xml string into its top-level elements.
+// Chardata between them is dropped.
+func splitRawElements(inner string) ([]rawElement, error) {
+	d := xml.NewDecoder(strings.NewReader(inner))
+	elems := make([]rawElement, 0, 16)
+	depth := 0
+	begin := int64(0)
+	var cur rawElement
+	for {
+		off := d.InputOffset()
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				begin = off
+				cur = rawElement{Name: tt.Name.Local, Attr: tt.Attr}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				cur.XML = inner[begin:d.InputOffset()]
+				elems = append(elems, cur)
+			}
+		}
+	}
+	return elems, nil
+}
+
+// decodeInnerXML reads the rest of start and returns its inner xml verbatim
+func decodeInnerXML(d *xml.Decoder, start *xml.StartElement) (string, error) {
+	var value struct {
+		Inner string `xml:",innerxml"`
+	}
+	err := d.DecodeElement(&value, start)
+	if err != nil && !strings.HasPrefix(err.Error(), "expected") {
+		return "", err
+	}
+	return value.Inner, nil
+}
+
+// prefixedAttrs converts the namespace-resolved attributes of a
+// decoded start element back into their prefixed form, so that
+// they can be written out again as they were read.
+func prefixedAttrs(attrs []xml.Attr) []xml.Attr {
+	prefixes := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		if a.Name.Space == "xmlns" {
+			prefixes[a.Value] = a.Name.Local
+		}
+	}
+	nattrs := make([]xml.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		switch {
+		case a.Name.Space == "":
+			nattrs = append(nattrs, a)
+		case a.Name.Space == "xmlns":
+			nattrs = append(nattrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + a.Name.Local}, Value: a.Value})
+		case prefixes[a.Name.Space] != "":
+			nattrs = append(nattrs, xml.Attr{Name: xml.Name{Local: prefixes[a.Name.Space] + ":" + a.Name.Local}, Value: a.Value})
+		default:
+			nattrs = append(nattrs, xml.Attr{Name: xml.Name{Local: a.Name.Local}, Value: a.Value})
+		}
+	}
+	return nattrs
+}
+
+// mergeNamespaces adds the xmlns declarations in from that are missing in to
+func mergeNamespaces(to, from []xml.Attr) []xml.Attr {
+	has := make(map[string]struct{}, len(to))
+	for _, a := range to {
+		has[a.Name.Local] = struct{}{}
+	}
+	for _, a := range from {
+		if !strings.HasPrefix(a.Name.Local, "xmlns:") {
+			continue
+		}
+		if _, ok := has[a.Name.Local]; ok {
+			continue
+		}
+		to = append(to, a)
+	}
+	return to
+}
+
+// encodeRaw writes inner verbatim as the content of start
+func encodeRaw(e *xml.Encoder, start xml.StartElement, inner string) error {
+	return e.EncodeElement(struct {
+		Inner string `xml:",innerxml"`
+	}{inner}, start)
+}
+
+// escapeAttr escapes s to be used as an xml attribute value
+func escapeAttr(s string) string {
+	sb := strings.Builder{}
+	_ = xml.EscapeText(&sb, StringToBytes(s))
+	return sb.String()
+}
@@ -0,0 +1,205 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// RenderPages renders the first n pages of f into images at dpi,
+// all pages if n <= 0. The layout is the same as WritePDFTo, and
+// the images can be encoded by image/png as previews.
+func (f *Docx) RenderPages(n int, dpi float64, opt *RenderOptions) ([]image.Image, error) {
+	if dpi <= 0 {
+		dpi = 96
+	}
+	pages, err := f.layoutDocument(opt)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 && n < len(pages) {
+		pages = pages[:n]
+	}
+	r := &rasterizer{scale: dpi / 72, images: make(map[*Media]image.Image, 4)}
+	imgs := make([]image.Image, len(pages))
+	for i, p := range pages {
+		imgs[i] = r.page(p)
+	}
+	return imgs, nil
+}
+
+// rasterizer paints the laid out pages
+type rasterizer struct {
+	scale  float64 // scale is pixels per point
+	images map[*Media]image.Image
+	z      vector.Rasterizer
+}
+
+// page paints p on a white image
+func (r *rasterizer) page(p *renderPage) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(p.width*r.scale)), int(math.Ceil(p.height*r.scale))))
+	draw.Draw(dst, dst.Rect, image.White, image.Point{}, draw.Src)
+	for _, it := range p.items {
+		switch o := it.(type) {
+		case *renderRect:
+			r.fill(dst, o.x, o.y, o.w, o.h, o.fill)
+		case *renderLine:
+			r.line(dst, o)
+		case *renderText:
+			r.text(dst, o)
+		case *renderImage:
+			r.image(dst, o)
+		}
+	}
+	return dst
+}
+
+// pixelRect converts a box in points into pixels
+func (r *rasterizer) pixelRect(x, y, w, h float64) image.Rectangle {
+	return image.Rect(
+		int(math.Floor(x*r.scale)), int(math.Floor(y*r.scale)),
+		int(math.Ceil((x+w)*r.scale)), int(math.Ceil((y+h)*r.scale)),
+	)
+}
+
+// fill paints a rectangle in points
+func (r *rasterizer) fill(dst *image.RGBA, x, y, w, h float64, c color.RGBA) {
+	rect := image.Rect(
+		int(math.Round(x*r.scale)), int(math.Round(y*r.scale)),
+		int(math.Round((x+w)*r.scale)), int(math.Round((y+h)*r.scale)),
+	)
+	if rect.Dx() == 0 {
+		rect.Max.X++
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y++
+	}
+	draw.Draw(dst, rect, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// line paints a horizontal or vertical line as rectangles, others are skipped
+func (r *rasterizer) line(dst *image.RGBA, l *renderLine) {
+	hw := l.width / 2
+	length := math.Max(math.Abs(l.x2-l.x1), math.Abs(l.y2-l.y1))
+	on, off := length, 0.0
+	if l.dash {
+		on, off = l.width*3, l.width*2
+	}
+	for d := 0.0; d < length; d += on + off {
+		seg := math.Min(on, length-d)
+		switch {
+		case l.y1 == l.y2:
+			r.fill(dst, math.Min(l.x1, l.x2)+d, l.y1-hw, seg, l.width, l.color)
+		case l.x1 == l.x2:
+			r.fill(dst, l.x1-hw, math.Min(l.y1, l.y2)+d, l.width, seg, l.color)
+		default:
+			return
+		}
+	}
+}
+
+// text paints the glyph outlines of t
+func (r *rasterizer) text(dst *image.RGBA, t *renderText) {
+	if len(t.glyphs) == 0 {
+		return
+	}
+	rf := t.font
+	px := t.size * r.scale
+	pad := px * 0.5
+	box := r.pixelRect(t.x, t.y, t.width, 0)
+	box.Min.X -= int(pad)
+	box.Max.X += int(pad)
+	box.Min.Y -= int(math.Ceil(px*rf.ascent + pad))
+	box.Max.Y += int(math.Ceil(px*rf.descent + pad))
+	box = box.Intersect(dst.Rect)
+	if box.Empty() {
+		return
+	}
+	r.z.Reset(box.Dx(), box.Dy())
+	ox := float32(t.x*r.scale) - float32(box.Min.X)
+	oy := float32(t.y*r.scale) - float32(box.Min.Y)
+	ppem := fixed.Int26_6(px * 64)
+	passes := []float32{0}
+	if t.fakeBold {
+		passes = append(passes, float32(px/30))
+	}
+	for _, dx := range passes {
+		x := ox + dx
+		for _, g := range t.glyphs {
+			segs, err := rf.font.LoadGlyph(&rf.buf, g, ppem, nil)
+			if err == nil {
+				addSegments(&r.z, segs, x, oy)
+			}
+			x += float32(rf.advance(g) * px)
+		}
+	}
+	r.z.DrawOp = draw.Over
+	r.z.Draw(dst, box, image.NewUniform(t.color), image.Point{})
+}
+
+// addSegments adds a glyph outline at x, y into z
+func addSegments(z *vector.Rasterizer, segs sfnt.Segments, x, y float32) {
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return x + float32(p.X)/64, y + float32(p.Y)/64
+	}
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			z.ClosePath()
+			z.MoveTo(pt(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			z.LineTo(pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			bx, by := pt(s.Args[0])
+			cx, cy := pt(s.Args[1])
+			z.QuadTo(bx, by, cx, cy)
+		case sfnt.SegmentOpCubeTo:
+			bx, by := pt(s.Args[0])
+			cx, cy := pt(s.Args[1])
+			dx, dy := pt(s.Args[2])
+			z.CubeTo(bx, by, cx, cy, dx, dy)
+		}
+	}
+	z.ClosePath()
+}
+
+// image paints the media scaled into its box, skipping the undecodable ones
+func (r *rasterizer) image(dst *image.RGBA, o *renderImage) {
+	img, ok := r.images[o.media]
+	if !ok {
+		data, _ := o.media.Load()
+		img, _, _ = image.Decode(bytes.NewReader(data))
+		r.images[o.media] = img
+	}
+	if img == nil {
+		return
+	}
+	xdraw.ApproxBiLinear.Scale(dst, r.pixelRect(o.x, o.y, o.w, o.h), img, img.Bounds(), xdraw.Over, nil)
+}
@@ -0,0 +1,52 @@
+package docx
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRenderPages(t *testing.T) {
+	f := New().WithDefaultTheme().WithA4Page()
+	for i := 0; i < 80; i++ {
+		f.AddParagraph().AddText("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor.").Size("28")
+	}
+	f.AddParagraph().AddText("red").Color("FF0000").Highlight("yellow")
+	_, err := f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imgs, err := f.RenderPages(2, 36, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatal("unexpected pages", len(imgs))
+	}
+	b := imgs[0].Bounds()
+	if b.Dx() != 298 || b.Dy() != 421 {
+		t.Fatal("unexpected size", b)
+	}
+	if c := color.RGBAModel.Convert(imgs[0].At(2, 2)).(color.RGBA); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Fatal("margin is not white", c)
+	}
+	dark := 0
+	for y := 36; y < 60; y++ {
+		for x := 45; x < 250; x++ {
+			if r, _, _, _ := imgs[0].At(x, y).RGBA(); r < 0x8000 {
+				dark++
+			}
+		}
+	}
+	if dark < 100 {
+		t.Fatal("text is not painted", dark)
+	}
+
+	all, err := f.RenderPages(0, 36, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) <= 2 {
+		t.Fatal("unexpected pages", len(all))
+	}
+}
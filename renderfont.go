@@ -0,0 +1,399 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// ErrInvalidFont the font data is not a TrueType or OpenType font
+var ErrInvalidFont = errors.New("invalid font data")
+
+// renderFont is a parsed font used in layout and rendering
+type renderFont struct {
+	name string // name is the PostScript name
+	data []byte // data is the sfnt of the font, extracted from its collection
+	font *sfnt.Font
+	buf  sfnt.Buffer
+	upem float64
+	cff  bool // cff is set if the outlines are in CFF instead of glyf
+
+	ascent, descent, height float64 // the metrics are in em
+
+	glyphs   map[rune]sfnt.GlyphIndex
+	advances map[sfnt.GlyphIndex]float64 // advances are in em
+	used     map[sfnt.GlyphIndex]rune    // used glyphs and their runes, for subsetting and ToUnicode
+}
+
+// newRenderFont parses data of a TrueType or OpenType font, or the first font in a collection
+func newRenderFont(data []byte) (*renderFont, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	if string(data[:4]) == "ttcf" {
+		var err error
+		data, err = extractCollectionFont(data, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	rf := &renderFont{
+		data:     data,
+		font:     f,
+		upem:     float64(f.UnitsPerEm()),
+		cff:      string(data[:4]) == "OTTO",
+		glyphs:   make(map[rune]sfnt.GlyphIndex, 256),
+		advances: make(map[sfnt.GlyphIndex]float64, 256),
+		used:     make(map[sfnt.GlyphIndex]rune, 256),
+	}
+	m, err := f.Metrics(&rf.buf, fixed.I(int(rf.upem)), font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	rf.ascent = float64(m.Ascent) / 64 / rf.upem
+	rf.descent = float64(m.Descent) / 64 / rf.upem
+	rf.height = float64(m.Height) / 64 / rf.upem
+	if rf.height < rf.ascent+rf.descent {
+		rf.height = rf.ascent + rf.descent
+	}
+	name, err := f.Name(&rf.buf, sfnt.NameIDPostScript)
+	if err != nil || name == "" {
+		name = "Font"
+	}
+	rf.name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || strings.ContainsRune("[](){}<>/%#", r) {
+			return -1
+		}
+		return r
+	}, name)
+	return rf, nil
+}
+
+// glyph gets the glyph of r, 0 if the font does not have it
+func (rf *renderFont) glyph(r rune) sfnt.GlyphIndex {
+	if g, ok := rf.glyphs[r]; ok {
+		return g
+	}
+	g, err := rf.font.GlyphIndex(&rf.buf, r)
+	if err != nil {
+		g = 0
+	}
+	rf.glyphs[r] = g
+	return g
+}
+
+// advance gets the advance width of g in em
+func (rf *renderFont) advance(g sfnt.GlyphIndex) float64 {
+	if a, ok := rf.advances[g]; ok {
+		return a
+	}
+	adv, err := rf.font.GlyphAdvance(&rf.buf, g, fixed.I(int(rf.upem)), font.HintingNone)
+	a := 0.0
+	if err == nil {
+		a = float64(adv) / 64 / rf.upem
+	}
+	rf.advances[g] = a
+	return a
+}
+
+// renderFonts finds the fonts for the runs of a document
+type renderFonts struct {
+	opt      *RenderOptions
+	named    map[string]*renderFont // named is the fonts by their keys in opt.Fonts
+	fallback []*renderFont
+	goFonts  [4]*renderFont // goFonts is regular, bold, italic and bold italic
+}
+
+func newRenderFonts(opt *RenderOptions) (*renderFonts, error) {
+	fs := &renderFonts{opt: opt, named: make(map[string]*renderFont, len(opt.Fonts))}
+	for _, data := range opt.Fallback {
+		rf, err := newRenderFont(data)
+		if err != nil {
+			return nil, err
+		}
+		fs.fallback = append(fs.fallback, rf)
+	}
+	for i, data := range [...][]byte{goregular.TTF, gobold.TTF, goitalic.TTF, gobolditalic.TTF} {
+		rf, err := newRenderFont(data)
+		if err != nil {
+			return nil, err
+		}
+		fs.goFonts[i] = rf
+	}
+	return fs, nil
+}
+
+// variant is the index of the style in goFonts
+func variant(bold, italic bool) int {
+	v := 0
+	if bold {
+		v |= 1
+	}
+	if italic {
+		v |= 2
+	}
+	return v
+}
+
+var variantSuffixes = [...]string{"", " Bold", " Italic", " Bold Italic"}
+
+// lookup finds the font of name in opt.Fonts, returning whether it is the
+// requested variant or the regular one
+func (fs *renderFonts) lookup(name string, bold, italic bool) (rf *renderFont, exact bool, err error) {
+	if name == "" {
+		return nil, false, nil
+	}
+	v := variant(bold, italic)
+	for _, key := range [...]string{name + variantSuffixes[v], name} {
+		if rf, ok := fs.named[key]; ok {
+			return rf, key != name || v == 0, nil
+		}
+		data, ok := fs.opt.Fonts[key]
+		if !ok {
+			for k, d := range fs.opt.Fonts {
+				if strings.EqualFold(k, key) {
+					data, ok = d, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		rf, err = newRenderFont(data)
+		if err != nil {
+			return nil, false, err
+		}
+		fs.named[key] = rf
+		return rf, key != name || v == 0, nil
+	}
+	return nil, false, nil
+}
+
+// forRune finds the font for r, trying primary, then the fallbacks and the Go fonts.
+// fake is set if the font is not bold as requested.
+func (fs *renderFonts) forRune(primary *renderFont, exact bool, r rune, bold, italic bool) (rf *renderFont, fake bool) {
+	if primary != nil && primary.glyph(r) != 0 {
+		return primary, bold && !exact
+	}
+	for _, f := range fs.fallback {
+		if f.glyph(r) != 0 {
+			return f, bold
+		}
+	}
+	g := fs.goFonts[variant(bold, italic)]
+	if g.glyph(r) != 0 || primary == nil {
+		return g, false
+	}
+	return primary, bold && !exact
+}
+
+// sfntTables reads the tables of the sfnt at offset of data
+func sfntTables(data []byte, offset int) (version uint32, tables map[string][]byte, err error) {
+	if offset+12 > len(data) {
+		return 0, nil, ErrInvalidFont
+	}
+	version = binary.BigEndian.Uint32(data[offset:])
+	n := int(binary.BigEndian.Uint16(data[offset+4:]))
+	tables = make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := offset + 12 + 16*i
+		if rec+16 > len(data) {
+			return 0, nil, ErrInvalidFont
+		}
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return 0, nil, ErrInvalidFont
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	return version, tables, nil
+}
+
+// buildSFNT writes the tables into a sfnt
+func buildSFNT(version uint32, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	size := 12 + 16*len(tables)
+	for tag, t := range tables {
+		tags = append(tags, tag)
+		size += (len(t) + 3) &^ 3
+	}
+	sort.Strings(tags)
+	out := make([]byte, 12+16*len(tables), size)
+	binary.BigEndian.PutUint32(out, version)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(tables)))
+	sr, sel := 1, 0
+	for sr*2 <= len(tables) {
+		sr *= 2
+		sel++
+	}
+	binary.BigEndian.PutUint16(out[6:], uint16(sr*16))
+	binary.BigEndian.PutUint16(out[8:], uint16(sel))
+	binary.BigEndian.PutUint16(out[10:], uint16(len(tables)*16-sr*16))
+	for i, tag := range tags {
+		t := tables[tag]
+		rec := out[12+16*i:]
+		copy(rec, tag)
+		sum := uint32(0)
+		for j := 0; j < len(t); j += 4 {
+			var w [4]byte
+			copy(w[:], t[j:])
+			sum += binary.BigEndian.Uint32(w[:])
+		}
+		binary.BigEndian.PutUint32(rec[4:], sum)
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(t)))
+		out = append(out, t...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+// extractCollectionFont extracts the i-th font of a TrueType collection as a standalone sfnt
+func extractCollectionFont(data []byte, i int) ([]byte, error) {
+	if len(data) < 16+4*i {
+		return nil, ErrInvalidFont
+	}
+	n := int(binary.BigEndian.Uint32(data[8:]))
+	if i >= n {
+		return nil, ErrInvalidFont
+	}
+	version, tables, err := sfntTables(data, int(binary.BigEndian.Uint32(data[12+4*i:])))
+	if err != nil {
+		return nil, err
+	}
+	return buildSFNT(version, tables), nil
+}
+
+// subset returns the font program containing only the used glyphs of rf.
+// The glyph ids are kept so that they can be used as CIDs. Fonts in CFF
+// are returned as they are.
+func (rf *renderFont) subset() ([]byte, error) {
+	if rf.cff {
+		return rf.data, nil
+	}
+	version, tables, err := sfntTables(rf.data, 0)
+	if err != nil {
+		return nil, err
+	}
+	head, loca, glyf, maxp := tables["head"], tables["loca"], tables["glyf"], tables["maxp"]
+	if len(head) < 54 || len(maxp) < 6 || glyf == nil {
+		return nil, ErrInvalidFont
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	long := binary.BigEndian.Uint16(head[50:]) != 0
+	offset := func(g int) int {
+		if long {
+			if 4*g+4 > len(loca) {
+				return -1
+			}
+			return int(binary.BigEndian.Uint32(loca[4*g:]))
+		}
+		if 2*g+2 > len(loca) {
+			return -1
+		}
+		return 2 * int(binary.BigEndian.Uint16(loca[2*g:]))
+	}
+	glyph := func(g int) []byte {
+		a, b := offset(g), offset(g+1)
+		if a < 0 || b < a || b > len(glyf) {
+			return nil
+		}
+		return glyf[a:b]
+	}
+	keep := make(map[int]bool, len(rf.used)+1)
+	var add func(g int)
+	add = func(g int) {
+		if g >= numGlyphs || keep[g] {
+			return
+		}
+		keep[g] = true
+		data := glyph(g)
+		if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+			return
+		}
+		for p := 10; p+4 <= len(data); { // components of a composite glyph
+			flags := binary.BigEndian.Uint16(data[p:])
+			add(int(binary.BigEndian.Uint16(data[p+2:])))
+			p += 4
+			if flags&0x0001 != 0 {
+				p += 4
+			} else {
+				p += 2
+			}
+			switch {
+			case flags&0x0008 != 0:
+				p += 2
+			case flags&0x0040 != 0:
+				p += 4
+			case flags&0x0080 != 0:
+				p += 8
+			}
+			if flags&0x0020 == 0 {
+				break
+			}
+		}
+	}
+	add(0)
+	for g := range rf.used {
+		add(int(g))
+	}
+	nglyf := make([]byte, 0, len(glyf)/4)
+	nloca := make([]byte, 4*(numGlyphs+1))
+	for g := 0; g < numGlyphs; g++ {
+		binary.BigEndian.PutUint32(nloca[4*g:], uint32(len(nglyf)))
+		if keep[g] {
+			nglyf = append(nglyf, glyph(g)...)
+			for len(nglyf)%4 != 0 {
+				nglyf = append(nglyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(nloca[4*numGlyphs:], uint32(len(nglyf)))
+	nhead := append([]byte(nil), head...)
+	binary.BigEndian.PutUint32(nhead[8:], 0)  // checkSumAdjustment
+	binary.BigEndian.PutUint16(nhead[50:], 1) // indexToLocFormat
+	out := map[string][]byte{"head": nhead, "loca": nloca, "glyf": nglyf, "maxp": maxp}
+	for _, tag := range [...]string{"hhea", "hmtx", "cvt ", "fpgm", "prep"} {
+		if t, ok := tables[tag]; ok {
+			out[tag] = t
+		}
+	}
+	return buildSFNT(version, out), nil
+}
@@ -0,0 +1,676 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// rtfHeadingSizes is the font size in half points of the headings in the stylesheet
+var rtfHeadingSizes = [...]int{40, 32, 28, 26, 24, 22, 21, 21, 21}
+
+// WriteRTFTo writes f to w as Rich Text Format.
+//
+// Paragraphs, headings, runs with their fonts and colors, lists, tables
+// with merged cells, pictures and hyperlinks are converted, and the page
+// size and margins are taken from the body section. The tables nested
+// in a cell are written as the paragraphs of the cell.
+func (f *Docx) WriteRTFTo(w io.Writer) error {
+	r := rtfWriter{
+		f:        f,
+		fontIdx:  make(map[string]int, 8),
+		colorIdx: make(map[string]int, 8),
+		listIdx:  make(map[string]int, 4),
+	}
+	r.font("Times New Roman")
+	r.blocks(f.Document.Body.Items)
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{\rtf1\ansi\ansicpg1252\deff0\uc1` + "\n" + `{\fonttbl`)
+	for i, name := range r.fonts {
+		bw.WriteString(`{\f` + strconv.Itoa(i) + `\fnil\fcharset0 `)
+		bw.WriteString(rtfText(name) + ";}")
+	}
+	bw.WriteString("}\n" + `{\colortbl;`)
+	for _, c := range r.colors {
+		v, _ := strconv.ParseUint(c, 16, 32)
+		bw.WriteString(`\red` + strconv.Itoa(int(v>>16)) + `\green` + strconv.Itoa(int(v>>8&0xff)) +
+			`\blue` + strconv.Itoa(int(v&0xff)) + ";")
+	}
+	bw.WriteString("}\n" + `{\stylesheet{\s0\snext0 Normal;}`)
+	for i, sz := range rtfHeadingSizes {
+		lv := strconv.Itoa(i + 1)
+		bw.WriteString(`{\s` + lv + `\sbasedon0\snext0\outlinelevel` + strconv.Itoa(i) + `\sb240\sa120\keepn\b\fs` +
+			strconv.Itoa(sz) + ` heading ` + lv + `;}`)
+	}
+	bw.WriteString("}\n")
+	if r.lists.Len() > 0 {
+		bw.WriteString(`{\*\listtable` + r.lists.String() + "}\n")
+		bw.WriteString(`{\*\listoverridetable` + r.overrides.String() + "}\n")
+	}
+	bw.WriteString(f.rtfPage() + "\n")
+	bw.WriteString(r.body.String())
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// rtfPage returns the page size and margins of the body section
+func (f *Docx) rtfPage() string {
+	var sp *SectPr
+	for _, it := range f.Document.Body.Items {
+		if s, ok := it.(*SectPr); ok {
+			sp = s
+		}
+	}
+	if sp == nil || sp.PgSz == nil || sp.PgSz.W <= 0 || sp.PgSz.H <= 0 {
+		return `\paperw11906\paperh16838\margl1800\margr1800\margt1440\margb1440`
+	}
+	s := `\paperw` + strconv.Itoa(sp.PgSz.W) + `\paperh` + strconv.Itoa(sp.PgSz.H)
+	if sp.PgSz.W > sp.PgSz.H {
+		s += `\landscape`
+	}
+	if m := sp.PgMar; m != nil {
+		s += `\margl` + strconv.Itoa(m.Left) + `\margr` + strconv.Itoa(m.Right) +
+			`\margt` + strconv.Itoa(m.Top) + `\margb` + strconv.Itoa(m.Bottom)
+	}
+	return s
+}
+
+type rtfWriter struct {
+	f    *Docx
+	body strings.Builder
+
+	fonts     []string
+	fontIdx   map[string]int
+	colors    []string       // colors is the hex RRGGBB of the color table after auto
+	colorIdx  map[string]int // colorIdx is the index of colors in the color table
+	listIdx   map[string]int // listIdx is numId -> \ls
+	lists     strings.Builder
+	overrides strings.Builder
+}
+
+// font returns the index of name in the font table, adding it on need
+func (r *rtfWriter) font(name string) int {
+	if i, ok := r.fontIdx[name]; ok {
+		return i
+	}
+	r.fontIdx[name] = len(r.fonts)
+	r.fonts = append(r.fonts, name)
+	return len(r.fonts) - 1
+}
+
+// color returns the index of hex color c in the color table, or 0 for auto
+func (r *rtfWriter) color(c string) int {
+	c = strings.ToUpper(strings.TrimPrefix(c, "#"))
+	if len(c) != 6 {
+		return 0
+	}
+	if _, err := strconv.ParseUint(c, 16, 32); err != nil {
+		return 0
+	}
+	if i, ok := r.colorIdx[c]; ok {
+		return i
+	}
+	r.colors = append(r.colors, c)
+	r.colorIdx[c] = len(r.colors)
+	return len(r.colors)
+}
+
+// rtfText escapes s, writing the non-ASCII characters as \u
+func rtfText(s string) string {
+	sb := strings.Builder{}
+	for _, c := range s {
+		switch {
+		case c == '\\' || c == '{' || c == '}':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case c == '\t':
+			sb.WriteString(`\tab `)
+		case c == '\n':
+			sb.WriteString(`\line `)
+		case c < 0x80:
+			sb.WriteRune(c)
+		default:
+			for _, u := range utf16.Encode([]rune{c}) {
+				sb.WriteString(`\u` + strconv.Itoa(int(int16(u))) + "?")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// blocks writes the paragraphs and tables in items
+func (r *rtfWriter) blocks(items []interface{}) {
+	for _, it := range items {
+		switch x := it.(type) {
+		case *Paragraph:
+			r.paragraph(x, false, `\par`)
+		case *Table:
+			r.table(x)
+		}
+	}
+}
+
+// paragraph writes p ended by end, which is \par or \cell
+func (r *rtfWriter) paragraph(p *Paragraph, intbl bool, end string) {
+	r.body.WriteString(`\pard\plain`)
+	if intbl {
+		r.body.WriteString(`\intbl`)
+	}
+	lv := r.f.HeadingLevel(p)
+	if lv > 9 {
+		lv = 9
+	}
+	if lv > 0 {
+		r.body.WriteString(`\s` + strconv.Itoa(lv) + `\outlinelevel` + strconv.Itoa(lv-1) +
+			`\sb240\sa120\keepn\b\fs` + strconv.Itoa(rtfHeadingSizes[lv-1]))
+	}
+	numID, ilvl, _ := r.f.ListLevel(p)
+	list := numID != "" && lv == 0
+	if list {
+		if ilvl < 0 || ilvl > 8 {
+			ilvl = 0
+		}
+		idx, left, hanging := r.list(numID, ilvl)
+		r.body.WriteString(`\ls` + strconv.Itoa(idx) + `\ilvl` + strconv.Itoa(ilvl) +
+			`\fi-` + strconv.Itoa(hanging) + `\li` + strconv.Itoa(left))
+	}
+	r.body.WriteString(r.paragraphRTF(p.Properties, list))
+	content, boxes := r.inline(p)
+	r.body.WriteString(content)
+	if len(boxes) == 0 {
+		r.body.WriteString(end + "\n")
+		return
+	}
+	r.body.WriteString(`\par` + "\n")
+	for i, b := range boxes {
+		if i < len(boxes)-1 {
+			r.paragraph(b, intbl, `\par`)
+		} else {
+			r.paragraph(b, intbl, end)
+		}
+	}
+}
+
+// paragraphRTF converts the paragraph properties into control words
+func (r *rtfWriter) paragraphRTF(pp *ParagraphProperties, list bool) string {
+	if pp == nil {
+		return ""
+	}
+	sb := strings.Builder{}
+	if pp.Justification != nil {
+		switch pp.Justification.Val {
+		case "left", "start":
+			sb.WriteString(`\ql`)
+		case "center":
+			sb.WriteString(`\qc`)
+		case "end", "right":
+			sb.WriteString(`\qr`)
+		case "both", "distribute":
+			sb.WriteString(`\qj`)
+		}
+	}
+	if pp.Ind != nil && !list {
+		if pp.Ind.Left != 0 {
+			sb.WriteString(`\li` + strconv.Itoa(pp.Ind.Left))
+		}
+		switch {
+		case pp.Ind.FirstLine != 0:
+			sb.WriteString(`\fi` + strconv.Itoa(pp.Ind.FirstLine))
+		case pp.Ind.Hanging != 0:
+			sb.WriteString(`\fi-` + strconv.Itoa(pp.Ind.Hanging))
+		}
+	}
+	if sp := pp.Spacing; sp != nil {
+		if sp.Before != 0 {
+			sb.WriteString(`\sb` + strconv.Itoa(sp.Before))
+		}
+		if sp.After != 0 {
+			sb.WriteString(`\sa` + strconv.Itoa(sp.After))
+		}
+		if sp.Line != 0 {
+			switch sp.LineRule {
+			case "", "auto":
+				sb.WriteString(`\sl` + strconv.Itoa(sp.Line) + `\slmult1`)
+			case "atLeast":
+				sb.WriteString(`\sl` + strconv.Itoa(sp.Line) + `\slmult0`)
+			default:
+				sb.WriteString(`\sl-` + strconv.Itoa(sp.Line) + `\slmult0`)
+			}
+		}
+	}
+	if pp.Shade != nil {
+		if c := r.color(pp.Shade.Fill); c > 0 {
+			sb.WriteString(`\cbpat` + strconv.Itoa(c))
+		}
+	}
+	return sb.String()
+}
+
+// runRTF converts the run properties into control words
+func (r *rtfWriter) runRTF(rp *RunProperties) string {
+	if rp == nil {
+		return ""
+	}
+	sb := strings.Builder{}
+	if rp.Fonts != nil {
+		if rp.Fonts.EastAsia != "" && rp.Fonts.EastAsia != rp.Fonts.ASCII {
+			sb.WriteString(`\dbch\af` + strconv.Itoa(r.font(rp.Fonts.EastAsia)) + `\loch`)
+		}
+		name := rp.Fonts.ASCII
+		if name == "" {
+			name = rp.Fonts.EastAsia
+		}
+		if name != "" {
+			sb.WriteString(`\f` + strconv.Itoa(r.font(name)))
+		}
+	}
+	if rp.Bold != nil && toggleOn(rp.Bold.Val) {
+		sb.WriteString(`\b`)
+	}
+	if rp.Italic != nil && toggleOn(rp.Italic.Val) {
+		sb.WriteString(`\i`)
+	}
+	if rp.Underline != nil {
+		switch rp.Underline.Val {
+		case "none":
+		case "double":
+			sb.WriteString(`\uldb`)
+		default:
+			sb.WriteString(`\ul`)
+		}
+	}
+	if rp.Strike != nil && toggleOn(rp.Strike.Val) {
+		sb.WriteString(`\strike`)
+	}
+	if rp.Color != nil {
+		if c := r.color(rp.Color.Val); c > 0 {
+			sb.WriteString(`\cf` + strconv.Itoa(c))
+		}
+	}
+	if rp.Size != nil {
+		if sz, err := strconv.Atoi(rp.Size.Val); err == nil && sz > 0 {
+			sb.WriteString(`\fs` + strconv.Itoa(sz))
+		}
+	}
+	if rp.Highlight != nil && highlightColors[rp.Highlight.Val] != "" {
+		sb.WriteString(`\highlight` + strconv.Itoa(r.color(highlightColors[rp.Highlight.Val])))
+	} else if rp.Shade != nil {
+		if c := r.color(rp.Shade.Fill); c > 0 {
+			sb.WriteString(`\chcbpat` + strconv.Itoa(c))
+		}
+	}
+	if rp.VertAlign != nil {
+		switch rp.VertAlign.Val {
+		case "superscript":
+			sb.WriteString(`\super`)
+		case "subscript":
+			sb.WriteString(`\sub`)
+		}
+	}
+	return sb.String()
+}
+
+// group wraps s into a group with the formatting of rp
+func (r *rtfWriter) group(rp *RunProperties, s string) string {
+	props := r.runRTF(rp)
+	if props == "" {
+		return "{" + s + "}"
+	}
+	return "{" + props + " " + s + "}"
+}
+
+// inline renders the children of p, and returns the paragraphs of its text boxes
+func (r *rtfWriter) inline(p *Paragraph) (string, []*Paragraph) {
+	sb := strings.Builder{}
+	var boxes []*Paragraph
+	for _, c := range p.Children {
+		switch x := c.(type) {
+		case *Hyperlink:
+			inst := `HYPERLINK \\l "` + rtfText(x.ID) + `"`
+			if target, err := r.f.ReferTarget(x.ID); err == nil {
+				inst = `HYPERLINK "` + rtfText(target) + `"`
+			}
+			sb.WriteString(`{\field{\*\fldinst{` + inst + `}}{\fldrslt` +
+				r.group(x.Run.RunProperties, rtfText(PlainText(x))) + "}}")
+		case *Run:
+			rs := strings.Builder{}
+			for _, y := range x.Children {
+				switch z := y.(type) {
+				case *Text:
+					rs.WriteString(rtfText(z.Text))
+				case *Tab:
+					rs.WriteString(`\tab `)
+				case *BarterRabbet:
+					switch z.Type {
+					case "", "textWrapping":
+						rs.WriteString(`\line `)
+					case "page":
+						rs.WriteString(`\page `)
+					}
+				case *Drawing:
+					for _, pic := range FindIn[*Picture](z) {
+						rs.WriteString(r.image(z, pic))
+					}
+					for _, sp := range FindIn[*WordprocessingShape](z) {
+						if sp.TextBox != nil && sp.TextBox.Content != nil {
+							for k := range sp.TextBox.Content.Paragraphs {
+								boxes = append(boxes, &sp.TextBox.Content.Paragraphs[k])
+							}
+						}
+					}
+				}
+			}
+			if rs.Len() > 0 {
+				sb.WriteString(r.group(x.RunProperties, rs.String()))
+			}
+		}
+	}
+	return sb.String(), boxes
+}
+
+// image returns the \pict of pic, converting the media other than PNG and JPEG into PNG
+func (r *rtfWriter) image(d *Drawing, pic *Picture) string {
+	if pic.BlipFill == nil || pic.BlipFill.Blip.Embed == "" {
+		return ""
+	}
+	target, err := r.f.ReferTarget(pic.BlipFill.Blip.Embed)
+	if err != nil {
+		return ""
+	}
+	media := r.f.Media(path.Base(target))
+	if media == nil {
+		return ""
+	}
+	data, err := media.Load()
+	if err != nil {
+		return ""
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	blip := `\pngblip`
+	switch format {
+	case "jpeg":
+		blip = `\jpegblip`
+	case "png":
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return ""
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, len(data)))
+		if png.Encode(buf, img) != nil {
+			return ""
+		}
+		data = buf.Bytes()
+	}
+	cx, cy := int64(0), int64(0)
+	if pic.SpPr != nil {
+		cx, cy = pic.SpPr.Xfrm.Ext.CX, pic.SpPr.Xfrm.Ext.CY
+	}
+	if cx == 0 || cy == 0 {
+		switch {
+		case d.Inline != nil && d.Inline.Extent != nil:
+			cx, cy = d.Inline.Extent.CX, d.Inline.Extent.CY
+		case d.Anchor != nil && d.Anchor.Extent != nil:
+			cx, cy = d.Anchor.Extent.CX, d.Anchor.Extent.CY
+		}
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`{\pict` + blip + `\picw` + strconv.Itoa(cfg.Width) + `\pich` + strconv.Itoa(cfg.Height))
+	if cx > 0 && cy > 0 {
+		// 635 EMU per twip
+		sb.WriteString(`\picwgoal` + strconv.FormatInt(cx/635, 10) + `\pichgoal` + strconv.FormatInt(cy/635, 10))
+	}
+	sb.WriteByte('\n')
+	h := hex.EncodeToString(data)
+	for len(h) > 128 {
+		sb.WriteString(h[:128] + "\n")
+		h = h[128:]
+	}
+	sb.WriteString(h + "}")
+	return sb.String()
+}
+
+// rtfNumFormats maps numFmt into \levelnfc
+var rtfNumFormats = map[string]int{
+	"decimal": 0, "upperRoman": 1, "lowerRoman": 2, "upperLetter": 3, "lowerLetter": 4, "bullet": 23, "none": 255,
+}
+
+// list returns the \ls of numID with the indents of ilvl, adding its list on need
+func (r *rtfWriter) list(numID string, ilvl int) (idx, left, hanging int) {
+	var num *Num
+	var a *AbstractNum
+	if r.f.hasNumbering() {
+		n, err := r.f.readNumbering()
+		if err == nil {
+			num = n.Num(numID)
+			if num != nil {
+				a = n.AbstractNum(num.AbstractNumID)
+			}
+		}
+	}
+	left, hanging = 720*(ilvl+1), 360
+	if a != nil {
+		if l, h := a.lvlInd(ilvl); l > 0 {
+			left, hanging = l, h
+		}
+	}
+	idx, ok := r.listIdx[numID]
+	if ok {
+		return
+	}
+	idx = len(r.listIdx) + 1
+	r.listIdx[numID] = idx
+	id := strconv.Itoa(idx)
+	r.lists.WriteString(`{\list\listtemplateid` + id)
+	for i := 0; i < 9; i++ {
+		numFmt, text, start, l, h := "bullet", "•", 1, 720*(i+1), 360
+		if a != nil {
+			if f := a.NumFmt(i); f != "" {
+				numFmt = f
+			}
+			if t, ok := a.lvlText(i); ok {
+				text = t
+			}
+			start = a.start(i)
+			if s := num.startOverride(i); s > 0 {
+				start = s
+			}
+			if nl, nh := a.lvlInd(i); nl > 0 {
+				l, h = nl, nh
+			}
+		}
+		nfc, ok := rtfNumFormats[numFmt]
+		if !ok {
+			nfc = 0
+		}
+		if nfc == 23 {
+			text = bulletText(text)
+		}
+		// %N in the level text becomes the placeholder \'0(N-1)
+		lt, nums, n := strings.Builder{}, strings.Builder{}, 0
+		rs := []rune(text)
+		for k := 0; k < len(rs); k++ {
+			if rs[k] == '%' && k+1 < len(rs) && rs[k+1] >= '1' && rs[k+1] <= '9' {
+				n++
+				lt.WriteString(`\'0` + strconv.Itoa(int(rs[k+1]-'1')))
+				nums.WriteString(`\'` + hex.EncodeToString([]byte{byte(n)}))
+				k++
+				continue
+			}
+			n++
+			lt.WriteString(rtfText(string(rs[k])))
+		}
+		nfcs := strconv.Itoa(nfc)
+		r.lists.WriteString(`{\listlevel\levelnfc` + nfcs + `\levelnfcn` + nfcs + `\leveljc0\leveljcn0\levelfollow0\levelstartat` +
+			strconv.Itoa(start) + `{\leveltext\'` + hex.EncodeToString([]byte{byte(n)}) + lt.String() + `;}{\levelnumbers` +
+			nums.String() + `;}\fi-` + strconv.Itoa(h) + `\li` + strconv.Itoa(l) + `\jclisttab\tx` + strconv.Itoa(l) + `}`)
+	}
+	r.lists.WriteString(`\listid` + id + "}")
+	r.overrides.WriteString(`{\listoverride\listid` + id + `\listoverridecount0\ls` + id + "}")
+	return
+}
+
+// rtfBorder converts a border into the control words following \clbrdr
+func (r *rtfWriter) rtfBorder(b *WTableBorder) string {
+	switch b.Val {
+	case "", "nil", "none":
+		return `\brdrnone`
+	}
+	style := `\brdrs`
+	switch b.Val {
+	case "double":
+		style = `\brdrdb`
+	case "dotted":
+		style = `\brdrdot`
+	case "dashed", "dashSmallGap", "dotDash", "dotDotDash":
+		style = `\brdrdash`
+	}
+	width := 10
+	if b.Size > 0 {
+		width = b.Size * 5 / 2 // eighths of a point into twips
+	}
+	style += `\brdrw` + strconv.Itoa(width)
+	if c := r.color(b.Color); c > 0 {
+		style += `\brdrcf` + strconv.Itoa(c)
+	}
+	return style
+}
+
+func (r *rtfWriter) table(t *Table) {
+	cols := 0
+	for _, row := range t.TableRows {
+		n := 0
+		for _, c := range row.TableCells {
+			n += gridSpan(c)
+		}
+		if n > cols {
+			cols = n
+		}
+	}
+	if cols == 0 {
+		return
+	}
+	widths := make([]int64, cols)
+	for i := range widths {
+		widths[i] = 9000 / int64(cols)
+		if t.TableGrid != nil && i < len(t.TableGrid.GridCols) && t.TableGrid.GridCols[i].W > 0 {
+			widths[i] = t.TableGrid.GridCols[i].W
+		}
+	}
+	var borders *WTableBorders
+	jc := ""
+	if tp := t.TableProperties; tp != nil {
+		borders = tp.TableBorders
+		if tp.Justification != nil {
+			switch tp.Justification.Val {
+			case "center":
+				jc = `\trqc`
+			case "end", "right":
+				jc = `\trqr`
+			}
+		}
+	}
+	for i, row := range t.TableRows {
+		r.body.WriteString(`\trowd\trgaph108` + jc)
+		col, x := 0, int64(0)
+		for _, c := range row.TableCells {
+			span := gridSpan(c)
+			switch merged, restart := vMerge(c); {
+			case restart:
+				r.body.WriteString(`\clvmgf`)
+			case merged:
+				r.body.WriteString(`\clvmrg`)
+			}
+			var cb *WTableBorders
+			if tcp := c.TableCellProperties; tcp != nil {
+				cb = tcp.TableBorders
+				if tcp.VAlign != nil {
+					switch tcp.VAlign.Val {
+					case "center":
+						r.body.WriteString(`\clvertalc`)
+					case "bottom":
+						r.body.WriteString(`\clvertalb`)
+					}
+				}
+				if tcp.Shade != nil {
+					if sc := r.color(tcp.Shade.Fill); sc > 0 {
+						r.body.WriteString(`\clcbpat` + strconv.Itoa(sc))
+					}
+				}
+			}
+			for k, b := range cellBorders(cb, borders, i == 0, i == len(t.TableRows)-1, col == 0, col+span >= cols) {
+				if b != nil {
+					r.body.WriteString(`\clbrdr` + cellSides[k][:1] + r.rtfBorder(b))
+				}
+			}
+			for k := col; k < col+span && k < cols; k++ {
+				x += widths[k]
+			}
+			r.body.WriteString(`\cellx` + strconv.FormatInt(x, 10))
+			col += span
+		}
+		r.body.WriteString("\n")
+		for _, c := range row.TableCells {
+			r.cell(c)
+		}
+		r.body.WriteString(`\row` + "\n")
+	}
+}
+
+// cell writes the paragraphs in c and in the tables nested in c
+func (r *rtfWriter) cell(c *WTableCell) {
+	var ps []*Paragraph
+	var walk func(c *WTableCell)
+	walk = func(c *WTableCell) {
+		ps = append(ps, c.Paragraphs...)
+		for _, t := range c.Tables {
+			for _, row := range t.TableRows {
+				for _, nc := range row.TableCells {
+					walk(nc)
+				}
+			}
+		}
+	}
+	walk(c)
+	if len(ps) == 0 {
+		r.body.WriteString(`\pard\plain\intbl\cell` + "\n")
+		return
+	}
+	for i, p := range ps {
+		end := `\par`
+		if i == len(ps)-1 {
+			end = `\cell`
+		}
+		r.paragraph(p, true, end)
+	}
+}
@@ -0,0 +1,104 @@
+package docx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRTF(t *testing.T) {
+	f, err := FromMarkdown([]byte("# Title\n\n- one\n- two\n  1. sub\n\nend  with **bold** and [link](https://example.com) 中文"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := f.AddParagraph().Justification("center")
+	p.AddText("red").Color("FF0000").Size("28").Font("Arial", "Arial", "Arial", "")
+	p.AddText(" under").Underline("single")
+	tbl := f.AddTable(2, 3, 0, nil)
+	c := tbl.TableRows[0].TableCells[0]
+	c.TableCellProperties.GridSpan = &WGridSpan{Val: 2}
+	tbl.TableRows[0].TableCells = tbl.TableRows[0].TableCells[:2]
+	c.AddParagraph().AddText("wide")
+	tbl.TableRows[0].TableCells[1].TableCellProperties.VMerge = &WvMerge{Val: "restart"}
+	tbl.TableRows[1].TableCells[2].TableCellProperties.VMerge = &WvMerge{}
+	tbl.TableRows[1].TableCells[0].AddParagraph().AddText("a{b}\\c")
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiama.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.WriteRTFTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rtf := buf.String()
+	for _, exp := range []string{
+		`{\rtf1\ansi`, `{\f1\fnil\fcharset0 Arial;}`, `\red255\green0\blue0;`,
+		`\s1\outlinelevel0`, `\ls1\ilvl0`, `\ls2\ilvl1`, `\levelnfc23`, `\levelnfc0`,
+		`{\field{\*\fldinst{HYPERLINK "https://example.com"}}{\fldrslt`, `{ \u20013?\u25991?}`,
+		`\qc{\f1\cf1\fs28 red}{\ul  under}`, `\clvmgf`, `\clvmrg`, `\cellx`, `a\{b\}\\c`, `{\pict\jpegblip`,
+	} {
+		if !strings.Contains(rtf, exp) {
+			t.Fatal("missing", exp, "in", rtf)
+		}
+	}
+
+	nf, err := FromRTF(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := bytes.NewBuffer(nil)
+	err = nf.WriteMarkdownTo(md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"# Title\n\n", "- one\n- two\n    1. sub\n\n", "end  with **bold** and [link](https://example.com) 中文",
+		"red under", "| wide |", `a{b}\\c`, "](media/image1.jpeg)",
+	} {
+		if !strings.Contains(md.String(), exp) {
+			t.Fatal("missing", exp, "in", md.String())
+		}
+	}
+	for _, it := range nf.Document.Body.Items {
+		switch x := it.(type) {
+		case *Paragraph:
+			if !strings.Contains(PlainText(x), "red") {
+				continue
+			}
+			if x.Properties.Justification.Val != "center" {
+				t.Fatal("unexpected paragraph")
+			}
+			rp := x.Children[0].(*Run).RunProperties
+			if rp.Color.Val != "FF0000" || rp.Size.Val != "28" || rp.Fonts.ASCII != "Arial" {
+				t.Fatal("unexpected run", rp)
+			}
+		case *Table:
+			row := x.TableRows[0]
+			if len(row.TableCells) != 2 || row.TableCells[0].TableCellProperties.GridSpan.Val != 2 ||
+				row.TableCells[1].TableCellProperties.VMerge.Val != "restart" {
+				t.Fatal("unexpected table")
+			}
+		}
+	}
+
+	nf, err = FromRTF(strings.NewReader(`{\rtf1\ansi\ansicpg936\deff0{\fonttbl{\f0\fswiss Arial;}{\f1\fnil\fcharset134 \'cb\'ce\'cc\'e5;}}` +
+		`{\stylesheet{\s0 Normal;}{\s2\b heading 2;}}` + "\n" +
+		`\pard\s2 Head\par` + "\n" +
+		`\pard\f1\'c4\'e3\'ba\'c3{\uc2\u8364\'80\'80} {\field{\*\fldinst HYPERLINK "http://a.b" \\l "x"}{\fldrslt go}}\par}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.Reset()
+	err = nf.WriteMarkdownTo(md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.String() != "## Head\n\n你好€ [go](http://a.b#x)" {
+		t.Fatalf("unexpected %q", md.String())
+	}
+	if _, err = FromRTF(strings.NewReader("{\\html}")); err != ErrInvalidRTF {
+		t.Fatal("unexpected", err)
+	}
+}
@@ -0,0 +1,1200 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// ErrInvalidRTF the data is not started by {\rtf
+var ErrInvalidRTF = errors.New("invalid rtf data")
+
+// rtfMaxDepth limits the nesting of the groups to be converted
+const rtfMaxDepth = 256
+
+// FromRTF creates a docx in the default theme from the Rich Text Format in r
+func FromRTF(r io.Reader) (*Docx, error) {
+	f := New().WithDefaultTheme()
+	err := f.AddRTF(r)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddRTF appends the Rich Text Format in r to the body of f.
+//
+// Paragraphs, headings by outline levels or heading styles, the character
+// formatting with the font and color tables, lists, tables with merged
+// cells, PNG and JPEG pictures and HYPERLINK fields are converted in the
+// same way as AddHTML. The 8-bit texts are decoded by \ansicpg and the
+// charsets of the fonts, and the tables nested in a cell are flattened
+// into the paragraphs of the cell.
+func (f *Docx) AddRTF(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	root := parseRTF(data)
+	if len(root.children) == 0 || !root.children[0].group || len(root.children[0].children) == 0 ||
+		root.children[0].children[0].word != "rtf" {
+		return ErrInvalidRTF
+	}
+	c := newRTFReader()
+	c.group(root.children[0], rtfFormat{uc: 1}, 0)
+	return c.build(f)
+}
+
+// rtfItem is a control word, a text or a group of RTF
+type rtfItem struct {
+	word     string // word is the control word or symbol, empty for texts and groups
+	param    int
+	has      bool       // has is set if word has a parameter
+	text     []byte     // text is the bytes of a text or \bin
+	children []*rtfItem // children is the items of a group
+	group    bool
+}
+
+func isRTFLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isRTFDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseRTF parses data loosely into a tree of groups
+func parseRTF(data []byte) *rtfItem {
+	root := &rtfItem{group: true}
+	stack := []*rtfItem{root}
+	addText := func(g *rtfItem, b []byte) {
+		if n := len(g.children); n > 0 && !g.children[n-1].group && g.children[n-1].word == "" {
+			g.children[n-1].text = append(g.children[n-1].text, b...)
+			return
+		}
+		g.children = append(g.children, &rtfItem{text: append([]byte(nil), b...)})
+	}
+	for i := 0; i < len(data); {
+		cur := stack[len(stack)-1]
+		switch c := data[i]; c {
+		case '{':
+			g := &rtfItem{group: true}
+			cur.children = append(cur.children, g)
+			stack = append(stack, g)
+			i++
+		case '}':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			i++
+		case '\r', '\n':
+			i++
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			c = data[i]
+			switch {
+			case isRTFLetter(c):
+				j := i
+				for j < len(data) && isRTFLetter(data[j]) {
+					j++
+				}
+				it := &rtfItem{word: string(data[i:j])}
+				k := j
+				neg := k < len(data) && data[k] == '-'
+				if neg {
+					k++
+				}
+				s := k
+				for k < len(data) && isRTFDigit(data[k]) && k-s < 10 {
+					k++
+				}
+				if k > s {
+					it.param, _ = strconv.Atoi(string(data[s:k]))
+					if neg {
+						it.param = -it.param
+					}
+					it.has = true
+					j = k
+				}
+				if j < len(data) && data[j] == ' ' {
+					j++
+				}
+				i = j
+				if it.word == "bin" && it.param > 0 {
+					n := minInt(it.param, len(data)-i)
+					it.text = data[i : i+n]
+					i += n
+				}
+				cur.children = append(cur.children, it)
+			case c == '\'':
+				i++
+				if i+2 <= len(data) {
+					if v, err := strconv.ParseUint(string(data[i:i+2]), 16, 8); err == nil {
+						cur.children = append(cur.children, &rtfItem{word: "'", param: int(v), has: true})
+					}
+					i += 2
+				}
+			case c == '\\' || c == '{' || c == '}':
+				addText(cur, data[i:i+1])
+				i++
+			case c == '\r' || c == '\n':
+				cur.children = append(cur.children, &rtfItem{word: "par"})
+				i++
+			default:
+				cur.children = append(cur.children, &rtfItem{word: string(c)})
+				i++
+			}
+		default:
+			j := i
+			for j < len(data) && data[j] != '{' && data[j] != '}' && data[j] != '\\' && data[j] != '\r' && data[j] != '\n' {
+				j++
+			}
+			addText(cur, data[i:j])
+			i = j
+		}
+	}
+	return root
+}
+
+// rtfDest returns the destination of group g, and whether it is marked by \*
+func rtfDest(g *rtfItem) (string, bool) {
+	items := g.children
+	star := len(items) > 0 && items[0].word == "*"
+	if star {
+		items = items[1:]
+	}
+	if len(items) > 0 && !items[0].group {
+		return items[0].word, star
+	}
+	return "", star
+}
+
+// rtfCharsets maps \fcharset into the code page
+var rtfCharsets = map[int]int{
+	77: 10000, 128: 932, 129: 949, 134: 936, 136: 950, 161: 1253, 162: 1254, 163: 1258,
+	177: 1255, 178: 1256, 186: 1257, 204: 1251, 222: 874, 238: 1250, 255: 437,
+}
+
+// rtfEncoding returns the encoding of code page cp, or nil for UTF-8
+func rtfEncoding(cp int) encoding.Encoding {
+	switch cp {
+	case 65001:
+		return nil
+	case 932:
+		return japanese.ShiftJIS
+	case 936:
+		return simplifiedchinese.GBK
+	case 949:
+		return korean.EUCKR
+	case 950:
+		return traditionalchinese.Big5
+	case 874:
+		return charmap.Windows874
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	case 1253:
+		return charmap.Windows1253
+	case 1254:
+		return charmap.Windows1254
+	case 1255:
+		return charmap.Windows1255
+	case 1256:
+		return charmap.Windows1256
+	case 1257:
+		return charmap.Windows1257
+	case 1258:
+		return charmap.Windows1258
+	case 437:
+		return charmap.CodePage437
+	case 850:
+		return charmap.CodePage850
+	case 866:
+		return charmap.CodePage866
+	case 10000:
+		return charmap.Macintosh
+	}
+	return charmap.Windows1252
+}
+
+// rtfDecode decodes b in code page cp
+func rtfDecode(b []byte, cp int) string {
+	e := rtfEncoding(cp)
+	if e == nil {
+		return string(b)
+	}
+	s, err := e.NewDecoder().Bytes(b)
+	if err != nil {
+		s, _ = charmap.Windows1252.NewDecoder().Bytes(b)
+	}
+	return string(s)
+}
+
+// rtfFormat is the character formatting in a group
+type rtfFormat struct {
+	b, i, u, s, hidden bool
+	vert               string
+	font, size         int // size is in half points
+	color, bg          int // color and bg are the indexes in the color table
+	uc                 int // uc is the count of the fallback characters after \u
+}
+
+// rtfPara is the paragraph formatting
+type rtfPara struct {
+	align    string
+	style    int
+	outline  int // outline is \outlinelevel + 1
+	ls, ilvl int
+	intbl    bool
+}
+
+type rtfFont struct {
+	name string
+	cp   int // cp is the code page by \fcharset, 0 for \ansicpg
+}
+
+// rtfCellDef is a cell defined by \cellx
+type rtfCellDef struct {
+	right          int
+	hmerge, vmerge bool // hmerge and vmerge are set on the cells merged into the previous
+	vfirst         bool // vfirst is set on the first cell of a vertical merge
+}
+
+type rtfRow struct {
+	cells [][]*htmlNode // cells is the blocks in each cell
+	defs  []rtfCellDef
+}
+
+// rtfList is an opened ul or ol of \ls
+type rtfList struct {
+	ls   int
+	node *htmlNode
+}
+
+// rtfReader converts the groups of RTF, or the paragraphs and
+// characters of other word processors, into HTML nodes
+type rtfReader struct {
+	cp        int // cp is \ansicpg
+	deff      int
+	fonts     map[int]rtfFont
+	colors    []string           // colors is the color table in css, empty for auto
+	headings  map[int]int        // headings is style -> heading level
+	styleCSS  map[int]string     // styleCSS is the css of the character formatting in the heading styles
+	levels    map[int][]odtLevel // levels is \listid -> levels
+	overrides map[int]int        // overrides is \ls -> \listid
+	pictures  map[string][]byte
+
+	blocks  []*htmlNode
+	para    rtfPara
+	inline  []*htmlNode // inline is the contents of the paragraph in progress
+	merge   bool        // merge is set if a text can be appended to the last span of inline
+	paras   int         // paras counts the ended paragraphs
+	pending []byte      // pending is the 8-bit text to be decoded
+	high    rune        // high is the pending high surrogate of \u
+
+	def   rtfCellDef
+	defs  []rtfCellDef
+	cell  []*htmlNode
+	cells [][]*htmlNode
+	rows  []*rtfRow
+	lists []rtfList
+}
+
+func newRTFReader() *rtfReader {
+	return &rtfReader{
+		cp:        1252,
+		fonts:     make(map[int]rtfFont, 8),
+		headings:  make(map[int]int, 8),
+		styleCSS:  make(map[int]string, 8),
+		levels:    make(map[int][]odtLevel, 4),
+		overrides: make(map[int]int, 4),
+		pictures:  make(map[string][]byte, 4),
+	}
+}
+
+// build ends the conversion and appends the nodes to the body of f
+func (r *rtfReader) build(f *Docx) error {
+	r.finish()
+	read := func(name string) ([]byte, error) {
+		if data, ok := r.pictures[name]; ok {
+			return data, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	b := htmlBuilder{
+		mdBuilder: mdBuilder{f: f, opt: &MarkdownImportOptions{ReadFile: read}, styles: make(map[string]string, 8)},
+		ctx:       &mdContext{ilvl: -1},
+	}
+	err := b.children(&htmlNode{tag: "div", children: r.blocks}, htmlFormat{})
+	b.end()
+	return err
+}
+
+// group converts g by its destination
+func (r *rtfReader) group(g *rtfItem, fm rtfFormat, depth int) {
+	if depth > rtfMaxDepth {
+		return
+	}
+	dest, star := rtfDest(g)
+	switch dest {
+	case "fonttbl":
+		r.fontTable(g)
+		return
+	case "colortbl":
+		r.colorTable(g)
+		return
+	case "stylesheet":
+		r.stylesheet(g)
+		return
+	case "listtable":
+		r.listTable(g)
+		return
+	case "listoverridetable":
+		r.overrideTable(g)
+		return
+	case "field":
+		r.field(g, fm, depth)
+		return
+	case "pict":
+		r.pict(g)
+		return
+	case "shpinst":
+		r.shape(g, fm, depth)
+		return
+	case "shppict", "fldrslt":
+	case "info", "header", "headerl", "headerr", "headerf", "footer", "footerl", "footerr", "footerf",
+		"footnote", "pntext", "pn", "listtext", "nonshppict", "shprslt", "annotation", "xe", "tc", "do":
+		return
+	default:
+		if star {
+			return
+		}
+	}
+	r.walk(g, fm, depth)
+}
+
+// walk converts the items in g
+func (r *rtfReader) walk(g *rtfItem, fm rtfFormat, depth int) {
+	skip := 0 // skip is the count of the fallback characters of \u left
+	for _, it := range g.children {
+		if it.group {
+			r.flush(&fm)
+			skip = 0
+			r.group(it, fm, depth+1)
+			continue
+		}
+		text := it.text
+		if skip > 0 {
+			if it.word != "" {
+				skip--
+				continue
+			}
+			n := minInt(skip, len(text))
+			skip -= n
+			text = text[n:]
+		}
+		switch it.word {
+		case "":
+			r.pending = append(r.pending, text...)
+		case "'":
+			r.pending = append(r.pending, byte(it.param))
+		default:
+			r.flush(&fm)
+			skip = r.control(it, &fm)
+		}
+	}
+	r.flush(&fm)
+}
+
+// codePage returns the code page of the texts in fm
+func (r *rtfReader) codePage(fm *rtfFormat) int {
+	if f, ok := r.fonts[fm.font]; ok && f.cp > 0 {
+		return f.cp
+	}
+	return r.cp
+}
+
+// flush decodes the pending 8-bit text
+func (r *rtfReader) flush(fm *rtfFormat) {
+	if len(r.pending) == 0 {
+		return
+	}
+	s := rtfDecode(r.pending, r.codePage(fm))
+	r.pending = r.pending[:0]
+	r.text(s, fm)
+}
+
+// rtfSymbols is the control words of special characters
+var rtfSymbols = map[string]string{
+	"~": "\u00a0", "_": "\u2011", "tab": "\t", "emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": "\u2003", "enspace": "\u2002", "qmspace": "\u2005",
+}
+
+// control applies the control word it, returning the count of characters to be skipped
+func (r *rtfReader) control(it *rtfItem, fm *rtfFormat) int {
+	on := !it.has || it.param != 0
+	switch it.word {
+	case "ansicpg":
+		r.cp = it.param
+	case "deff":
+		r.deff = it.param
+		fm.font = it.param
+	case "plain":
+		*fm = rtfFormat{font: r.deff, uc: fm.uc}
+	case "b":
+		fm.b = on
+	case "i":
+		fm.i = on
+	case "ul", "uld", "uldb", "ulw", "ulwave", "uldash", "ulth", "uldashd", "uldashdd", "ulhwave", "ulldash":
+		fm.u = on
+	case "ulnone":
+		fm.u = false
+	case "strike", "striked":
+		fm.s = on
+	case "v":
+		fm.hidden = on
+	case "super":
+		fm.vert = "super"
+	case "sub":
+		fm.vert = "sub"
+	case "nosupersub":
+		fm.vert = ""
+	case "f":
+		fm.font = it.param
+	case "fs":
+		fm.size = it.param
+	case "cf":
+		fm.color = it.param
+	case "highlight", "cb", "chcbpat":
+		fm.bg = it.param
+	case "uc":
+		if it.param >= 0 {
+			fm.uc = it.param
+		}
+	case "u":
+		c := it.param
+		if c < 0 {
+			c += 65536
+		}
+		r.unicode(rune(c), fm)
+		return fm.uc
+	case "pard":
+		r.para = rtfPara{}
+	case "ql":
+		r.para.align = "left"
+	case "qc":
+		r.para.align = "center"
+	case "qr":
+		r.para.align = "right"
+	case "qj", "qd":
+		r.para.align = "justify"
+	case "s":
+		r.para.style = it.param
+	case "outlinelevel":
+		r.para.outline = it.param + 1
+	case "ls":
+		r.para.ls = it.param
+	case "ilvl":
+		r.para.ilvl = it.param
+	case "intbl":
+		r.para.intbl = true
+	case "itap":
+		r.para.intbl = it.param > 0
+	case "par", "nestcell":
+		r.endPara()
+	case "line":
+		r.inline = append(r.inline, &htmlNode{tag: "br"})
+		r.merge = false
+	case "cell":
+		r.endCell()
+	case "row":
+		r.endRow()
+	case "trowd":
+		r.defs, r.def = nil, rtfCellDef{}
+	case "clmrg":
+		r.def.hmerge = true
+	case "clvmgf":
+		r.def.vfirst = true
+	case "clvmrg":
+		r.def.vmerge = true
+	case "cellx":
+		r.def.right = it.param
+		r.defs = append(r.defs, r.def)
+		r.def = rtfCellDef{}
+	default:
+		if s, ok := rtfSymbols[it.word]; ok {
+			r.text(s, fm)
+		}
+	}
+	return 0
+}
+
+// unicode adds the character of \u, joining the surrogates
+func (r *rtfReader) unicode(c rune, fm *rtfFormat) {
+	switch {
+	case c >= 0xD800 && c < 0xDC00:
+		r.high = c
+		return
+	case c >= 0xDC00 && c < 0xE000:
+		if r.high == 0 {
+			return
+		}
+		c = utf16.DecodeRune(r.high, c)
+	}
+	r.high = 0
+	r.text(string(c), fm)
+}
+
+// css converts fm into css
+func (r *rtfReader) css(fm *rtfFormat) string {
+	sb := strings.Builder{}
+	if fm.b {
+		sb.WriteString("font-weight:bold;")
+	}
+	if fm.i {
+		sb.WriteString("font-style:italic;")
+	}
+	switch {
+	case fm.u && fm.s:
+		sb.WriteString("text-decoration:underline line-through;")
+	case fm.u:
+		sb.WriteString("text-decoration:underline;")
+	case fm.s:
+		sb.WriteString("text-decoration:line-through;")
+	}
+	if fm.color > 0 && fm.color < len(r.colors) && r.colors[fm.color] != "" {
+		sb.WriteString("color:" + r.colors[fm.color] + ";")
+	}
+	if fm.bg > 0 && fm.bg < len(r.colors) && r.colors[fm.bg] != "" {
+		sb.WriteString("background-color:" + r.colors[fm.bg] + ";")
+	}
+	if fm.size > 0 {
+		sb.WriteString("font-size:" + strconv.FormatFloat(float64(fm.size)/2, 'f', -1, 64) + "pt;")
+	}
+	if f, ok := r.fonts[fm.font]; ok && fm.font != r.deff && f.name != "" {
+		sb.WriteString("font-family:'" + f.name + "';")
+	}
+	if fm.vert != "" {
+		sb.WriteString("vertical-align:" + fm.vert + ";")
+	}
+	return sb.String()
+}
+
+// text adds s in the formatting fm into the paragraph in progress
+func (r *rtfReader) text(s string, fm *rtfFormat) {
+	if s == "" || fm.hidden {
+		return
+	}
+	css := r.css(fm)
+	if n := len(r.inline); r.merge && n > 0 && r.inline[n-1].attr["style"] == css {
+		t := r.inline[n-1].children[0]
+		t.text += s
+		return
+	}
+	r.inline = append(r.inline, &htmlNode{
+		tag: "span", attr: map[string]string{"style": css},
+		children: []*htmlNode{{text: s, raw: true}},
+	})
+	r.merge = true
+}
+
+// endPara ends the paragraph in progress
+func (r *rtfReader) endPara() {
+	level := r.para.outline
+	if level == 0 {
+		level = r.headings[r.para.style]
+	}
+	tag := "p"
+	if level > 0 {
+		tag = "h" + strconv.Itoa(minInt(level, 6))
+	}
+	attr := make(map[string]string, 1)
+	if r.para.align != "" {
+		attr["style"] = "text-align:" + r.para.align
+	}
+	if css := r.styleCSS[r.para.style]; level > 0 && css != "" {
+		// the look of headings comes from the heading styles on import
+		unstyle(r.inline, strings.Split(css, ";"))
+	}
+	p := &htmlNode{tag: tag, attr: attr, children: r.inline}
+	r.inline, r.merge = nil, false
+	r.paras++
+	if !r.para.intbl && len(r.rows)+len(r.cells) > 0 {
+		r.endTable()
+	}
+	blocks := &r.blocks
+	if r.para.intbl {
+		blocks = &r.cell
+	}
+	if r.para.ls <= 0 || level > 0 {
+		r.lists = nil
+		*blocks = append(*blocks, p)
+		return
+	}
+	ilvl := r.para.ilvl
+	if ilvl < 0 || ilvl > 8 {
+		ilvl = 0
+	}
+	if len(r.lists) > ilvl+1 {
+		r.lists = r.lists[:ilvl+1]
+	}
+	if len(r.lists) == ilvl+1 && r.lists[ilvl].ls != r.para.ls {
+		r.lists = r.lists[:ilvl]
+	}
+	for len(r.lists) < ilvl+1 {
+		lv := odtLevel{}
+		if levels := r.levels[r.overrides[r.para.ls]]; len(r.lists) < len(levels) {
+			lv = levels[len(r.lists)]
+		}
+		n := &htmlNode{tag: "ul", attr: make(map[string]string, 1)}
+		if lv.ordered {
+			n.tag = "ol"
+			n.attr["start"] = strconv.Itoa(lv.start)
+		}
+		if len(r.lists) == 0 {
+			*blocks = append(*blocks, n)
+		} else {
+			parent := r.lists[len(r.lists)-1].node
+			if len(parent.children) == 0 {
+				parent.children = append(parent.children, &htmlNode{tag: "li", attr: map[string]string{}})
+			}
+			li := parent.children[len(parent.children)-1]
+			li.children = append(li.children, n)
+		}
+		r.lists = append(r.lists, rtfList{ls: r.para.ls, node: n})
+	}
+	l := r.lists[ilvl].node
+	l.children = append(l.children, &htmlNode{tag: "li", attr: map[string]string{}, children: []*htmlNode{p}})
+}
+
+// unstyle removes the css declarations in decls from the spans in nodes
+func unstyle(nodes []*htmlNode, decls []string) {
+	for _, n := range nodes {
+		if n.tag == "span" {
+			style := n.attr["style"]
+			for _, d := range decls {
+				if d != "" {
+					style = strings.Replace(style, d+";", "", 1)
+				}
+			}
+			n.attr["style"] = style
+		}
+		unstyle(n.children, decls)
+	}
+}
+
+// endCell ends the paragraph in progress and the cell
+func (r *rtfReader) endCell() {
+	r.para.intbl = true
+	r.endPara()
+	r.cells = append(r.cells, r.cell)
+	r.cell, r.lists = nil, nil
+}
+
+// endRow ends the row by the cells defined
+func (r *rtfReader) endRow() {
+	if r.cell != nil {
+		r.cells = append(r.cells, r.cell)
+		r.cell = nil
+	}
+	r.rows = append(r.rows, &rtfRow{cells: r.cells, defs: append([]rtfCellDef(nil), r.defs...)})
+	r.cells = nil
+}
+
+// rtfTableCell is a cell placed in the grid of the table
+type rtfTableCell struct {
+	blocks      []*htmlNode
+	left, right int // left and right are the indexes of the grid columns taken
+	vfirst      bool
+	vmerge      bool
+}
+
+// endTable adds the table of the rows, taking the columns by the boundaries of \cellx
+func (r *rtfReader) endTable() {
+	if r.cells != nil || r.cell != nil {
+		r.endRow()
+	}
+	rows, bounds := make([][]*rtfTableCell, len(r.rows)), map[int]bool{}
+	for i, row := range r.rows {
+		x := 0
+		for j, blocks := range row.cells {
+			def := rtfCellDef{right: x + 1440}
+			if j < len(row.defs) {
+				def = row.defs[j]
+			}
+			if def.right <= x {
+				def.right = x + 1
+			}
+			if def.hmerge && len(rows[i]) > 0 {
+				rows[i][len(rows[i])-1].right = def.right
+			} else {
+				rows[i] = append(rows[i], &rtfTableCell{
+					blocks: blocks, left: x, right: def.right, vfirst: def.vfirst, vmerge: def.vmerge && !def.vfirst,
+				})
+			}
+			x = def.right
+			bounds[x] = true
+		}
+	}
+	grid := make([]int, 0, len(bounds)+1)
+	grid = append(grid, 0)
+	for x := range bounds {
+		grid = append(grid, x)
+	}
+	sort.Ints(grid)
+	col := func(x int) int {
+		return sort.SearchInts(grid, x)
+	}
+	for _, row := range rows {
+		for _, c := range row {
+			c.left, c.right = col(c.left), col(c.right)
+		}
+	}
+	table := &htmlNode{tag: "table", attr: map[string]string{}}
+	for i, row := range rows {
+		tr := &htmlNode{tag: "tr", attr: map[string]string{}}
+		for _, c := range row {
+			if c.vmerge {
+				continue
+			}
+			attr := map[string]string{}
+			if span := c.right - c.left; span > 1 {
+				attr["colspan"] = strconv.Itoa(span)
+			}
+			if c.vfirst {
+				n := 1
+			next:
+				for _, nr := range rows[i+1:] {
+					for _, nc := range nr {
+						if nc.left == c.left {
+							if !nc.vmerge {
+								break next
+							}
+							n++
+							continue next
+						}
+					}
+					break
+				}
+				if n > 1 {
+					attr["rowspan"] = strconv.Itoa(n)
+				}
+			}
+			tr.children = append(tr.children, &htmlNode{tag: "td", attr: attr, children: c.blocks})
+		}
+		table.children = append(table.children, tr)
+	}
+	r.rows, r.lists = nil, nil
+	r.blocks = append(r.blocks, table)
+}
+
+// finish ends the paragraph and the table in progress
+func (r *rtfReader) finish() {
+	if len(r.inline) > 0 {
+		r.endPara()
+	}
+	if len(r.rows)+len(r.cells) > 0 || r.cell != nil {
+		r.endTable()
+	}
+}
+
+// plain collects the texts in g
+func (r *rtfReader) plain(g *rtfItem) string {
+	var b []byte
+	var walk func(g *rtfItem)
+	walk = func(g *rtfItem) {
+		for _, it := range g.children {
+			switch {
+			case it.group:
+				walk(it)
+			case it.word == "":
+				b = append(b, it.text...)
+			case it.word == "'":
+				b = append(b, byte(it.param))
+			}
+		}
+	}
+	walk(g)
+	return rtfDecode(b, r.cp)
+}
+
+// fontTable reads \fonttbl
+func (r *rtfReader) fontTable(g *rtfItem) {
+	id, cp := 0, 0
+	var name []byte
+	var walk func(g *rtfItem)
+	walk = func(g *rtfItem) {
+		for _, it := range g.children {
+			if it.group {
+				if _, star := rtfDest(it); !star {
+					walk(it)
+				}
+				continue
+			}
+			switch it.word {
+			case "f":
+				id, cp, name = it.param, 0, nil
+			case "fcharset":
+				cp = rtfCharsets[it.param]
+			case "'":
+				name = append(name, byte(it.param))
+			case "":
+				for _, c := range it.text {
+					if c != ';' {
+						name = append(name, c)
+						continue
+					}
+					c := cp
+					if c == 0 {
+						c = r.cp
+					}
+					r.fonts[id] = rtfFont{name: strings.TrimSpace(rtfDecode(name, c)), cp: cp}
+					name = nil
+				}
+			}
+		}
+	}
+	walk(g)
+}
+
+// colorTable reads \colortbl
+func (r *rtfReader) colorTable(g *rtfItem) {
+	rgb, set := [3]int{}, false
+	for _, it := range g.children {
+		switch it.word {
+		case "red":
+			rgb[0], set = it.param, true
+		case "green":
+			rgb[1], set = it.param, true
+		case "blue":
+			rgb[2], set = it.param, true
+		case "":
+			for _, c := range it.text {
+				if c != ';' {
+					continue
+				}
+				c := ""
+				if set {
+					c = "#" + hex.EncodeToString([]byte{byte(rgb[0]), byte(rgb[1]), byte(rgb[2])})
+				}
+				r.colors = append(r.colors, c)
+				rgb, set = [3]int{}, false
+			}
+		}
+	}
+}
+
+// colorIndex returns the index of css color c in the color table, adding it on need
+func (r *rtfReader) colorIndex(c string) int {
+	if len(r.colors) == 0 {
+		r.colors = append(r.colors, "")
+	}
+	for i, v := range r.colors[1:] {
+		if v == c {
+			return i + 1
+		}
+	}
+	r.colors = append(r.colors, c)
+	return len(r.colors) - 1
+}
+
+// stylesheet reads the heading levels of the paragraph styles
+func (r *rtfReader) stylesheet(g *rtfItem) {
+	for _, st := range g.children {
+		if !st.group {
+			continue
+		}
+		if _, star := rtfDest(st); star {
+			continue
+		}
+		id, level := 0, 0
+		for _, it := range st.children {
+			switch it.word {
+			case "s":
+				id = it.param
+			case "outlinelevel":
+				level = it.param + 1
+			case "cs", "ds", "ts", "tsrowd":
+				id = -1
+			}
+		}
+		if id < 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(r.plain(st)), ";")))
+		if s := strings.TrimPrefix(name, "heading "); s != name {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 {
+				level = n
+			}
+		}
+		if level > 0 && level <= 9 {
+			r.headings[id] = level
+			para, fm := r.para, rtfFormat{font: r.deff}
+			for _, it := range st.children {
+				if !it.group && it.word != "" && it.word != "'" {
+					r.control(it, &fm)
+				}
+			}
+			r.para = para
+			r.styleCSS[id] = r.css(&fm)
+		}
+	}
+}
+
+// listTable reads the kinds and the starts of the levels in \listtable
+func (r *rtfReader) listTable(g *rtfItem) {
+	for _, l := range g.children {
+		if !l.group {
+			continue
+		}
+		if dest, _ := rtfDest(l); dest != "list" {
+			continue
+		}
+		id := 0
+		var levels []odtLevel
+		for _, it := range l.children {
+			if !it.group {
+				if it.word == "listid" {
+					id = it.param
+				}
+				continue
+			}
+			if dest, _ := rtfDest(it); dest != "listlevel" {
+				continue
+			}
+			lv := odtLevel{ordered: true, start: 1}
+			for _, w := range it.children {
+				switch w.word {
+				case "levelnfc":
+					lv.ordered = w.param != 23 && w.param != 255
+				case "levelstartat":
+					lv.start = w.param
+				}
+			}
+			levels = append(levels, lv)
+		}
+		r.levels[id] = levels
+	}
+}
+
+// overrideTable reads the \ls of the lists in \listoverridetable
+func (r *rtfReader) overrideTable(g *rtfItem) {
+	for _, o := range g.children {
+		if !o.group {
+			continue
+		}
+		id, ls := 0, 0
+		for _, it := range o.children {
+			switch it.word {
+			case "listid":
+				id = it.param
+			case "ls":
+				ls = it.param
+			}
+		}
+		r.overrides[ls] = id
+	}
+}
+
+// rtfHyperlink returns the target of the instruction of a HYPERLINK field
+func rtfHyperlink(inst string) string {
+	inst = strings.TrimSpace(inst)
+	if len(inst) < 9 || !strings.EqualFold(inst[:9], "HYPERLINK") {
+		return ""
+	}
+	var args []string
+	for s := strings.TrimSpace(inst[9:]); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			arg, rest, _ := strings.Cut(s[1:], `"`)
+			args = append(args, arg)
+			s = rest
+			continue
+		}
+		k := strings.IndexAny(s, " \t")
+		if k < 0 {
+			k = len(s)
+		}
+		args = append(args, s[:k])
+		s = s[k:]
+	}
+	target, anchor := "", ""
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == `\l` && i+1 < len(args):
+			anchor = args[i+1]
+			i++
+		case strings.HasPrefix(a, `\`):
+			if a == `\o` || a == `\t` {
+				i++
+			}
+		case target == "":
+			target = a
+		}
+	}
+	if anchor != "" {
+		return target + "#" + anchor
+	}
+	return target
+}
+
+// field converts a field, wrapping the result of a HYPERLINK into a link
+func (r *rtfReader) field(g *rtfItem, fm rtfFormat, depth int) {
+	inst := ""
+	var result *rtfItem
+	for _, it := range g.children {
+		if !it.group {
+			continue
+		}
+		switch dest, _ := rtfDest(it); dest {
+		case "fldinst":
+			inst = r.plain(it)
+		case "fldrslt":
+			result = it
+		}
+	}
+	mark, paras := len(r.inline), r.paras
+	r.merge = false
+	if result != nil {
+		r.walk(result, fm, depth+1)
+	}
+	r.link(mark, paras, rtfHyperlink(inst))
+}
+
+// link wraps the contents added since mark into a link to href,
+// if the paragraph is still the one when mark is taken
+func (r *rtfReader) link(mark, paras int, href string) {
+	if href == "" || r.paras != paras || mark > len(r.inline) {
+		return
+	}
+	a := &htmlNode{tag: "a", attr: map[string]string{"href": href}, children: append([]*htmlNode(nil), r.inline[mark:]...)}
+	r.inline = append(r.inline[:mark], a)
+	r.merge = false
+}
+
+// pict adds the PNG or JPEG picture in g
+func (r *rtfReader) pict(g *rtfItem) {
+	ext := ""
+	wgoal, hgoal, sx, sy := 0, 0, 100, 100
+	var data, hexs []byte
+	for _, it := range g.children {
+		if it.group {
+			continue
+		}
+		switch it.word {
+		case "pngblip":
+			ext = ".png"
+		case "jpegblip":
+			ext = ".jpeg"
+		case "picwgoal":
+			wgoal = it.param
+		case "pichgoal":
+			hgoal = it.param
+		case "picscalex":
+			sx = it.param
+		case "picscaley":
+			sy = it.param
+		case "bin":
+			data = it.text
+		case "":
+			hexs = append(hexs, it.text...)
+		}
+	}
+	if ext == "" {
+		return
+	}
+	if data == nil {
+		digits := hexs[:0]
+		for _, c := range hexs {
+			if isRTFDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+				digits = append(digits, c)
+			}
+		}
+		data = make([]byte, len(digits)/2)
+		n, _ := hex.Decode(data, digits[:len(data)*2])
+		data = data[:n]
+	}
+	if len(data) == 0 {
+		return
+	}
+	r.image(data, ext, wgoal*sx/100, hgoal*sy/100)
+}
+
+// image adds the picture data of the extension ext in the size of twips
+func (r *rtfReader) image(data []byte, ext string, w, h int) {
+	name := "pict" + strconv.Itoa(len(r.pictures)+1) + ext
+	r.pictures[name] = data
+	style := ""
+	if w > 0 && h > 0 {
+		style = "width:" + strconv.FormatFloat(float64(w)/20, 'f', -1, 64) + "pt;height:" +
+			strconv.FormatFloat(float64(h)/20, 'f', -1, 64) + "pt;"
+	}
+	r.inline = append(r.inline, &htmlNode{tag: "img", attr: map[string]string{"src": name, "style": style}})
+	r.merge = false
+}
+
+// shape converts the picture and the text box of \shpinst
+func (r *rtfReader) shape(g *rtfItem, fm rtfFormat, depth int) {
+	for _, it := range g.children {
+		if !it.group {
+			continue
+		}
+		switch dest, _ := rtfDest(it); dest {
+		case "sp":
+			var sn string
+			var sv *rtfItem
+			for _, c := range it.children {
+				if !c.group {
+					continue
+				}
+				switch d, _ := rtfDest(c); d {
+				case "sn":
+					sn = strings.TrimSpace(r.plain(c))
+				case "sv":
+					sv = c
+				}
+			}
+			if sn == "pib" && sv != nil {
+				r.walk(sv, fm, depth+1)
+			}
+		case "shptxt":
+			r.walk(it, fm, depth+1)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// Match is an occurrence found by Search
+type Match struct {
+	// Part is the name of the part, like word/document.xml or word/footnotes.xml
+	Part string
+	// Parents are the containers from the part root to the paragraph,
+	// like *Body, *Table, *WTableRow, *WTableCell or *WordprocessingShape
+	Parents []interface{}
+	// Path is the index of each of Parents and the paragraph in its
+	// container, in which -1 means it is not in a slice (like the root)
+	Path []int
+	// Paragraph contains the match
+	Paragraph *Paragraph
+	// Start and End are the rune offsets [Start, End) in Paragraph.String()
+	Start, End int
+	// Text is the matched text
+	Text string
+	// Runs contain the match
+	Runs []*Run
+}
+
+// Range returns the range of the match for formatting or editing.
+//
+// Editing a match changes the offsets after it in the same paragraph,
+// so process the matches in reverse order if you replace them.
+func (m *Match) Range() (*Range, error) {
+	return m.Paragraph.Range(m.Start, m.End)
+}
+
+// Search finds re in every paragraph of body, headers, footers,
+// footnotes and comments, including the ones in tables and text boxes.
+//
+// Only the text in runs and the text of hyperlinks are searched, so a match
+// never spans paragraphs, hyperlinks, drawings, link targets or the list
+// indents in Paragraph.String.
+func (f *Docx) Search(re *regexp.Regexp) ([]*Match, error) {
+	var matches []*Match
+	err := Walk(f, VisitorFuncs{EnterFunc: func(c *Cursor) WalkAction {
+		p, ok := c.Node().(*Paragraph)
+		if !ok {
+			return WalkContinue
+		}
+		s := p.String()
+		var parents []interface{}
+		var path []int
+		for _, seg := range p.textSegments() {
+			i := runeIndex(s, seg[0])
+			text := s[i : i+runeIndex(s[i:], seg[1]-seg[0])]
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				if parents == nil {
+					parents, path = c.Parents(), c.Indices()
+				}
+				m := &Match{Part: c.Part(), Parents: parents, Path: path, Paragraph: p, Text: text[loc[0]:loc[1]]}
+				m.Start = seg[0] + utf8.RuneCountInString(text[:loc[0]])
+				m.End = m.Start + utf8.RuneCountInString(m.Text)
+				m.Runs = p.runsIn(m.Start, m.End)
+				matches = append(matches, m)
+			}
+		}
+		return WalkContinue
+	}})
+	return matches, err
+}
+
+// textSegments returns the rune offsets [start, end) in p.String() of the
+// texts, tabs and breaks in runs and the texts of hyperlinks, which are cut
+// by the other elements
+func (p *Paragraph) textSegments() [][2]int {
+	var segs [][2]int
+	pos := utf8.RuneCountInString(p.indentString())
+	start := pos
+	skip := func(l int) {
+		if l == 0 {
+			return
+		}
+		if pos > start {
+			segs = append(segs, [2]int{start, pos})
+		}
+		pos += l
+		start = pos
+	}
+	for _, c := range p.Children {
+		switch o := c.(type) {
+		case *Run:
+			for _, rc := range o.Children {
+				l := utf8.RuneCountInString(runChildString(rc))
+				switch rc.(type) {
+				case *Text, *Tab, *BarterRabbet:
+					pos += l
+				default:
+					skip(l)
+				}
+			}
+		case *Hyperlink:
+			// only the text of [text](target)
+			l := utf8.RuneCountInString(o.Run.InstrText)
+			skip(1)
+			pos += l
+			skip(p.childRuneLen(c) - l - 1)
+		default:
+			skip(p.childRuneLen(c))
+		}
+	}
+	if pos > start {
+		segs = append(segs, [2]int{start, pos})
+	}
+	return segs
+}
+
+// runsIn returns the runs overlapping rune offsets [start, end)
+func (p *Paragraph) runsIn(start, end int) []*Run {
+	var runs []*Run
+	pos := utf8.RuneCountInString(p.indentString())
+	for _, c := range p.Children {
+		l := p.childRuneLen(c)
+		if pos < end && pos+l > start {
+			switch o := c.(type) {
+			case *Run:
+				runs = append(runs, o)
+			case *Hyperlink:
+				runs = append(runs, &o.Run)
+			}
+		}
+		pos += l
+		if pos > end {
+			break
+		}
+	}
+	return runs
+}
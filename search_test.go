@@ -0,0 +1,107 @@
+package docx
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	f := New().WithDefaultTheme()
+	p := f.AddParagraph()
+	p.AddText("the secret ")
+	p.AddText("code is se").Bold()
+	p.AddText("cret")
+	tbl := f.AddTable(1, 2, 0, nil)
+	tbl.TableRows[0].TableCells[1].AddParagraph().AddText("中文 secret")
+
+	matches, err := f.Search(regexp.MustCompile(`secret`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatal("unexpected matches", len(matches))
+	}
+	m := matches[0]
+	if m.Part != "word/document.xml" || m.Paragraph != p || m.Start != 4 || m.End != 10 || len(m.Runs) != 1 {
+		t.Fatal("unexpected match", m)
+	}
+	m = matches[1]
+	if m.Start != 19 || m.End != 25 || len(m.Runs) != 2 || m.Runs[0].RunProperties.Bold == nil {
+		t.Fatal("unexpected match", m)
+	}
+	m = matches[2]
+	if m.Start != 3 || m.End != 9 || m.Text != "secret" {
+		t.Fatal("unexpected match", m)
+	}
+	if len(m.Path) != 5 || m.Path[1] != 1 || m.Path[2] != 0 || m.Path[3] != 1 || m.Path[4] != 0 {
+		t.Fatal("unexpected path", m.Path)
+	}
+	if _, ok := m.Parents[3].(*WTableCell); !ok {
+		t.Fatal("unexpected parents", m.Parents)
+	}
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		r, err := matches[i].Range()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.Replace("******")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.String() != "the ****** code is ******" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+}
+
+func TestSearchSkipsLinkTargets(t *testing.T) {
+	f := New().WithDefaultTheme()
+	p := f.AddParagraph()
+	p.AddText("see ")
+	p.AddLink("site", "https://example.com/foo")
+	p.AddText(" foo")
+	matches, err := f.Search(regexp.MustCompile(`foo`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatal("unexpected matches", len(matches))
+	}
+	r, err := matches[0].Range()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Replace("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "see [site](https://example.com/foo) bar" {
+		t.Fatalf("unexpected paragraph %q", p.String())
+	}
+	matches, err = f.Search(regexp.MustCompile(`see.*bar`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Fatal("unexpected match across the link", matches[0].Text)
+	}
+}
+
+func TestSearchInLinkText(t *testing.T) {
+	f := New().WithDefaultTheme()
+	p := f.AddParagraph()
+	p.AddText("see ")
+	link := p.AddLink("the site", "https://example.com/site")
+	matches, err := f.Search(regexp.MustCompile(`site`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatal("unexpected matches", len(matches))
+	}
+	m := matches[0]
+	if m.Start != 9 || m.End != 13 || m.Text != "site" || len(m.Runs) != 1 || m.Runs[0] != &link.Run {
+		t.Fatal("unexpected match", m)
+	}
+}
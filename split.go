@@ -0,0 +1,364 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import "strings"
+
+// SplitRule checks whether item is a separator,
+// n is the count of items already in the current part
+type SplitRule func(item interface{}, n int) bool
+
+// SplitSeparator decides where the separator goes on splitting
+type SplitSeparator uint8
+
+const (
+	// SeparatorToNext places the separator at the beginning of the next part
+	SeparatorToNext SplitSeparator = iota
+	// SeparatorToPrevious places the separator at the end of the previous part
+	SeparatorToPrevious
+	// SeparatorDropped drops the separator
+	SeparatorDropped
+)
+
+// SplitOptions controls how Split builds each part
+//
+// The zero value behaves like SplitByParagraph.
+type SplitOptions struct {
+	Separator SplitSeparator
+	// WithStyles copies the loaded (and maybe modified) styles and numbering
+	// definitions into each part. Otherwise each part packs them from the
+	// template as they were parsed.
+	WithStyles bool
+	// WithNumbering keeps the numbering relationship so that lists
+	// are still numbered in each part
+	WithNumbering bool
+	// WithHeaderFooter keeps the headers and footers referred by the sections.
+	// Otherwise the references are dropped.
+	WithHeaderFooter bool
+	// WithSectPr ends each part with the section properties
+	// (page size, margins...) that apply to its last item
+	WithSectPr bool
+}
+
+// SplitDocxByParagraph uses the paragraphs matching rule as separators
+func SplitDocxByParagraph(rule ParagraphSplitRule) SplitRule {
+	return func(item interface{}, _ int) bool {
+		p, ok := item.(*Paragraph)
+		return ok && rule(p)
+	}
+}
+
+// SplitDocxByHeading uses the headings of level 1 to level as separators
+func SplitDocxByHeading(level int) SplitRule {
+	return func(item interface{}, _ int) bool {
+		p, ok := item.(*Paragraph)
+		if !ok {
+			return false
+		}
+		lv := p.file.HeadingLevel(p)
+		return lv > 0 && lv <= level
+	}
+}
+
+// SplitDocxBySectionBreak uses the last paragraph of each section
+// as separator, which is usually used with SeparatorToPrevious
+func SplitDocxBySectionBreak() SplitRule {
+	return func(item interface{}, _ int) bool {
+		p, ok := item.(*Paragraph)
+		return ok && p.Properties != nil && p.Properties.SectPr != nil
+	}
+}
+
+// SplitDocxByPageBreak uses the paragraphs that contain a page break as separators
+func SplitDocxByPageBreak() SplitRule {
+	return func(item interface{}, _ int) bool {
+		p, ok := item.(*Paragraph)
+		if !ok {
+			return false
+		}
+		for _, pc := range p.Children {
+			r, ok := pc.(*Run)
+			if !ok {
+				continue
+			}
+			for _, rc := range r.Children {
+				if br, ok := rc.(*BarterRabbet); ok && br.Type == "page" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// SplitDocxByItemCount splits the body every count items,
+// in which a table counts as one item. The body sectPr
+// will never be split from the last part.
+//
+// The item reaching the count is the separator, so each part has count
+// items only in SeparatorToNext. SeparatorToPrevious ends each part with
+// it, making count+1 items, and SeparatorDropped drops it.
+func SplitDocxByItemCount(count int) SplitRule {
+	return func(item interface{}, n int) bool {
+		if _, ok := item.(*SectPr); ok {
+			return false
+		}
+		return n >= count
+	}
+}
+
+// Split splits a doc to many docs by the separators matched by rule
+func (f *Docx) Split(rule SplitRule, opt SplitOptions) (docs []*Docx) {
+	items := f.Document.Body.Items
+	var govern []int // govern is the index of the sectPr applied to each item
+	if opt.WithSectPr {
+		govern = make([]int, len(items))
+		g := -1
+		for i := len(items) - 1; i >= 0; i-- {
+			switch o := items[i].(type) {
+			case *SectPr:
+				g = i
+			case *Paragraph:
+				if o.Properties != nil && o.Properties.SectPr != nil {
+					g = i
+				}
+			}
+			govern[i] = g
+		}
+	}
+	var ndoc *Docx
+	last := -1
+	finish := func() {
+		if ndoc != nil && len(ndoc.Document.Body.Items) > 0 {
+			if opt.WithSectPr {
+				f.endPartSection(ndoc, items, govern[last], last, opt)
+			}
+			docs = append(docs, ndoc)
+		}
+		ndoc = nil
+	}
+	for i, item := range items {
+		if ndoc == nil {
+			ndoc = f.newPart(opt)
+		}
+		if !rule(item, len(ndoc.Document.Body.Items)) {
+			f.copyItemTo(ndoc, item, opt)
+			last = i
+			continue
+		}
+		switch opt.Separator {
+		case SeparatorToNext:
+			if len(ndoc.Document.Body.Items) > 0 {
+				finish()
+				ndoc = f.newPart(opt)
+			}
+			f.copyItemTo(ndoc, item, opt)
+			last = i
+		case SeparatorToPrevious:
+			f.copyItemTo(ndoc, item, opt)
+			last = i
+			finish()
+		default:
+			finish()
+		}
+	}
+	finish()
+	return
+}
+
+// splitKeptRelations are the types of the relationships kept in each part
+var splitKeptRelations = []string{
+	REL_STYLES,
+	`http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme`,
+	`http://schemas.openxmlformats.org/officeDocument/2006/relationships/fontTable`,
+}
+
+// newPart makes an empty docx sharing the template of f
+func (f *Docx) newPart(opt SplitOptions) *Docx {
+	ndoc := new(Docx)
+
+	// migrate base data
+	ndoc.mediaNameIdx = make(map[string]int, 64)
+	ndoc.slowIDs = make(map[string]uintptr, 64)
+	ndoc.template = f.template
+	ndoc.tmplfs = f.tmplfs
+	ndoc.tmpfslst = f.tmpfslst
+	ndoc.fixedTime = f.fixedTime
+	if f.parts != nil {
+		ndoc.parts = make(map[string][]byte, len(f.parts))
+		for name, data := range f.parts {
+			ndoc.parts[name] = data
+		}
+	}
+
+	ndoc.Document.XMLW = XMLNS_W
+	ndoc.Document.XMLR = XMLNS_R
+	ndoc.Document.XMLWP = XMLNS_WP
+	// ndoc.Document.XMLMC = XMLNS_MC
+	// ndoc.Document.XMLO = XMLNS_O
+	// ndoc.Document.XMLV = XMLNS_V
+	ndoc.Document.XMLWPS = XMLNS_WPS
+	ndoc.Document.XMLWPC = XMLNS_WPC
+	ndoc.Document.XMLWPG = XMLNS_WPG
+	// ndoc.Document.XMLWP14 = XMLNS_WP14
+	ndoc.Document.XMLName.Space = XMLNS_W
+	ndoc.Document.XMLName.Local = "document"
+	ndoc.Document.Body.file = ndoc
+
+	ndoc.docRelation = Relationships{Xmlns: XMLNS_REL}
+	if opt.WithStyles {
+		if f.styles != nil {
+			ndoc.styles = f.styles.Copy()
+		}
+		if f.numbering != nil {
+			ndoc.numbering = f.numbering.Copy()
+		}
+	}
+	// keep the relationships of the styles, theme and fonts of f
+	// whose parts are packed in ndoc
+	for _, typ := range splitKeptRelations {
+		for _, r := range f.docRelation.Relationship {
+			if r.Type != typ || r.TargetMode != "" {
+				continue
+			}
+			if (typ == REL_STYLES && ndoc.styles != nil) || ndoc.hasTemplateFile(partTarget("word/document.xml", r.Target)) {
+				ndoc.addRelation(r.Type, r.Target)
+			}
+			break
+		}
+	}
+
+	if opt.WithNumbering && f.hasNumbering() {
+		ndoc.ensureNumberingRelation()
+	}
+	if opt.WithHeaderFooter {
+		// avoid name conflicts with the media of headers and footers
+		ndoc.imageID = f.imageID
+	}
+	return ndoc
+}
+
+// copyItemTo appends a copy of the body item to ndoc
+func (f *Docx) copyItemTo(ndoc *Docx, item interface{}, opt SplitOptions) {
+	switch o := item.(type) {
+	case *Paragraph:
+		np := o.copymedia(ndoc)
+		if np.Properties != nil && np.Properties.SectPr != nil {
+			pp := *np.Properties
+			pp.SectPr = f.copySectPr(ndoc, pp.SectPr, opt.WithHeaderFooter)
+			np.Properties = &pp
+		}
+		ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, &np)
+	case *Table:
+		nt := o.copymedia(ndoc)
+		ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, &nt)
+	case *SectPr:
+		ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, f.copySectPr(ndoc, o, opt.WithHeaderFooter))
+	default:
+		ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, o)
+	}
+}
+
+// endPartSection appends the sectPr at items[g] to the end of ndoc,
+// moving it out from the last paragraph if it is there.
+func (f *Docx) endPartSection(ndoc *Docx, items []interface{}, g, last int, opt SplitOptions) {
+	nitems := ndoc.Document.Body.Items
+	if g < 0 {
+		return
+	}
+	if _, ok := nitems[len(nitems)-1].(*SectPr); ok {
+		return
+	}
+	var sect *SectPr
+	switch o := items[g].(type) {
+	case *SectPr:
+		sect = o
+	case *Paragraph:
+		sect = o.Properties.SectPr
+		if g == last {
+			np := nitems[len(nitems)-1].(*Paragraph)
+			pp := *np.Properties
+			pp.SectPr = nil
+			np.Properties = &pp
+		}
+	}
+	ndoc.Document.Body.Items = append(nitems, f.copySectPr(ndoc, sect, opt.WithHeaderFooter))
+}
+
+// copySectPr copies s for ndoc, with its header and footer
+// references moved into ndoc or dropped.
+func (f *Docx) copySectPr(ndoc *Docx, s *SectPr, withhf bool) *SectPr {
+	ns := *s
+	ns.HeaderReferences = nil
+	ns.FooterReferences = nil
+	if !withhf {
+		return &ns
+	}
+	for _, h := range s.HeaderReferences {
+		if id := f.copyPartRelation(ndoc, h.ID); id != "" {
+			ns.HeaderReferences = append(ns.HeaderReferences, &HeaderReference{Type: h.Type, ID: id})
+		}
+	}
+	for _, h := range s.FooterReferences {
+		if id := f.copyPartRelation(ndoc, h.ID); id != "" {
+			ns.FooterReferences = append(ns.FooterReferences, &FooterReference{Type: h.Type, ID: id})
+		}
+	}
+	return &ns
+}
+
+// copyPartRelation copies the relationship id of f into ndoc and returns
+// the new id, together with the media used by the target part.
+func (f *Docx) copyPartRelation(ndoc *Docx, id string) string {
+	var rel *Relationship
+	for i, r := range f.docRelation.Relationship {
+		if r.ID == id {
+			rel = &f.docRelation.Relationship[i]
+			break
+		}
+	}
+	if rel == nil {
+		return ""
+	}
+	for _, r := range ndoc.docRelation.Relationship {
+		if r.Type == rel.Type && r.Target == rel.Target {
+			return r.ID
+		}
+	}
+	nid := ndoc.addRelation(rel.Type, rel.Target)
+	var rels Relationships
+	if f.decodeTemplate(relationshipsName(partTarget("word/document.xml", rel.Target)), &rels) != nil {
+		return nid
+	}
+	for _, r := range rels.Relationship {
+		if r.Type != REL_IMAGE || !strings.HasPrefix(r.Target, "media/") {
+			continue
+		}
+		name := r.Target[len("media/"):]
+		if ndoc.Media(name) != nil {
+			continue
+		}
+		if m := f.Media(name); m != nil {
+			ndoc.addMedia(*m)
+		}
+	}
+	return nid
+}
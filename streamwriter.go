@@ -0,0 +1,142 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// ErrStreamClosed is returned on writing to a closed StreamWriter
+var ErrStreamClosed = errors.New("stream writer closed")
+
+// StreamWriter writes the body of a docx into the zip item by item,
+// so that the body items need not be held in memory all at once.
+//
+// The items added to its Docx, e.g. by AddParagraph and AddTable,
+// are written and dropped on Flush. The relationships, media and
+// other parts are collected as usual and written on Close.
+type StreamWriter struct {
+	f      *Docx
+	opt    *WriteOptions
+	zw     *zip.Writer
+	w      io.Writer // w is word/document.xml in zw
+	enc    *xml.Encoder
+	tail   []byte // tail is the closing tags of body and document
+	closed bool
+}
+
+// NewStreamWriter starts writing f to w in the compression of opt.
+// The items already in the body of f are written on the first Flush.
+//
+// Unlike WriteTo, word/document.xml is the first entry of the zip
+// rather than [Content_Types].xml and _rels/.rels, because the content
+// types depend on the parts added until Close, while the body must be
+// written as it is streamed. The package is still valid, as OPC does
+// not require the order.
+func (f *Docx) NewStreamWriter(w io.Writer, opt *WriteOptions) (*StreamWriter, error) {
+	if opt == nil {
+		opt = &WriteOptions{}
+	}
+	zw := zip.NewWriter(w)
+	registerCompressor(zw, opt)
+	dw, err := zw.CreateHeader(&zip.FileHeader{
+		Name: "word/document.xml", Method: opt.method("word/document.xml"), Modified: f.packTime(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// split the empty document into its opening and closing tags
+	doc := f.Document
+	doc.Body = Body{}
+	data, err := xml.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndex(data, []byte("</w:body>"))
+	if i < 0 {
+		return nil, errors.New("unexpected document: " + string(data))
+	}
+	_, err = io.WriteString(dw, xml.Header)
+	if err == nil {
+		_, err = dw.Write(data[:i])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &StreamWriter{f: f, opt: opt, zw: zw, w: dw, enc: xml.NewEncoder(dw), tail: data[i:]}, nil
+}
+
+// Docx returns the file to add the body items to
+func (sw *StreamWriter) Docx() *Docx {
+	return sw.f
+}
+
+// Append adds items to the body and writes them at once
+func (sw *StreamWriter) Append(items ...interface{}) error {
+	sw.f.Document.Body.Items = append(sw.f.Document.Body.Items, items...)
+	return sw.Flush()
+}
+
+// Flush writes the items in the body and drops them
+func (sw *StreamWriter) Flush() error {
+	if sw.closed {
+		return ErrStreamClosed
+	}
+	items := sw.f.Document.Body.Items
+	for i, item := range items {
+		err := sw.enc.Encode(item)
+		if err != nil {
+			sw.f.Document.Body.Items = items[i:]
+			return err
+		}
+		items[i] = nil
+	}
+	sw.f.Document.Body.Items = items[:0]
+	return sw.enc.Flush()
+}
+
+// Close flushes the remaining items, then writes the other parts
+// and finishes the zip. It does not close the underlying writer.
+func (sw *StreamWriter) Close() error {
+	err := sw.Flush()
+	if err != nil {
+		return err
+	}
+	sw.closed = true
+	_, err = sw.w.Write(sw.tail)
+	if err != nil {
+		return err
+	}
+	files, err := sw.f.packFiles(true)
+	if err != nil {
+		return err
+	}
+	err = sw.f.packWrite(sw.zw, files, sw.opt)
+	if err != nil {
+		_ = sw.zw.Close()
+		return err
+	}
+	return sw.zw.Close()
+}
@@ -0,0 +1,80 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("head")
+	sw, err := f.NewStreamWriter(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		sw.Docx().AddParagraph().AddText("line " + strconv.Itoa(i))
+		err = sw.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(f.Document.Body.Items) != 0 {
+			t.Fatal("items not dropped")
+		}
+	}
+	tbl := f.AddTable(3, 2, 0, nil)
+	tbl.TableRows[2].TableCells[1].AddParagraph().AddText("cell")
+	_, err = f.AddParagraph().AddInlineDrawingFrom("testdata/fumiamayoko.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sw.Flush() != ErrStreamClosed {
+		t.Fatal("unexpected flush after close")
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := nf.Document.Body.Items
+	if len(items) != 103 {
+		t.Fatal("unexpected item count", len(items))
+	}
+	if p := items[0].(*Paragraph); p.String() != "head" {
+		t.Fatal("unexpected first item", p.String())
+	}
+	if p := items[100].(*Paragraph); p.String() != "line 99" {
+		t.Fatal("unexpected line", p.String())
+	}
+	if tb, ok := items[101].(*Table); !ok || len(tb.TableRows) != 3 {
+		t.Fatal("unexpected table", items[101])
+	}
+	if len(nf.media) != 1 || !bytes.Equal(nf.mediaData(nf.media[0].Name), f.media[0].Data) {
+		t.Fatal("unexpected media")
+	}
+	ct, err := nf.ContentTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Lookup("word/document.xml") == "" {
+		t.Fatal("missing content type of document")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Name != "word/document.xml" || zr.File[1].Name != "[Content_Types].xml" || zr.File[2].Name != "_rels/.rels" {
+		t.Fatal("unexpected order", zr.File[0].Name, zr.File[1].Name, zr.File[2].Name)
+	}
+	for _, file := range zr.File[1:] {
+		if file.Name == "word/document.xml" {
+			t.Fatal("document written twice")
+		}
+	}
+}
@@ -0,0 +1,36 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import "encoding/xml"
+
+// BookmarkStart <w:bookmarkStart>
+type BookmarkStart struct {
+	XMLName xml.Name `xml:"w:bookmarkStart"`
+	ID      string   `xml:"w:id,attr"`
+	Name    string   `xml:"w:name,attr"`
+}
+
+// BookmarkEnd <w:bookmarkEnd>
+type BookmarkEnd struct {
+	XMLName xml.Name `xml:"w:bookmarkEnd"`
+	ID      string   `xml:"w:id,attr"`
+}
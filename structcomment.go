@@ -0,0 +1,129 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+//nolint:revive,stylecheck
+const (
+	REL_COMMENTS = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments`
+)
+
+// Comments is word/comments.xml <w:comments>
+type Comments struct {
+	XMLName xml.Name `xml:"w:comments"`
+	XMLW    string   `xml:"xmlns:w,attr"`             // cannot be unmarshalled in
+	XMLR    string   `xml:"xmlns:r,attr,omitempty"`   // cannot be unmarshalled in
+	XMLWP   string   `xml:"xmlns:wp,attr,omitempty"`  // cannot be unmarshalled in
+	XMLWPS  string   `xml:"xmlns:wps,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPC  string   `xml:"xmlns:wpc,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPG  string   `xml:"xmlns:wpg,attr,omitempty"` // cannot be unmarshalled in
+
+	Comments []*Comment
+
+	name string // name is the part name like word/comments.xml
+	file *Docx
+}
+
+// UnmarshalXML ...
+func (cs *Comments) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if tt, ok := t.(xml.StartElement); ok {
+			if tt.Name.Local == "comment" {
+				value := Comment{
+					ID:       getAtt(tt.Attr, "id"),
+					Author:   getAtt(tt.Attr, "author"),
+					Date:     getAtt(tt.Attr, "date"),
+					Initials: getAtt(tt.Attr, "initials"),
+				}
+				b := Body{file: cs.file}
+				err = d.DecodeElement(&b, &tt)
+				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
+					return err
+				}
+				value.Items = b.Items
+				cs.Comments = append(cs.Comments, &value)
+				continue
+			}
+			err = d.Skip() // skip unsupported tags
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setNamespaces fills the namespaces used by the models
+func (cs *Comments) setNamespaces() {
+	cs.XMLW = XMLNS_W
+	cs.XMLR = XMLNS_R
+	cs.XMLWP = XMLNS_WP
+	cs.XMLWPS = XMLNS_WPS
+	cs.XMLWPC = XMLNS_WPC
+	cs.XMLWPG = XMLNS_WPG
+}
+
+// Name is the part name like word/comments.xml
+func (cs *Comments) Name() string {
+	return cs.name
+}
+
+// Comment <w:comment>
+type Comment struct {
+	XMLName  xml.Name `xml:"w:comment"`
+	ID       string   `xml:"w:id,attr"`
+	Author   string   `xml:"w:author,attr,omitempty"`
+	Date     string   `xml:"w:date,attr,omitempty"`
+	Initials string   `xml:"w:initials,attr,omitempty"`
+
+	Items []interface{}
+}
+
+// CommentRangeStart <w:commentRangeStart>
+type CommentRangeStart struct {
+	XMLName xml.Name `xml:"w:commentRangeStart"`
+	ID      string   `xml:"w:id,attr"`
+}
+
+// CommentRangeEnd <w:commentRangeEnd>
+type CommentRangeEnd struct {
+	XMLName xml.Name `xml:"w:commentRangeEnd"`
+	ID      string   `xml:"w:id,attr"`
+}
+
+// CommentReference <w:commentReference> in a run
+type CommentReference struct {
+	XMLName xml.Name `xml:"w:commentReference"`
+	ID      string   `xml:"w:id,attr"`
+}
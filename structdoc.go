@@ -73,44 +73,44 @@ func (b *Body) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 		}
 
 		if tt, ok := t.(xml.StartElement); ok {
-			switch tt.Name.Local {
-			case "p":
-				var value Paragraph
-				value.file = b.file
-				err = d.DecodeElement(&value, &tt)
-				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
-					return err
-				}
-				b.Items = append(b.Items, &value)
-			case "tbl":
-				var value Table
-				value.file = b.file
-				err = d.DecodeElement(&value, &tt)
-				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
-					return err
-				}
-				b.Items = append(b.Items, &value)
-			case "sectPr":
-				var value SectPr
-				err = d.DecodeElement(&value, &tt)
-				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
-					return err
-				}
-				b.Items = append(b.Items, &value)
-			default:
-				err = d.Skip() // skip unsupported tags
-				if err != nil {
-					return err
-				}
+			item, err := decodeBodyItem(d, &tt, b.file)
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				b.Items = append(b.Items, item)
 			}
 		}
 	}
 	return nil
 }
 
+// decodeBodyItem decodes the item of the body started by tt,
+// or skips it and returns nil if unsupported
+func decodeBodyItem(d *xml.Decoder, tt *xml.StartElement, file *Docx) (interface{}, error) {
+	var item interface{}
+	switch tt.Name.Local {
+	case "p":
+		item = &Paragraph{file: file}
+	case "tbl":
+		item = &Table{file: file}
+	case "sectPr":
+		item = &SectPr{}
+	default:
+		return nil, d.Skip() // skip unsupported tags
+	}
+	err := d.DecodeElement(item, tt)
+	if err != nil && !strings.HasPrefix(err.Error(), "expected") {
+		return nil, err
+	}
+	return item, nil
+}
+
 // KeepElements keep named elems amd removes others
 //
 // names: *docx.Paragraph *docx.Table
+//
+// Deprecated: use typed predicates like b.Keep(docx.OfType[*docx.Paragraph]()).
 func (b *Body) KeepElements(name ...string) {
 	items := make([]interface{}, 0, len(b.Items))
 	namemap := make(map[string]struct{}, len(name)*2)
@@ -216,79 +216,7 @@ func SplitDocxByPlainTextRegex(re *regexp.Regexp) ParagraphSplitRule {
 //
 // The separator will be placed to the first doc item
 func (f *Docx) SplitByParagraph(separator ParagraphSplitRule) (docs []*Docx) {
-	items := f.Document.Body.Items
-newdoclop:
-	for len(items) > 0 {
-		ndoc := new(Docx)
-
-		// migrate base data
-		ndoc.mediaNameIdx = make(map[string]int, 64)
-		ndoc.slowIDs = make(map[string]uintptr, 64)
-		ndoc.template = f.template
-		ndoc.tmplfs = f.tmplfs
-		ndoc.tmpfslst = f.tmpfslst
-
-		ndoc.Document.XMLW = XMLNS_W
-		ndoc.Document.XMLR = XMLNS_R
-		ndoc.Document.XMLWP = XMLNS_WP
-		// ndoc.Document.XMLMC = XMLNS_MC
-		// ndoc.Document.XMLO = XMLNS_O
-		// ndoc.Document.XMLV = XMLNS_V
-		ndoc.Document.XMLWPS = XMLNS_WPS
-		ndoc.Document.XMLWPC = XMLNS_WPC
-		ndoc.Document.XMLWPG = XMLNS_WPG
-		// ndoc.Document.XMLWP14 = XMLNS_WP14
-		ndoc.Document.XMLName.Space = XMLNS_W
-		ndoc.Document.XMLName.Local = "document"
-		ndoc.Document.Body.file = ndoc
-
-		ndoc.docRelation = Relationships{
-			Xmlns: XMLNS_REL,
-			Relationship: []Relationship{
-				{
-					ID:     "rId1",
-					Type:   `http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles`,
-					Target: "styles.xml",
-				},
-				{
-					ID:     "rId2",
-					Type:   `http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme`,
-					Target: "theme/theme1.xml",
-				},
-				{
-					ID:     "rId3",
-					Type:   `http://schemas.openxmlformats.org/officeDocument/2006/relationships/fontTable`,
-					Target: "fontTable.xml",
-				},
-			},
-		}
-
-		ndoc.rID = 3
-
-		for i, item := range items {
-			switch o := item.(type) {
-			case *Paragraph:
-				if separator(o) && len(ndoc.Document.Body.Items) > 0 {
-					items = items[i:]
-					docs = append(docs, ndoc)
-					continue newdoclop
-				}
-				np := o.copymedia(ndoc)
-				ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, &np)
-			case *Table:
-				nt := o.copymedia(ndoc)
-				ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, &nt)
-			default:
-				ndoc.Document.Body.Items = append(ndoc.Document.Body.Items, o)
-			}
-		}
-
-		if len(ndoc.Document.Body.Items) > 0 {
-			docs = append(docs, ndoc)
-		}
-		break
-	}
-	return
+	return f.Split(SplitDocxByParagraph(separator), SplitOptions{})
 }
 
 func (r *Run) copymedia(to *Docx) *Run {
@@ -347,6 +275,13 @@ func (t *Table) copymedia(to *Docx) (nt Table) {
 				np := p.copymedia(to)
 				ntc.Paragraphs = append(ntc.Paragraphs, &np)
 			}
+			if len(tc.Tables) > 0 {
+				ntc.Tables = make([]*Table, 0, len(tc.Tables))
+				for _, t := range tc.Tables {
+					nt := t.copymedia(to)
+					ntc.Tables = append(ntc.Tables, &nt)
+				}
+			}
 			ntr.TableCells = append(ntr.TableCells, &ntc)
 		}
 		nt.TableRows = append(nt.TableRows, &ntr)
@@ -355,17 +290,14 @@ func (t *Table) copymedia(to *Docx) (nt Table) {
 }
 
 // AppendFile appends all contents in af to f
+//
+// The styles and numbering used by af are imported
+// as AppendFileWithOptions does with the default options.
+// If they cannot be parsed, nothing is imported and the contents
+// are appended as they are, with their style and list ids unchanged.
+// Use AppendFileWithOptions to get the error instead.
 func (f *Docx) AppendFile(af *Docx) {
-	for _, item := range af.Document.Body.Items {
-		switch o := item.(type) {
-		case *Paragraph:
-			np := o.copymedia(f)
-			f.Document.Body.Items = append(f.Document.Body.Items, &np)
-		case *Table:
-			nt := o.copymedia(f)
-			f.Document.Body.Items = append(f.Document.Body.Items, &nt)
-		default:
-			f.Document.Body.Items = append(f.Document.Body.Items, o)
-		}
+	if f.AppendFileWithOptions(af, AppendOptions{}) != nil {
+		f.appendItems(af, nil)
 	}
 }
@@ -22,6 +22,8 @@ package docx
 
 import (
 	"encoding/xml"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -71,3 +73,92 @@ func TestUnmarshalPlainStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestSplit(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().Style("Heading1").AddText("chapter 1")
+	f.AddParagraph().AddText("text 1")
+	f.AddParagraph().AddPageBreaks()
+	f.AddParagraph().Style("Heading2").AddText("section 1.1")
+	sp := f.AddParagraph()
+	sp.AddText("end of section 1")
+	sp.Properties = &ParagraphProperties{SectPr: &SectPr{PgSz: &PgSz{W: 16838, H: 11906}}}
+	f.AddParagraph().Style("Heading1").AddText("chapter 2")
+	f.WithA4Page()
+
+	if n := len(f.Split(SplitDocxByHeading(1), SplitOptions{})); n != 2 {
+		t.Fatal("expected 2 parts by heading 1 but has", n)
+	}
+	if n := len(f.Split(SplitDocxByHeading(2), SplitOptions{})); n != 3 {
+		t.Fatal("expected 3 parts by heading 2 but has", n)
+	}
+	docs := f.Split(SplitDocxByPageBreak(), SplitOptions{Separator: SeparatorDropped})
+	if len(docs) != 2 || len(docs[0].Document.Body.Items) != 2 {
+		t.Fatal("unexpected parts by page break", len(docs))
+	}
+	docs = f.Split(SplitDocxBySectionBreak(), SplitOptions{Separator: SeparatorToPrevious, WithSectPr: true})
+	if len(docs) != 2 {
+		t.Fatal("expected 2 parts by section break but has", len(docs))
+	}
+	items := docs[0].Document.Body.Items
+	if s, ok := items[len(items)-1].(*SectPr); !ok || s.PgSz.W != 16838 {
+		t.Fatal("the first part should end with the landscape section")
+	}
+	if items[len(items)-2].(*Paragraph).Properties.SectPr != nil {
+		t.Fatal("the section break should have been moved to the body")
+	}
+	if sp.Properties.SectPr == nil {
+		t.Fatal("source file has been modified")
+	}
+	items = docs[1].Document.Body.Items
+	if s, ok := items[len(items)-1].(*SectPr); !ok || s.PgSz.W != 11906 {
+		t.Fatal("the last part should end with the A4 section")
+	}
+	docs = f.Split(SplitDocxByItemCount(3), SplitOptions{WithSectPr: true})
+	if len(docs) != 2 || len(docs[0].Document.Body.Items) != 4 || len(docs[1].Document.Body.Items) != 4 {
+		t.Fatal("unexpected parts by item count", len(docs))
+	}
+}
+
+func TestSplitByItemCountSeparators(t *testing.T) {
+	f := New().WithDefaultTheme()
+	for i := 0; i < 7; i++ {
+		f.AddParagraph().AddText(strconv.Itoa(i))
+	}
+	for sep, exp := range map[SplitSeparator][]int{
+		SeparatorToNext:     {3, 3, 1},
+		SeparatorToPrevious: {4, 3},
+		SeparatorDropped:    {3, 3},
+	} {
+		docs := f.Split(SplitDocxByItemCount(3), SplitOptions{Separator: sep})
+		if len(docs) != len(exp) {
+			t.Fatal("unexpected parts of", sep, len(docs))
+		}
+		for i, d := range docs {
+			if len(d.Document.Body.Items) != exp[i] {
+				t.Fatal("unexpected items of", sep, i, len(d.Document.Body.Items))
+			}
+		}
+	}
+}
+
+func TestSplitRelations(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("a")
+	err := f.RemovePart("word/fontTable.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := f.Split(SplitDocxByItemCount(1), SplitOptions{})
+	if len(docs) != 1 {
+		t.Fatal("unexpected parts", len(docs))
+	}
+	for _, r := range docs[0].docRelation.Relationship {
+		if strings.HasSuffix(r.Type, "/fontTable") {
+			t.Fatal("relationship to a missing part", r.Target)
+		}
+	}
+	if len(docs[0].docRelation.Relationship) != 2 || docs[0].docRelation.Relationship[0].ID != "rId1" || docs[0].docRelation.Relationship[0].Type != REL_STYLES {
+		t.Fatal("unexpected relationships", docs[0].docRelation.Relationship)
+	}
+}
@@ -21,8 +21,6 @@
 package docx
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/xml"
 	"io"
 	"strconv"
@@ -244,8 +242,7 @@ func (r *WPInline) String() string {
 			if err != nil {
 				sb.WriteString(err.Error())
 			} else {
-				h := md5.Sum(r.file.Media(tgt[6:]).Data)
-				sb.WriteString(hex.EncodeToString(h[:]))
+				sb.WriteString(r.file.mediaMD5(tgt[6:]))
 			}
 		}
 		sb.WriteByte(')')
@@ -298,7 +295,11 @@ func (r *WPInline) copymedia(to *Docx) *WPInline { //nolint: dupl
 			if m == nil {
 				return nil
 			}
-			rid := to.addImage(format, m.Data)
+			data, err := m.Load()
+			if err != nil {
+				return nil
+			}
+			rid := to.addImage(format, data)
 			inln := *r
 			grph := *r.Graphic
 			inln.Graphic = &grph
@@ -1333,8 +1334,7 @@ func (r *WPAnchor) String() string {
 				if err != nil {
 					sb.WriteString(err.Error())
 				} else {
-					h := md5.Sum(r.file.Media(tgt[6:]).Data)
-					sb.WriteString(hex.EncodeToString(h[:]))
+					sb.WriteString(r.file.mediaMD5(tgt[6:]))
 				}
 			}
 			sb.WriteByte(')')
@@ -1388,7 +1388,11 @@ func (r *WPAnchor) copymedia(to *Docx) *WPAnchor { //nolint: dupl
 			if m == nil {
 				return nil
 			}
-			rid := to.addImage(format, m.Data)
+			data, err := m.Load()
+			if err != nil {
+				return nil
+			}
+			rid := to.addImage(format, data)
 			anch := *r
 			grph := *r.Graphic
 			anch.Graphic = &grph
@@ -60,11 +60,13 @@ type SizeCs struct {
 // Bold ...
 type Bold struct {
 	XMLName xml.Name `xml:"w:b,omitempty"`
+	Val     string   `xml:"w:val,attr,omitempty"`
 }
 
 // Italic ...
 type Italic struct {
 	XMLName xml.Name `xml:"w:i,omitempty"`
+	Val     string   `xml:"w:val,attr,omitempty"`
 }
 
 // Underline ...
@@ -110,6 +112,11 @@ type VertAlign struct {
 	Val     string   `xml:"w:val,attr"`
 }
 
+// toggleOn checks whether a toggle property like w:b with val is on
+func toggleOn(val string) bool {
+	return val != "false" && val != "0" && val != "off"
+}
+
 // Strike ...
 type Strike struct {
 	XMLName xml.Name `xml:"w:strike,omitempty"`
@@ -285,6 +292,8 @@ type Spacing struct {
 
 	BeforeLines int    `xml:"w:beforeLines,attr,omitempty"`
 	Before      int    `xml:"w:before,attr,omitempty"`
+	AfterLines  int    `xml:"w:afterLines,attr,omitempty"`
+	After       int    `xml:"w:after,attr,omitempty"`
 	Line        int    `xml:"w:line,attr,omitempty"`
 	LineRule    string `xml:"w:lineRule,attr,omitempty"`
 }
@@ -308,6 +317,16 @@ func (s *Spacing) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err erro
 			if err != nil {
 				return
 			}
+		case "afterLines":
+			s.AfterLines, err = GetInt(attr.Value)
+			if err != nil {
+				return
+			}
+		case "after":
+			s.After, err = GetInt(attr.Value)
+			if err != nil {
+				return
+			}
 		case "line":
 			s.Line, err = GetInt(attr.Value)
 			if err != nil {
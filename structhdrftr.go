@@ -0,0 +1,199 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+//nolint:revive,stylecheck
+const (
+	REL_HEADER    = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/header`
+	REL_FOOTER    = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer`
+	REL_FOOTNOTES = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes`
+)
+
+// Header is word/headerN.xml <w:hdr>
+type Header struct {
+	XMLName xml.Name `xml:"w:hdr"`
+	XMLW    string   `xml:"xmlns:w,attr"`             // cannot be unmarshalled in
+	XMLR    string   `xml:"xmlns:r,attr,omitempty"`   // cannot be unmarshalled in
+	XMLWP   string   `xml:"xmlns:wp,attr,omitempty"`  // cannot be unmarshalled in
+	XMLWPS  string   `xml:"xmlns:wps,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPC  string   `xml:"xmlns:wpc,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPG  string   `xml:"xmlns:wpg,attr,omitempty"` // cannot be unmarshalled in
+
+	Items []interface{}
+
+	id   string // id is the rId in document.xml.rels
+	name string // name is the part name like word/header1.xml
+	file *Docx
+}
+
+// UnmarshalXML ...
+func (h *Header) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	b := Body{file: h.file}
+	err := b.UnmarshalXML(d, start)
+	h.Items = b.Items
+	return err
+}
+
+// ID is the rId referred by HeaderReference
+func (h *Header) ID() string {
+	return h.id
+}
+
+// setNamespaces fills the namespaces used by the models
+func (h *Header) setNamespaces() {
+	h.XMLW = XMLNS_W
+	h.XMLR = XMLNS_R
+	h.XMLWP = XMLNS_WP
+	h.XMLWPS = XMLNS_WPS
+	h.XMLWPC = XMLNS_WPC
+	h.XMLWPG = XMLNS_WPG
+}
+
+// Name is the part name like word/header1.xml
+func (h *Header) Name() string {
+	return h.name
+}
+
+// Footer is word/footerN.xml <w:ftr>
+type Footer struct {
+	XMLName xml.Name `xml:"w:ftr"`
+	XMLW    string   `xml:"xmlns:w,attr"`             // cannot be unmarshalled in
+	XMLR    string   `xml:"xmlns:r,attr,omitempty"`   // cannot be unmarshalled in
+	XMLWP   string   `xml:"xmlns:wp,attr,omitempty"`  // cannot be unmarshalled in
+	XMLWPS  string   `xml:"xmlns:wps,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPC  string   `xml:"xmlns:wpc,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPG  string   `xml:"xmlns:wpg,attr,omitempty"` // cannot be unmarshalled in
+
+	Items []interface{}
+
+	id   string // id is the rId in document.xml.rels
+	name string // name is the part name like word/footer1.xml
+	file *Docx
+}
+
+// UnmarshalXML ...
+func (h *Footer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	b := Body{file: h.file}
+	err := b.UnmarshalXML(d, start)
+	h.Items = b.Items
+	return err
+}
+
+// ID is the rId referred by FooterReference
+func (h *Footer) ID() string {
+	return h.id
+}
+
+// setNamespaces fills the namespaces used by the models
+func (h *Footer) setNamespaces() {
+	h.XMLW = XMLNS_W
+	h.XMLR = XMLNS_R
+	h.XMLWP = XMLNS_WP
+	h.XMLWPS = XMLNS_WPS
+	h.XMLWPC = XMLNS_WPC
+	h.XMLWPG = XMLNS_WPG
+}
+
+// Name is the part name like word/footer1.xml
+func (h *Footer) Name() string {
+	return h.name
+}
+
+// Footnotes is word/footnotes.xml <w:footnotes>
+type Footnotes struct {
+	XMLName xml.Name `xml:"w:footnotes"`
+	XMLW    string   `xml:"xmlns:w,attr"`             // cannot be unmarshalled in
+	XMLR    string   `xml:"xmlns:r,attr,omitempty"`   // cannot be unmarshalled in
+	XMLWP   string   `xml:"xmlns:wp,attr,omitempty"`  // cannot be unmarshalled in
+	XMLWPS  string   `xml:"xmlns:wps,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPC  string   `xml:"xmlns:wpc,attr,omitempty"` // cannot be unmarshalled in
+	XMLWPG  string   `xml:"xmlns:wpg,attr,omitempty"` // cannot be unmarshalled in
+
+	Footnotes []*Footnote
+
+	name string // name is the part name like word/footnotes.xml
+	file *Docx
+}
+
+// UnmarshalXML ...
+func (fn *Footnotes) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if tt, ok := t.(xml.StartElement); ok {
+			if tt.Name.Local == "footnote" {
+				value := Footnote{
+					Type: getAtt(tt.Attr, "type"),
+					ID:   getAtt(tt.Attr, "id"),
+				}
+				b := Body{file: fn.file}
+				err = d.DecodeElement(&b, &tt)
+				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
+					return err
+				}
+				value.Items = b.Items
+				fn.Footnotes = append(fn.Footnotes, &value)
+				continue
+			}
+			err = d.Skip() // skip unsupported tags
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setNamespaces fills the namespaces used by the models
+func (fn *Footnotes) setNamespaces() {
+	fn.XMLW = XMLNS_W
+	fn.XMLR = XMLNS_R
+	fn.XMLWP = XMLNS_WP
+	fn.XMLWPS = XMLNS_WPS
+	fn.XMLWPC = XMLNS_WPC
+	fn.XMLWPG = XMLNS_WPG
+}
+
+// Name is the part name like word/footnotes.xml
+func (fn *Footnotes) Name() string {
+	return fn.name
+}
+
+// Footnote <w:footnote>
+type Footnote struct {
+	XMLName xml.Name `xml:"w:footnote"`
+	Type    string   `xml:"w:type,attr,omitempty"` // Type is separator, continuationSeparator or empty for normal notes
+	ID      string   `xml:"w:id,attr"`
+
+	Items []interface{}
+}
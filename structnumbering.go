@@ -0,0 +1,355 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	lvlNumFmtRe     = regexp.MustCompile(`<w:numFmt\s+w:val="([^"]*)"`)
+	lvlTextRe       = regexp.MustCompile(`<w:lvlText\s+w:val="([^"]*)"`)
+	lvlIndLeftRe    = regexp.MustCompile(`<w:ind\s[^>]*w:(?:left|start)="(\d+)"`)
+	lvlIndHangingRe = regexp.MustCompile(`<w:ind\s[^>]*w:hanging="(\d+)"`)
+	startOverrideRe = regexp.MustCompile(`<w:startOverride\s+w:val="(\d+)"`)
+)
+
+// Numbering is word/numbering.xml
+//
+// Only abstractNum and num are modelled, numPicBullet
+// and numIdMacAtCleanup are kept as they were.
+type Numbering struct {
+	Attrs        []xml.Attr // Attrs is the attributes of <w:numbering>, including namespaces
+	AbstractNums []*AbstractNum
+	Nums         []*Num
+
+	prefix []rawElement // prefix is numPicBullet
+	suffix []rawElement // suffix is numIdMacAtCleanup
+}
+
+// UnmarshalXML ...
+func (n *Numbering) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.Attrs = prefixedAttrs(start.Attr)
+	inner, err := decodeInnerXML(d, &start)
+	if err != nil {
+		return err
+	}
+	elems, err := splitRawElements(inner)
+	if err != nil {
+		return err
+	}
+	for _, e := range elems {
+		switch e.Name {
+		case "abstractNum":
+			var value AbstractNum
+			err = xml.Unmarshal(StringToBytes(e.XML), &value)
+			if err != nil {
+				return err
+			}
+			n.AbstractNums = append(n.AbstractNums, &value)
+		case "num":
+			var value Num
+			err = xml.Unmarshal(StringToBytes(e.XML), &value)
+			if err != nil {
+				return err
+			}
+			n.Nums = append(n.Nums, &value)
+		case "numPicBullet":
+			n.prefix = append(n.prefix, e)
+		default:
+			n.suffix = append(n.suffix, e)
+		}
+	}
+	return nil
+}
+
+// MarshalXML ...
+func (n *Numbering) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	sb := strings.Builder{}
+	for _, r := range n.prefix {
+		sb.WriteString(r.XML)
+	}
+	for _, a := range n.AbstractNums {
+		data, err := xml.Marshal(a)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+	}
+	for _, num := range n.Nums {
+		data, err := xml.Marshal(num)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+	}
+	for _, r := range n.suffix {
+		sb.WriteString(r.XML)
+	}
+	attrs := n.Attrs
+	if len(attrs) == 0 {
+		attrs = []xml.Attr{{Name: xml.Name{Local: "xmlns:w"}, Value: XMLNS_W}}
+	}
+	return encodeRaw(e, xml.StartElement{Name: xml.Name{Local: "w:numbering"}, Attr: attrs}, sb.String())
+}
+
+// Num gets the num by numId, or nil on notfound
+func (n *Numbering) Num(id string) *Num {
+	for _, num := range n.Nums {
+		if num.NumID == id {
+			return num
+		}
+	}
+	return nil
+}
+
+// AbstractNum gets the abstractNum by abstractNumId, or nil on notfound
+func (n *Numbering) AbstractNum(id string) *AbstractNum {
+	for _, a := range n.AbstractNums {
+		if a.AbstractNumID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// nextNumID is the max numId + 1
+func (n *Numbering) nextNumID() int {
+	mx := 0
+	for _, num := range n.Nums {
+		id, err := strconv.Atoi(num.NumID)
+		if err == nil && id > mx {
+			mx = id
+		}
+	}
+	return mx + 1
+}
+
+// nextAbstractNumID is the max abstractNumId + 1
+func (n *Numbering) nextAbstractNumID() int {
+	mx := -1
+	for _, a := range n.AbstractNums {
+		id, err := strconv.Atoi(a.AbstractNumID)
+		if err == nil && id > mx {
+			mx = id
+		}
+	}
+	return mx + 1
+}
+
+// AbstractNum <w:abstractNum> is the definition of a list
+type AbstractNum struct {
+	AbstractNumID string
+	Nsid          string // Nsid identifies the list, lists with the same nsid are merged by word
+
+	elems []rawElement // elems is multiLevelType, lvl...
+}
+
+// UnmarshalXML ...
+func (a *AbstractNum) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	a.AbstractNumID = getAtt(start.Attr, "abstractNumId")
+	inner, err := decodeInnerXML(d, &start)
+	if err != nil {
+		return err
+	}
+	elems, err := splitRawElements(inner)
+	if err != nil {
+		return err
+	}
+	for _, e := range elems {
+		if e.Name == "nsid" {
+			a.Nsid = getAtt(e.Attr, "val")
+			continue
+		}
+		a.elems = append(a.elems, e)
+	}
+	return nil
+}
+
+// MarshalXML ...
+func (a *AbstractNum) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	sb := strings.Builder{}
+	if a.Nsid != "" {
+		sb.WriteString(`<w:nsid w:val="` + escapeAttr(a.Nsid) + `"/>`)
+	}
+	for _, r := range a.elems {
+		sb.WriteString(r.XML)
+	}
+	return encodeRaw(e, xml.StartElement{
+		Name: xml.Name{Local: "w:abstractNum"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "w:abstractNumId"}, Value: a.AbstractNumID}},
+	}, sb.String())
+}
+
+// Copy returns a deep copy of the abstractNum
+func (a *AbstractNum) Copy() *AbstractNum {
+	na := *a
+	na.elems = make([]rawElement, len(a.elems))
+	copy(na.elems, a.elems)
+	return &na
+}
+
+// lvl returns the raw <w:lvl> of level ilvl, or "" on notfound
+func (a *AbstractNum) lvl(ilvl int) string {
+	lvl := strconv.Itoa(ilvl)
+	for _, e := range a.elems {
+		if e.Name == "lvl" && getAtt(e.Attr, "ilvl") == lvl {
+			return e.XML
+		}
+	}
+	return ""
+}
+
+// NumFmt returns the numFmt of level ilvl like decimal or bullet, or "" on notfound
+func (a *AbstractNum) NumFmt(ilvl int) string {
+	sub := lvlNumFmtRe.FindStringSubmatch(a.lvl(ilvl))
+	if len(sub) > 1 {
+		return sub[1]
+	}
+	return ""
+}
+
+// lvlText returns the lvlText of level ilvl like %1. and whether it is set
+func (a *AbstractNum) lvlText(ilvl int) (string, bool) {
+	sub := lvlTextRe.FindStringSubmatch(a.lvl(ilvl))
+	if len(sub) > 1 {
+		return html.UnescapeString(sub[1]), true
+	}
+	return "", false
+}
+
+// lvlInd returns the left and hanging indent in twips of level ilvl
+func (a *AbstractNum) lvlInd(ilvl int) (left, hanging int) {
+	x := a.lvl(ilvl)
+	if sub := lvlIndLeftRe.FindStringSubmatch(x); len(sub) > 1 {
+		left, _ = strconv.Atoi(sub[1])
+	}
+	if sub := lvlIndHangingRe.FindStringSubmatch(x); len(sub) > 1 {
+		hanging, _ = strconv.Atoi(sub[1])
+	}
+	return
+}
+
+// Num <w:num> is an instance of a list
+type Num struct {
+	NumID         string
+	AbstractNumID string
+
+	elems []rawElement // elems is lvlOverride
+}
+
+// UnmarshalXML ...
+func (n *Num) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.NumID = getAtt(start.Attr, "numId")
+	inner, err := decodeInnerXML(d, &start)
+	if err != nil {
+		return err
+	}
+	elems, err := splitRawElements(inner)
+	if err != nil {
+		return err
+	}
+	for _, e := range elems {
+		if e.Name == "abstractNumId" {
+			n.AbstractNumID = getAtt(e.Attr, "val")
+			continue
+		}
+		n.elems = append(n.elems, e)
+	}
+	return nil
+}
+
+// MarshalXML ...
+func (n *Num) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	sb := strings.Builder{}
+	sb.WriteString(`<w:abstractNumId w:val="` + escapeAttr(n.AbstractNumID) + `"/>`)
+	for _, r := range n.elems {
+		sb.WriteString(r.XML)
+	}
+	return encodeRaw(e, xml.StartElement{
+		Name: xml.Name{Local: "w:num"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "w:numId"}, Value: n.NumID}},
+	}, sb.String())
+}
+
+// startOverride returns the overridden start of level ilvl, or 0 if not overridden
+func (n *Num) startOverride(ilvl int) int {
+	lvl := strconv.Itoa(ilvl)
+	for _, e := range n.elems {
+		if e.Name != "lvlOverride" || getAtt(e.Attr, "ilvl") != lvl {
+			continue
+		}
+		if sub := startOverrideRe.FindStringSubmatch(e.XML); len(sub) > 1 {
+			start, _ := strconv.Atoi(sub[1])
+			return start
+		}
+	}
+	return 0
+}
+
+// Copy returns a deep copy of the num
+func (n *Num) Copy() *Num {
+	nn := *n
+	nn.elems = make([]rawElement, len(n.elems))
+	copy(nn.elems, n.elems)
+	return &nn
+}
+
+// StartOverride restarts the numbering of level ilvl at start,
+// replacing the previous override of this level.
+func (n *Num) StartOverride(ilvl, start int) *Num {
+	lvl := strconv.Itoa(ilvl)
+	elems := make([]rawElement, 0, len(n.elems)+1)
+	for _, e := range n.elems {
+		if e.Name == "lvlOverride" && getAtt(e.Attr, "ilvl") == lvl {
+			continue
+		}
+		elems = append(elems, e)
+	}
+	n.elems = append(elems, rawElement{
+		Name: "lvlOverride",
+		Attr: []xml.Attr{{Name: xml.Name{Space: "w", Local: "ilvl"}, Value: lvl}},
+		XML:  `<w:lvlOverride w:ilvl="` + lvl + `"><w:startOverride w:val="` + strconv.Itoa(start) + `"/></w:lvlOverride>`,
+	})
+	return n
+}
+
+// Copy returns a deep copy of the numbering
+func (n *Numbering) Copy() *Numbering {
+	nn := &Numbering{
+		Attrs:        append([]xml.Attr(nil), n.Attrs...),
+		AbstractNums: make([]*AbstractNum, len(n.AbstractNums)),
+		Nums:         make([]*Num, len(n.Nums)),
+		prefix:       append([]rawElement(nil), n.prefix...),
+		suffix:       append([]rawElement(nil), n.suffix...),
+	}
+	for i, a := range n.AbstractNums {
+		nn.AbstractNums[i] = a.Copy()
+	}
+	for i, num := range n.Nums {
+		nn.Nums[i] = num.Copy()
+	}
+	return nn
+}
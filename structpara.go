@@ -46,6 +46,7 @@ type ParagraphProperties struct {
 	OverflowPunct  *OverflowPunct
 
 	RunProperties *RunProperties
+	SectPr        *SectPr // SectPr is set on the last paragraph of a section
 }
 
 // UnmarshalXML ...
@@ -108,6 +109,13 @@ func (p *ParagraphProperties) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) e
 					return err
 				}
 				p.RunProperties = &value
+			case "sectPr":
+				var value SectPr
+				err = d.DecodeElement(&value, &tt)
+				if err != nil && !strings.HasPrefix(err.Error(), "expected") {
+					return err
+				}
+				p.SectPr = &value
 			case "pStyle":
 				p.Style = &Style{Val: getAtt(tt.Attr, "val")}
 			case "numPr":
@@ -193,46 +201,14 @@ type Paragraph struct {
 
 func (p *Paragraph) String() string {
 	sb := strings.Builder{}
-	if p.Properties != nil && p.Properties.NumProperties != nil && p.Properties.NumProperties.Ilvl != nil {
-		indent, err := strconv.Atoi(p.Properties.NumProperties.Ilvl.Val)
-		if err == nil {
-			sb.WriteString(strings.Repeat(" ", indent*2))
-		}
-	}
+	sb.WriteString(p.indentString())
 	for _, c := range p.Children {
 		switch o := c.(type) {
 		case *Hyperlink:
-			id := o.ID
-			text := o.Run.InstrText
-			link, err := p.file.ReferTarget(id)
-			sb.WriteString("[")
-			sb.WriteString(text)
-			sb.WriteString("](")
-			if err != nil {
-				sb.WriteString(id)
-			} else {
-				sb.WriteString(link)
-			}
-			sb.WriteByte(')')
+			sb.WriteString(p.linkString(o))
 		case *Run:
 			for _, c := range o.Children {
-				switch x := c.(type) {
-				case *Text:
-					sb.WriteString(x.Text)
-				case *Tab:
-					sb.WriteByte('\t')
-				case *BarterRabbet:
-					sb.WriteByte('\n')
-				case *Drawing:
-					if x.Inline != nil {
-						sb.WriteString(x.Inline.String())
-						continue
-					}
-					if x.Anchor != nil {
-						sb.WriteString(x.Anchor.String())
-						continue
-					}
-				}
+				sb.WriteString(runChildString(c))
 			}
 		default:
 			continue
@@ -241,6 +217,55 @@ func (p *Paragraph) String() string {
 	return sb.String()
 }
 
+// indentString is the leading spaces of a numbered paragraph in String
+func (p *Paragraph) indentString() string {
+	if p.Properties != nil && p.Properties.NumProperties != nil && p.Properties.NumProperties.Ilvl != nil {
+		indent, err := strconv.Atoi(p.Properties.NumProperties.Ilvl.Val)
+		if err == nil {
+			return strings.Repeat(" ", indent*2)
+		}
+	}
+	return ""
+}
+
+// linkString is the markdown-like link of o in String
+func (p *Paragraph) linkString(o *Hyperlink) string {
+	sb := strings.Builder{}
+	id := o.ID
+	text := o.Run.InstrText
+	link, err := p.file.ReferTarget(id)
+	sb.WriteString("[")
+	sb.WriteString(text)
+	sb.WriteString("](")
+	if err != nil {
+		sb.WriteString(id)
+	} else {
+		sb.WriteString(link)
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// runChildString is the text of a run child in String
+func runChildString(c interface{}) string {
+	switch x := c.(type) {
+	case *Text:
+		return x.Text
+	case *Tab:
+		return "\t"
+	case *BarterRabbet:
+		return "\n"
+	case *Drawing:
+		if x.Inline != nil {
+			return x.Inline.String()
+		}
+		if x.Anchor != nil {
+			return x.Anchor.String()
+		}
+	}
+	return ""
+}
+
 // UnmarshalXML ...
 func (p *Paragraph) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 	/*for _, attr := range start.Attr {
@@ -307,6 +332,14 @@ func (p *Paragraph) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 				}
 				p.Properties = &value
 				continue
+			case "bookmarkStart":
+				elem = &BookmarkStart{ID: getAtt(tt.Attr, "id"), Name: getAtt(tt.Attr, "name")}
+			case "bookmarkEnd":
+				elem = &BookmarkEnd{ID: getAtt(tt.Attr, "id")}
+			case "commentRangeStart":
+				elem = &CommentRangeStart{ID: getAtt(tt.Attr, "id")}
+			case "commentRangeEnd":
+				elem = &CommentRangeEnd{ID: getAtt(tt.Attr, "id")}
 			default:
 				err = d.Skip() // skip unsupported tags
 				if err != nil {
@@ -324,6 +357,8 @@ func (p *Paragraph) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 // KeepElements keep named elems amd removes others
 //
 // names: *docx.Hyperlink *docx.Run *docx.RunProperties
+//
+// Deprecated: use typed predicates like p.Keep(docx.OfType[*docx.Run]()).
 func (p *Paragraph) KeepElements(name ...string) {
 	items := make([]interface{}, 0, len(p.Children))
 	namemap := make(map[string]struct{}, len(name)*2)
@@ -25,6 +25,8 @@ const (
 	XMLNS_REL     = `http://schemas.openxmlformats.org/package/2006/relationships`
 	REL_HYPERLINK = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink`
 	REL_IMAGE     = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/image`
+	REL_STYLES    = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles`
+	REL_NUMBERING = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering`
 
 	REL_TARGETMODE = "External"
 )
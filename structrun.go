@@ -116,6 +116,8 @@ func (r *Run) parse(d *xml.Decoder, tt xml.StartElement) (child interface{}, err
 		child = &value
 	case "tab":
 		child = &Tab{}
+	case "commentReference":
+		child = &CommentReference{ID: getAtt(tt.Attr, "id")}
 	case "br":
 		var value BarterRabbet
 		err = d.DecodeElement(&value, &tt)
@@ -183,6 +185,8 @@ func (r *Run) parse(d *xml.Decoder, tt xml.StartElement) (child interface{}, err
 // KeepElements keep named elems amd removes others
 //
 // names: *docx.Text *docx.Drawing *docx.Tab *docx.BarterRabbet
+//
+// Deprecated: use typed predicates like r.Keep(docx.OfType[*docx.Text]()).
 func (r *Run) KeepElements(name ...string) {
 	items := make([]interface{}, 0, len(r.Children))
 	namemap := make(map[string]struct{}, len(name)*2)
@@ -219,6 +223,31 @@ type RunProperties struct {
 	Strike    *Strike
 }
 
+// Copy returns a deep copy of r
+func (r *RunProperties) Copy() *RunProperties {
+	if r == nil {
+		return nil
+	}
+	n := *r
+	n.Fonts = clonePtr(r.Fonts)
+	n.Bold = clonePtr(r.Bold)
+	n.ICs = clonePtr(r.ICs)
+	n.Italic = clonePtr(r.Italic)
+	n.Highlight = clonePtr(r.Highlight)
+	n.Color = clonePtr(r.Color)
+	n.Size = clonePtr(r.Size)
+	n.SizeCs = clonePtr(r.SizeCs)
+	n.Spacing = clonePtr(r.Spacing)
+	n.RunStyle = clonePtr(r.RunStyle)
+	n.Style = clonePtr(r.Style)
+	n.Shade = clonePtr(r.Shade)
+	n.Kern = clonePtr(r.Kern)
+	n.Underline = clonePtr(r.Underline)
+	n.VertAlign = clonePtr(r.VertAlign)
+	n.Strike = clonePtr(r.Strike)
+	return &n
+}
+
 // UnmarshalXML ...
 func (r *RunProperties) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 	for {
@@ -240,11 +269,11 @@ func (r *RunProperties) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 				}
 				r.Fonts = &value
 			case "b":
-				r.Bold = &Bold{}
+				r.Bold = &Bold{Val: getAtt(tt.Attr, "val")}
 			case "iCs":
 				r.ICs = &struct{}{}
 			case "i":
-				r.Italic = &Italic{}
+				r.Italic = &Italic{Val: getAtt(tt.Attr, "val")}
 			case "u":
 				var value Underline
 				value.Val = getAtt(tt.Attr, "val")
@@ -27,12 +27,30 @@ import (
 // SectPr show the properties of the document, like paper size
 type SectPr struct {
 	XMLName xml.Name `xml:"w:sectPr,omitempty"` // properties of the document, including paper size
+
+	HeaderReferences []*HeaderReference
+	FooterReferences []*FooterReference
+
 	PgSz    *PgSz    `xml:"w:pgSz,omitempty"`
 	PgMar   *PgMar   `xml:"w:pgMar,omitempty"`
 	Cols    *Cols    `xml:"w:cols,omitempty"`
 	DocGrid *DocGrid `xml:"w:docGrid,omitempty"`
 }
 
+// HeaderReference refers to a header part by its rId
+type HeaderReference struct {
+	XMLName xml.Name `xml:"w:headerReference,omitempty"`
+	Type    string   `xml:"w:type,attr"` // default, first or even
+	ID      string   `xml:"r:id,attr"`
+}
+
+// FooterReference refers to a footer part by its rId
+type FooterReference struct {
+	XMLName xml.Name `xml:"w:footerReference,omitempty"`
+	Type    string   `xml:"w:type,attr"` // default, first or even
+	ID      string   `xml:"r:id,attr"`
+}
+
 // PgSz show the paper size
 type PgSz struct {
 	W int `xml:"w:w,attr"` // width of paper
@@ -73,6 +91,24 @@ func (sect *SectPr) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 		}
 		if tt, ok := t.(xml.StartElement); ok {
 			switch tt.Name.Local {
+			case "headerReference":
+				sect.HeaderReferences = append(sect.HeaderReferences, &HeaderReference{
+					Type: getAtt(tt.Attr, "type"),
+					ID:   getAtt(tt.Attr, "id"),
+				})
+				err = d.Skip()
+				if err != nil {
+					return err
+				}
+			case "footerReference":
+				sect.FooterReferences = append(sect.FooterReferences, &FooterReference{
+					Type: getAtt(tt.Attr, "type"),
+					ID:   getAtt(tt.Attr, "id"),
+				})
+				err = d.Skip()
+				if err != nil {
+					return err
+				}
 			case "pgSz":
 				var value PgSz
 				err = d.DecodeElement(&value, &tt)
@@ -0,0 +1,241 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+// styleChildOrder is the sequence of <w:style> children in ECMA-376
+var styleChildOrder = map[string]int{
+	"name": 0, "aliases": 1, "basedOn": 2, "next": 3, "link": 4,
+	"autoRedefine": 5, "hidden": 6, "uiPriority": 7, "semiHidden": 8,
+	"unhideWhenUsed": 9, "qFormat": 10, "locked": 11, "personal": 12,
+	"personalCompose": 13, "personalReply": 14, "rsid": 15, "pPr": 16,
+	"rPr": 17, "tblPr": 18, "trPr": 19, "tcPr": 20, "tblStylePr": 21,
+}
+
+// Styles is word/styles.xml
+//
+// Only the style definitions are modelled, other parts
+// like docDefaults and latentStyles are kept as they were.
+type Styles struct {
+	Attrs  []xml.Attr // Attrs is the attributes of <w:styles>, including namespaces
+	Styles []*StyleDefinition
+
+	prefix []rawElement // prefix is docDefaults, latentStyles...
+}
+
+// UnmarshalXML ...
+func (s *Styles) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	s.Attrs = prefixedAttrs(start.Attr)
+	inner, err := decodeInnerXML(d, &start)
+	if err != nil {
+		return err
+	}
+	elems, err := splitRawElements(inner)
+	if err != nil {
+		return err
+	}
+	for _, e := range elems {
+		if e.Name != "style" {
+			s.prefix = append(s.prefix, e)
+			continue
+		}
+		var value StyleDefinition
+		err = xml.Unmarshal(StringToBytes(e.XML), &value)
+		if err != nil {
+			return err
+		}
+		s.Styles = append(s.Styles, &value)
+	}
+	return nil
+}
+
+// MarshalXML ...
+func (s *Styles) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	sb := strings.Builder{}
+	for _, r := range s.prefix {
+		sb.WriteString(r.XML)
+	}
+	for _, st := range s.Styles {
+		data, err := xml.Marshal(st)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+	}
+	attrs := s.Attrs
+	if len(attrs) == 0 {
+		attrs = []xml.Attr{{Name: xml.Name{Local: "xmlns:w"}, Value: XMLNS_W}}
+	}
+	return encodeRaw(e, xml.StartElement{Name: xml.Name{Local: "w:styles"}, Attr: attrs}, sb.String())
+}
+
+// Get the style definition by styleId, or nil on notfound
+func (s *Styles) Get(id string) *StyleDefinition {
+	for _, st := range s.Styles {
+		if st.StyleID == id {
+			return st
+		}
+	}
+	return nil
+}
+
+// GetByName gets the style definition by its name (case-insensitive), or nil on notfound
+func (s *Styles) GetByName(typ, name string) *StyleDefinition {
+	for _, st := range s.Styles {
+		if st.Type == typ && strings.EqualFold(st.Name, name) {
+			return st
+		}
+	}
+	return nil
+}
+
+// Default gets the default style definition of typ
+// (paragraph, character, table or numbering), or nil on notfound
+func (s *Styles) Default(typ string) *StyleDefinition {
+	for _, st := range s.Styles {
+		if st.Type == typ && st.Default {
+			return st
+		}
+	}
+	return nil
+}
+
+// StyleDefinition is <w:style> in word/styles.xml
+type StyleDefinition struct {
+	Type        string // Type is paragraph, character, table or numbering
+	StyleID     string
+	Default     bool
+	CustomStyle bool
+
+	Name    string
+	BasedOn string
+	Next    string
+	Link    string
+
+	elems []rawElement // elems is pPr, rPr... that are not modelled
+}
+
+// UnmarshalXML ...
+func (st *StyleDefinition) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "type":
+			st.Type = attr.Value
+		case "styleId":
+			st.StyleID = attr.Value
+		case "default":
+			st.Default = attr.Value == "1" || attr.Value == "true"
+		case "customStyle":
+			st.CustomStyle = attr.Value == "1" || attr.Value == "true"
+		default:
+			// ignore other attributes
+		}
+	}
+	inner, err := decodeInnerXML(d, &start)
+	if err != nil {
+		return err
+	}
+	elems, err := splitRawElements(inner)
+	if err != nil {
+		return err
+	}
+	for _, e := range elems {
+		switch e.Name {
+		case "name":
+			st.Name = getAtt(e.Attr, "val")
+		case "basedOn":
+			st.BasedOn = getAtt(e.Attr, "val")
+		case "next":
+			st.Next = getAtt(e.Attr, "val")
+		case "link":
+			st.Link = getAtt(e.Attr, "val")
+		default:
+			st.elems = append(st.elems, e)
+		}
+	}
+	return nil
+}
+
+// MarshalXML ...
+func (st *StyleDefinition) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	attrs := make([]xml.Attr, 0, 4)
+	if st.Type != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "w:type"}, Value: st.Type})
+	}
+	if st.Default {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "w:default"}, Value: "1"})
+	}
+	if st.CustomStyle {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "w:customStyle"}, Value: "1"})
+	}
+	attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "w:styleId"}, Value: st.StyleID})
+	return encodeRaw(e, xml.StartElement{Name: xml.Name{Local: "w:style"}, Attr: attrs}, st.inner())
+}
+
+// inner builds the children of <w:style> in schema order
+func (st *StyleDefinition) inner() string {
+	elems := make([]rawElement, 0, len(st.elems)+4)
+	elems = append(elems, st.elems...)
+	for _, kv := range [...][2]string{
+		{"name", st.Name}, {"basedOn", st.BasedOn}, {"next", st.Next}, {"link", st.Link},
+	} {
+		if kv[1] != "" {
+			elems = append(elems, rawElement{
+				Name: kv[0],
+				XML:  `<w:` + kv[0] + ` w:val="` + escapeAttr(kv[1]) + `"/>`,
+			})
+		}
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		return styleChildOrder[elems[i].Name] < styleChildOrder[elems[j].Name]
+	})
+	sb := strings.Builder{}
+	for _, e := range elems {
+		sb.WriteString(e.XML)
+	}
+	return sb.String()
+}
+
+// Copy returns a deep copy of the style definition
+func (st *StyleDefinition) Copy() *StyleDefinition {
+	nst := *st
+	nst.elems = make([]rawElement, len(st.elems))
+	copy(nst.elems, st.elems)
+	return &nst
+}
+
+// Copy returns a deep copy of the styles
+func (s *Styles) Copy() *Styles {
+	ns := &Styles{
+		Attrs:  append([]xml.Attr(nil), s.Attrs...),
+		Styles: make([]*StyleDefinition, len(s.Styles)),
+		prefix: append([]rawElement(nil), s.prefix...),
+	}
+	for i, st := range s.Styles {
+		ns.Styles[i] = st.Copy()
+	}
+	return ns
+}
@@ -0,0 +1,253 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const numbering_xml_1 = `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:abstractNum w:abstractNumId="0"><w:nsid w:val="1A2B3C4D"/><w:multiLevelType w:val="hybridMultilevel"/><w:lvl w:ilvl="0"><w:start w:val="3"/><w:numFmt w:val="decimal"/><w:lvlText w:val="%1."/></w:lvl></w:abstractNum><w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num></w:numbering>`
+
+const styles_xml_1 = `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:docDefaults><w:rPrDefault/></w:docDefaults><w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style><w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr></w:pPr><w:rPr><w:b/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/></w:style></w:styles>`
+
+func newStyledTestFile(t *testing.T) *Docx {
+	f := New().WithDefaultTheme()
+	f.styles = &Styles{}
+	err := xml.Unmarshal([]byte(styles_xml_1), f.styles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.numbering = &Numbering{}
+	err = xml.Unmarshal([]byte(numbering_xml_1), f.numbering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddParagraph().Style("Heading1").AddText("title")
+	f.AddParagraph().NumPr("1", "0").AddText("item")
+	f.AddParagraph().Style("Quote").AddText("quote")
+	return f
+}
+
+func TestStylesStructure(t *testing.T) {
+	var s Styles
+	err := xml.Unmarshal([]byte(styles_xml_1), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Styles) != 3 || s.Get("Heading1").BasedOn != "Normal" || s.Default("paragraph").StyleID != "Normal" {
+		t.Fatal("unexpected styles", s.Styles)
+	}
+	data, err := xml.Marshal(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != styles_xml_1 {
+		t.Fatal("styles changed after marshal:", string(data))
+	}
+	var n Numbering
+	err = xml.Unmarshal([]byte(numbering_xml_1), &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = xml.Marshal(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != numbering_xml_1 {
+		t.Fatal("numbering changed after marshal:", string(data))
+	}
+}
+
+func TestAppendFileWithOptions(t *testing.T) {
+	f := newStyledTestFile(t)
+	af := newStyledTestFile(t)
+	af.styles.Get("Quote").elems = []rawElement{{Name: "rPr", XML: `<w:rPr><w:i/></w:rPr>`}}
+
+	err := f.AppendFileWithOptions(af, AppendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.numbering.Nums) != 2 || len(f.numbering.AbstractNums) != 1 {
+		t.Fatal("expected 2 nums sharing 1 abstractNum but has", len(f.numbering.Nums), len(f.numbering.AbstractNums))
+	}
+	if !strings.Contains(f.numbering.Nums[1].elems[0].XML, `<w:startOverride w:val="3"/>`) {
+		t.Fatal("appended list is not restarted")
+	}
+	if v := f.Document.Body.Items[4].(*Paragraph).Properties.NumProperties.NumID.Val; v != "2" {
+		t.Fatal("expected numId 2 but has", v)
+	}
+	if len(f.styles.Styles) != 3 {
+		t.Fatal("expected no new styles but has", len(f.styles.Styles))
+	}
+
+	f = newStyledTestFile(t)
+	err = f.AppendFileWithOptions(af, AppendOptions{StyleMode: KeepSourceFormatting, ContinueNumbering: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := f.Document.Body.Items[5].(*Paragraph).Properties.Style.Val; v != "Quote_1" {
+		t.Fatal("expected Quote_1 but has", v)
+	}
+	if st := f.styles.Get("Heading1_1"); st == nil || st.BasedOn != "Normal_1" || !strings.Contains(st.inner(), `<w:numId w:val="2"/>`) {
+		t.Fatal("unexpected imported style", st)
+	}
+	if len(f.numbering.Nums[1].elems) != 0 {
+		t.Fatal("continued list should not be overridden")
+	}
+	if af.Document.Body.Items[2].(*Paragraph).Properties.Style.Val != "Quote" {
+		t.Fatal("source file has been modified")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nf, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := nf.Numbering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Nums) != 2 {
+		t.Fatal("expected 2 nums after reparsing but has", len(n.Nums))
+	}
+}
+
+func TestAppendFileNestedAndLinked(t *testing.T) {
+	f := newStyledTestFile(t)
+	af := newStyledTestFile(t)
+	a := af.numbering.AbstractNums[0]
+	a.Nsid = "0000FFFF"
+	a.elems = append(a.elems, rawElement{Name: "styleLink", Attr: []xml.Attr{{Name: xml.Name{Space: "w", Local: "val"}, Value: "Quote"}}, XML: `<w:styleLink w:val="Quote"/>`})
+	tbl := af.AddTable(1, 1, 0, nil)
+	inner := af.AddTable(1, 1, 0, nil)
+	af.Document.Body.Items = af.Document.Body.Items[:len(af.Document.Body.Items)-1]
+	tbl.TableRows[0].TableCells[0].Tables = append(tbl.TableRows[0].TableCells[0].Tables, inner)
+	inner.TableRows[0].TableCells[0].AddParagraph().Style("Quote").NumPr("1", "0").AddText("nested")
+
+	err := f.AppendFileWithOptions(af, AppendOptions{StyleMode: KeepSourceFormatting})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nt := f.Document.Body.Items[len(f.Document.Body.Items)-1].(*Table).TableRows[0].TableCells[0].Tables[0]
+	np := nt.TableRows[0].TableCells[0].Paragraphs[0]
+	if np.Properties.Style.Val != "Quote_1" || np.Properties.NumProperties.NumID.Val != "2" {
+		t.Fatal("nested table not remapped", np.Properties.Style.Val, np.Properties.NumProperties.NumID.Val)
+	}
+	if nt == inner || inner.TableRows[0].TableCells[0].Paragraphs[0].Properties.Style.Val != "Quote" {
+		t.Fatal("source file has been modified")
+	}
+	na := f.numbering.AbstractNums[len(f.numbering.AbstractNums)-1]
+	if len(f.numbering.AbstractNums) != 2 || !strings.Contains(na.elems[len(na.elems)-1].XML, `<w:styleLink w:val="Quote_1"/>`) {
+		t.Fatal("styleLink not remapped", na.elems)
+	}
+}
+
+func TestReadOnlyStyles(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	_, err := newStyledTestFile(t).WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.HeadingLevel(f.Document.Body.Items[0].(*Paragraph)) != 1 {
+		t.Fatal("unexpected heading level")
+	}
+	if _, _, numFmt := f.ListLevel(f.Document.Body.Items[1].(*Paragraph)); numFmt != "decimal" {
+		t.Fatal("unexpected numFmt", numFmt)
+	}
+	err = f.WriteMarkdownTo(bytes.NewBuffer(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.styles != nil || f.numbering != nil {
+		t.Fatal("styles or numbering loaded for reading")
+	}
+	nf := New().WithDefaultTheme()
+	nf.AddParagraph().AddText("plain")
+	nf.HeadingLevel(nf.Document.Body.Items[0].(*Paragraph))
+	nf.ListLevel(nf.Document.Body.Items[0].(*Paragraph))
+	buf.Reset()
+	_, err = nf.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("word/numbering.xml")) {
+		t.Fatal("unexpected numbering part")
+	}
+	s, err := f.Styles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != f.styles || f.cachedStyles != nil {
+		t.Fatal("cached styles not taken over")
+	}
+}
+
+func TestAppendFileWithOptionsError(t *testing.T) {
+	f := newStyledTestFile(t)
+	af := newStyledTestFile(t)
+	af.styles = nil
+	af.parts = map[string][]byte{"word/styles.xml": []byte("<w:styles")}
+	n := len(f.Document.Body.Items)
+	if f.AppendFileWithOptions(af, AppendOptions{}) == nil {
+		t.Fatal("unexpected success")
+	}
+	if len(f.numbering.Nums) != 1 || len(f.Document.Body.Items) != n {
+		t.Fatal("f changed on error")
+	}
+	f.AppendFile(af)
+	if len(f.numbering.Nums) != 1 || len(f.Document.Body.Items) != 2*n {
+		t.Fatal("unexpected fallback")
+	}
+}
+
+func TestAddListNumberingStarts(t *testing.T) {
+	f, err := FromMarkdown([]byte("3. three\n\n   5. five\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := f.Numbering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Nums) != 2 {
+		t.Fatal("unexpected nums", len(n.Nums))
+	}
+	for i, exp := range [][2]string{{`w:ilvl="0"><w:startOverride w:val="3"`, `w:ilvl="1"><w:startOverride w:val="1"`}, {`w:ilvl="0"><w:startOverride w:val="1"`, `w:ilvl="1"><w:startOverride w:val="5"`}} {
+		sb := strings.Builder{}
+		for _, e := range n.Nums[i].elems {
+			sb.WriteString(e.XML)
+		}
+		if !strings.Contains(sb.String(), exp[0]) || !strings.Contains(sb.String(), exp[1]) {
+			t.Fatal("unexpected overrides of list", i, sb.String())
+		}
+	}
+}
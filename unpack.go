@@ -22,9 +22,9 @@ package docx
 
 import (
 	"archive/zip"
-	"encoding/xml"
 	"errors"
 	"io"
+	"io/fs"
 	"strconv"
 	"strings"
 )
@@ -37,50 +37,69 @@ import (
 //  3. Media
 //
 // Then it stores all other files into tmpfslist for packing.
-func unpack(zipReader *zip.Reader) (docx *Docx, err error) {
+//
+// The entries and parts are limited by opt if not nil.
+func unpack(zipReader *zip.Reader, opt *ParseOptions) (docx *Docx, err error) {
+	if opt != nil {
+		err = opt.checkZip(zipReader)
+		if err != nil {
+			return
+		}
+	}
+	names := make([]string, 0, len(zipReader.File))
+	for _, f := range zipReader.File {
+		if !strings.HasSuffix(f.Name, "/") {
+			names = append(names, f.Name)
+		}
+	}
+	return unpackFS(zipReader, names, opt)
+}
+
+// unpackFS does the same as unpack on the files names in fsys
+func unpackFS(fsys fs.FS, names []string, opt *ParseOptions) (docx *Docx, err error) {
 	docx = new(Docx)
+	docx.limits = opt
 	docx.mediaNameIdx = make(map[string]int, 64)
 	docx.slowIDs = make(map[string]uintptr, 64)
-	docx.tmplfs = zipReader
+	docx.tmplfs = fsys
 	docx.tmpfslst = make([]string, 0, 64)
-	for _, f := range zipReader.File {
-		if f.Name == "word/_rels/document.xml.rels" {
-			err = docx.parseDocRelation(f)
-			if err != nil {
-				return
-			}
+	for _, name := range names {
+		var parse func(string, io.Reader) error
+		switch {
+		case name == "word/_rels/document.xml.rels":
+			parse = docx.parseDocRelation
+		case name == "word/document.xml":
+			parse = docx.parseDocument
+		case strings.HasPrefix(name, MEDIA_FOLDER):
+			docx.parseMedia(name, fsys)
 			continue
-		}
-		if f.Name == "word/document.xml" {
-			err = docx.parseDocument(f)
-			if err != nil {
-				return
-			}
+		default:
+			// fill remaining files into tmpfslst
+			docx.tmpfslst = append(docx.tmpfslst, name)
 			continue
 		}
-		if strings.HasPrefix(f.Name, MEDIA_FOLDER) {
-			err = docx.parseMedia(f)
-			if err != nil {
-				return
-			}
-			continue
+		err = docx.parseFile(fsys, name, parse)
+		if err != nil {
+			return
 		}
-		// fill remaining files into tmpfslst
-		docx.tmpfslst = append(docx.tmpfslst, f.Name)
 	}
 	//TODO: find last imageID
 	docx.imageID = 100000
 	return
 }
 
-// parseDocument processes one of the relevant files, the one with the actual document
-func (f *Docx) parseDocument(file *zip.File) error {
-	zf, err := file.Open()
+// parseFile opens name in fsys for parse within the limits of f
+func (f *Docx) parseFile(fsys fs.FS, name string, parse func(string, io.Reader) error) error {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return err
 	}
-	defer zf.Close()
+	defer file.Close()
+	return parse(name, f.limits.limitReader(name, file))
+}
 
+// parseDocument processes one of the relevant files, the one with the actual document
+func (f *Docx) parseDocument(name string, zf io.Reader) error {
 	f.Document.XMLW = XMLNS_W
 	f.Document.XMLR = XMLNS_R
 	f.Document.XMLWP = XMLNS_WP
@@ -97,20 +116,13 @@ func (f *Docx) parseDocument(file *zip.File) error {
 	f.Document.Body.file = f
 	//TODO: find last docID
 	f.docID = 100000
-	err = xml.NewDecoder(zf).Decode(&f.Document)
-	return err
+	return f.limits.newDecoder(name, zf).Decode(&f.Document)
 }
 
 // parseDocRelation processes one of the relevant files, the one with the relationships
-func (f *Docx) parseDocRelation(file *zip.File) error {
-	zf, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer zf.Close()
-
+func (f *Docx) parseDocRelation(name string, zf io.Reader) error {
 	f.docRelation.Xmlns = XMLNS_R
-	err = xml.NewDecoder(zf).Decode(&f.docRelation)
+	err := f.limits.newDecoder(name, zf).Decode(&f.docRelation)
 	if err != nil {
 		return err
 	}
@@ -129,18 +141,7 @@ func (f *Docx) parseDocRelation(file *zip.File) error {
 	return nil
 }
 
-// parseMedia add the media into Docx struct
-func (f *Docx) parseMedia(file *zip.File) error {
-	name := file.Name[len(MEDIA_FOLDER):]
-	zf, err := file.Open()
-	if err != nil {
-		return err
-	}
-	data, err := io.ReadAll(zf)
-	if err != nil {
-		return err
-	}
-	f.mediaNameIdx[name] = len(f.media)
-	f.media = append(f.media, Media{Name: name, Data: data})
-	return zf.Close()
+// parseMedia add the media into Docx struct, which is loaded on demand
+func (f *Docx) parseMedia(name string, fsys fs.FS) {
+	f.addMedia(Media{Name: name[len(MEDIA_FOLDER):], src: fsys, limits: f.limits})
 }
@@ -0,0 +1,376 @@
+/*
+   Copyright (c) 2020 gingfrederik
+   Copyright (c) 2021 Gonzalo Fernandez-Victorio
+   Copyright (c) 2021 Basement Crowd Ltd (https://www.basementcrowd.com)
+   Copyright (c) 2023 Fumiama Minamoto (源文雨)
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published
+   by the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docx
+
+// WalkAction tells Walk what to do after visiting a node
+type WalkAction uint8
+
+const (
+	// WalkContinue goes on walking
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren skips the children of the node, only valid in Enter
+	WalkSkipChildren
+	// WalkStop stops the whole walk
+	WalkStop
+)
+
+// Visitor is called on entering and leaving each node by Walk
+type Visitor interface {
+	Enter(c *Cursor) WalkAction
+	Leave(c *Cursor) WalkAction
+}
+
+// VisitorFuncs is a Visitor made of funcs, either of which can be nil
+type VisitorFuncs struct {
+	EnterFunc func(c *Cursor) WalkAction
+	LeaveFunc func(c *Cursor) WalkAction
+}
+
+// Enter calls EnterFunc if not nil
+func (v VisitorFuncs) Enter(c *Cursor) WalkAction {
+	if v.EnterFunc == nil {
+		return WalkContinue
+	}
+	return v.EnterFunc(c)
+}
+
+// Leave calls LeaveFunc if not nil
+func (v VisitorFuncs) Leave(c *Cursor) WalkAction {
+	if v.LeaveFunc == nil {
+		return WalkContinue
+	}
+	return v.LeaveFunc(c)
+}
+
+// Cursor describes the node being visited and its position
+type Cursor struct {
+	w        *walker
+	node     interface{}
+	index    int
+	inslice  bool
+	replaced bool
+	repl     []interface{}
+}
+
+// Node is the node being visited, such as *Paragraph, *Run or *Text
+func (c *Cursor) Node() interface{} {
+	return c.node
+}
+
+// Parent is the direct parent of the node, or nil for the root
+func (c *Cursor) Parent() interface{} {
+	if len(c.w.parents) == 0 {
+		return nil
+	}
+	return c.w.parents[len(c.w.parents)-1]
+}
+
+// Parents is the stack of the parents, from the root to the direct parent
+func (c *Cursor) Parents() []interface{} {
+	return append([]interface{}(nil), c.w.parents...)
+}
+
+// Index is the index of the node in the children slice of its parent,
+// or -1 if the node is not in a slice (like the Run of a Hyperlink)
+func (c *Cursor) Index() int {
+	if !c.inslice {
+		return -1
+	}
+	return c.index
+}
+
+// Indices is the index path from the root to the node,
+// in which -1 means the node is not in a slice.
+func (c *Cursor) Indices() []int {
+	return append(append(make([]int, 0, len(c.w.indices)+1), c.w.indices...), c.Index())
+}
+
+// Part is the name of the part being walked, like word/document.xml
+// or word/header1.xml. It is empty when walking by WalkNode.
+func (c *Cursor) Part() string {
+	return c.w.part
+}
+
+// Replace replaces the node with nodes, which will not be walked.
+// Nodes of types that cannot be held by the parent are dropped.
+// It returns false if the node is not in a slice and cannot be replaced.
+//
+// If called in Enter, the children of the node will be skipped
+// and Leave will not be called.
+func (c *Cursor) Replace(nodes ...interface{}) bool {
+	if !c.inslice {
+		return false
+	}
+	c.replaced = true
+	c.repl = nodes
+	return true
+}
+
+// Delete removes the node from its parent, the same as Replace()
+func (c *Cursor) Delete() bool {
+	return c.Replace()
+}
+
+// Walk traverses the body, headers, footers, footnotes and comments of f
+// in depth-first order, including the paragraphs in table cells,
+// text boxes, groups and canvases.
+//
+// Headers, footers, footnotes and comments are parsed on need, and will be
+// written back on packing only if they are changed.
+func Walk(f *Docx, v Visitor) error {
+	w := walker{v: v, part: "word/document.xml"}
+	w.visit(&f.Document.Body, 0, false)
+	if w.stopped {
+		return nil
+	}
+	headers, err := f.Headers()
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		w.part = h.name
+		w.visit(h, 0, false)
+		if w.stopped {
+			return nil
+		}
+	}
+	footers, err := f.Footers()
+	if err != nil {
+		return err
+	}
+	for _, h := range footers {
+		w.part = h.name
+		w.visit(h, 0, false)
+		if w.stopped {
+			return nil
+		}
+	}
+	footnotes, err := f.Footnotes()
+	if err != nil {
+		return err
+	}
+	if footnotes != nil {
+		w.part = footnotes.name
+		w.visit(footnotes, 0, false)
+		if w.stopped {
+			return nil
+		}
+	}
+	comments, err := f.Comments()
+	if err != nil {
+		return err
+	}
+	if comments != nil {
+		w.part = comments.name
+		w.visit(comments, 0, false)
+	}
+	return nil
+}
+
+// WalkNode traverses node and its children in depth-first order
+func WalkNode(node interface{}, v Visitor) {
+	w := walker{v: v}
+	w.visit(node, 0, false)
+}
+
+type walker struct {
+	v       Visitor
+	part    string
+	parents []interface{}
+	indices []int
+	stopped bool
+}
+
+// visit visits node and its children, and returns its replacement if any
+func (w *walker) visit(node interface{}, index int, inslice bool) (repl []interface{}, replaced bool) {
+	c := Cursor{w: w, node: node, index: index, inslice: inslice}
+	act := w.v.Enter(&c)
+	if act == WalkStop {
+		w.stopped = true
+	}
+	if c.replaced || w.stopped {
+		return c.repl, c.replaced
+	}
+	if act != WalkSkipChildren {
+		w.parents = append(w.parents, node)
+		w.indices = append(w.indices, c.Index())
+		w.children(node)
+		w.parents = w.parents[:len(w.parents)-1]
+		w.indices = w.indices[:len(w.indices)-1]
+		if w.stopped {
+			return nil, false
+		}
+	}
+	if w.v.Leave(&c) == WalkStop {
+		w.stopped = true
+	}
+	return c.repl, c.replaced
+}
+
+// children walks the children of node
+func (w *walker) children(node interface{}) {
+	switch o := node.(type) {
+	case *Body:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *Header:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *Footer:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *Footnotes:
+		o.Footnotes = walkSlice(w, o.Footnotes, valueNode[*Footnote], as[*Footnote])
+	case *Footnote:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *Comments:
+		o.Comments = walkSlice(w, o.Comments, valueNode[*Comment], as[*Comment])
+	case *Comment:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *Paragraph:
+		o.Children = walkSlice(w, o.Children, valueNode[interface{}], as[interface{}])
+	case *Run:
+		o.Children = walkSlice(w, o.Children, valueNode[interface{}], as[interface{}])
+	case *Hyperlink:
+		w.visit(&o.Run, 0, false)
+	case *Table:
+		o.TableRows = walkSlice(w, o.TableRows, valueNode[*WTableRow], as[*WTableRow])
+	case *WTableRow:
+		o.TableCells = walkSlice(w, o.TableCells, valueNode[*WTableCell], as[*WTableCell])
+	case *WTableCell:
+		o.Paragraphs = walkSlice(w, o.Paragraphs, valueNode[*Paragraph], as[*Paragraph])
+		if !w.stopped {
+			o.Tables = walkSlice(w, o.Tables, valueNode[*Table], as[*Table])
+		}
+	case *Drawing:
+		if o.Inline != nil {
+			w.visit(o.Inline, 0, false)
+		}
+		if o.Anchor != nil && !w.stopped {
+			w.visit(o.Anchor, 0, false)
+		}
+	case *WPInline:
+		w.graphic(o.Graphic)
+	case *WPAnchor:
+		w.graphic(o.Graphic)
+	case *WordprocessingShape:
+		if o.TextBox != nil && o.TextBox.Content != nil {
+			o.TextBox.Content.Paragraphs = walkSlice(w, o.TextBox.Content.Paragraphs, pointerNode[Paragraph], asValue[Paragraph])
+		}
+	case *WordprocessingCanvas:
+		o.Items = walkSlice(w, o.Items, valueNode[interface{}], as[interface{}])
+	case *WordprocessingGroup:
+		o.Elems = walkSlice(w, o.Elems, valueNode[interface{}], as[interface{}])
+	case *WPGGroupShape:
+		o.Elems = walkSlice(w, o.Elems, valueNode[interface{}], as[interface{}])
+	}
+}
+
+// graphic walks the picture, shape, canvas or group in g
+func (w *walker) graphic(g *AGraphic) {
+	if g == nil || g.GraphicData == nil {
+		return
+	}
+	for _, n := range [...]interface{}{g.GraphicData.Pic, g.GraphicData.Shape, g.GraphicData.Canvas, g.GraphicData.Group} {
+		if w.stopped {
+			return
+		}
+		switch o := n.(type) {
+		case *Picture:
+			if o == nil {
+				continue
+			}
+		case *WordprocessingShape:
+			if o == nil {
+				continue
+			}
+		case *WordprocessingCanvas:
+			if o == nil {
+				continue
+			}
+		case *WordprocessingGroup:
+			if o == nil {
+				continue
+			}
+		}
+		w.visit(n, 0, false)
+	}
+}
+
+// walkSlice visits each element of s and applies the replacements,
+// only allocating a new slice if anything is replaced.
+func walkSlice[T any](w *walker, s []T, node func(*T) interface{}, conv func(interface{}) (T, bool)) []T {
+	var out []T
+	for i := range s {
+		if w.stopped {
+			if out != nil {
+				out = append(out, s[i:]...)
+			}
+			break
+		}
+		idx := i
+		if out != nil {
+			idx = len(out)
+		}
+		repl, replaced := w.visit(node(&s[i]), idx, true)
+		if !replaced {
+			if out != nil {
+				out = append(out, s[i])
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]T, i, len(s)+len(repl))
+			copy(out, s[:i])
+		}
+		for _, r := range repl {
+			if v, ok := conv(r); ok {
+				out = append(out, v)
+			}
+		}
+	}
+	if out == nil {
+		return s
+	}
+	return out
+}
+
+// valueNode is the node of slices holding pointers or interfaces
+func valueNode[T any](p *T) interface{} {
+	return *p
+}
+
+// pointerNode is the node of slices holding structs
+func pointerNode[T any](p *T) interface{} {
+	return p
+}
+
+// as converts x to T
+func as[T any](x interface{}) (T, bool) {
+	v, ok := x.(T)
+	return v, ok
+}
+
+// asValue converts x of T or *T to T
+func asValue[T any](x interface{}) (T, bool) {
+	if p, ok := x.(*T); ok && p != nil {
+		return *p, true
+	}
+	v, ok := x.(T)
+	return v, ok
+}
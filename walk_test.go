@@ -0,0 +1,129 @@
+package docx
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const decoded_hdr_1 = `<w:hdr xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:p><w:r><w:t>header text</w:t></w:r></w:p></w:hdr>`
+
+func TestWalk(t *testing.T) {
+	f := New().WithDefaultTheme()
+	f.AddParagraph().AddText("body text")
+	f.AddParagraph().AddText("to be deleted")
+	f.AddParagraph().AddLink("link text", "https://example.com")
+	tbl := f.AddTable(1, 1, 0, nil)
+	tbl.TableRows[0].TableCells[0].AddParagraph().AddText("cell text")
+	r := f.AddParagraph().AddInlineShape(100, 100, "box", "auto", "rect", nil)
+	box := &Paragraph{file: f}
+	box.AddText("box text")
+	r.Children[0].(*Drawing).Inline.Graphic.GraphicData.Shape.TextBox = &WPSTextBox{
+		Content: &WTextBoxContent{Paragraphs: []Paragraph{*box}},
+	}
+	var h Header
+	err := xml.Unmarshal(StringToBytes(decoded_hdr_1), &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.name = "word/header1.xml"
+	f.headers = []*Header{&h}
+
+	var texts []string
+	depth, maxdepth := 0, 0
+	err = Walk(f, VisitorFuncs{
+		EnterFunc: func(c *Cursor) WalkAction {
+			depth++
+			if depth > maxdepth {
+				maxdepth = depth
+			}
+			if len(c.Parents()) != depth-1 {
+				t.Fatal("unexpected parents", len(c.Parents()), depth-1)
+			}
+			switch o := c.Node().(type) {
+			case *Paragraph:
+				if strings.Contains(o.String(), "deleted") {
+					if !c.Delete() {
+						t.Fatal("cannot delete paragraph")
+					}
+					depth--
+				}
+			case *Text:
+				texts = append(texts, c.Part()+":"+o.Text)
+				if o.Text == "body text" {
+					c.Replace(&Text{Text: "new text"})
+					depth--
+				}
+			}
+			return WalkContinue
+		},
+		LeaveFunc: func(c *Cursor) WalkAction {
+			depth--
+			return WalkContinue
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth != 0 {
+		t.Fatal("enter and leave mismatch", depth)
+	}
+	exp := []string{
+		"word/document.xml:body text",
+		"word/document.xml:cell text",
+		"word/document.xml:box text",
+		"word/header1.xml:header text",
+	}
+	if strings.Join(texts, "\n") != strings.Join(exp, "\n") {
+		t.Fatal("unexpected texts", texts)
+	}
+	if len(f.Document.Body.Items) != 4 {
+		t.Fatal("paragraph not deleted", len(f.Document.Body.Items))
+	}
+	if f.Document.Body.Items[0].(*Paragraph).String() != "new text" {
+		t.Fatal("text not replaced", f.Document.Body.Items[0].(*Paragraph).String())
+	}
+
+	n := 0
+	WalkNode(&f.Document.Body, VisitorFuncs{EnterFunc: func(c *Cursor) WalkAction {
+		if _, ok := c.Node().(*Table); ok {
+			return WalkSkipChildren
+		}
+		if _, ok := c.Node().(*Text); ok {
+			n++
+			if n == 2 {
+				return WalkStop
+			}
+		}
+		return WalkContinue
+	}})
+	if n != 2 {
+		t.Fatal("unexpected text count", n)
+	}
+}
+
+func TestPartTarget(t *testing.T) {
+	for _, c := range [][3]string{
+		{"word/document.xml", "header1.xml", "word/header1.xml"},
+		{"word/document.xml", "/word/header1.xml", "word/header1.xml"},
+		{"word/document.xml", "../customXml/item1.xml", "customXml/item1.xml"},
+		{"word/document.xml", "../../x.xml", "x.xml"},
+		{"word/header1.xml", "media/image1.png", "word/media/image1.png"},
+		{"", "word/document.xml", "word/document.xml"},
+	} {
+		if name := partTarget(c[0], c[1]); name != c[2] {
+			t.Fatal("unexpected name of", c[1], name)
+		}
+	}
+	f := New().WithDefaultTheme()
+	f.parts = map[string][]byte{"word/header9.xml": []byte(decoded_hdr_1)}
+	f.addRelation(REL_HEADER, "/word/header9.xml")
+	hs, err := f.Headers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := hs[len(hs)-1]
+	if h.name != "word/header9.xml" || len(h.Items) != 1 {
+		t.Fatal("unexpected header", h.name, len(h.Items))
+	}
+}